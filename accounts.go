@@ -0,0 +1,467 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/nikola43/aureo-vpn-client/internal/config"
+	"github.com/nikola43/aureo-vpn-client/internal/models"
+	"github.com/nikola43/aureo-vpn-client/internal/secrets"
+)
+
+const (
+	// accountsFile holds the saved accounts; their tokens are in the
+	// credential store
+	accountsFile = "accounts.json"
+	// legacySessionFile held the single saved session of older versions
+	legacySessionFile = "session.json"
+)
+
+// savedAccounts is the content of the accounts file
+type savedAccounts struct {
+	// Active is the ID of the account restored at startup
+	Active   string        `json:"active"`
+	Accounts []SessionData `json:"accounts"`
+}
+
+// Account is a saved account, without its tokens
+type Account struct {
+	ID       string    `json:"id"`
+	APIURL   string    `json:"api_url"`
+	UserID   string    `json:"user_id"`
+	Email    string    `json:"email"`
+	Username string    `json:"username"`
+	Active   bool      `json:"active"`
+	LastUsed time.Time `json:"last_used"`
+}
+
+// sessionTokens is the secret part of a saved account
+type sessionTokens struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+}
+
+// accountID identifies an account by its API server and user, so the same
+// user on production and staging are separate accounts
+func accountID(apiURL, userID string) string {
+	return config.NormalizeAPIURL(apiURL) + "#" + userID
+}
+
+// tokensSecret names an account's tokens in the credential store. Account
+// IDs contain URLs, so they are hashed into a safe name.
+func tokensSecret(id string) string {
+	sum := sha256.Sum256([]byte(id))
+	return "account-" + hex.EncodeToString(sum[:16])
+}
+
+// storeTokens saves a session's tokens in the credential store
+func (a *App) storeTokens(session *SessionData) error {
+	if a.secrets == nil {
+		return fmt.Errorf("credential store not available")
+	}
+	secret, err := json.Marshal(sessionTokens{AccessToken: session.AccessToken, RefreshToken: session.RefreshToken})
+	if err != nil {
+		return fmt.Errorf("failed to marshal tokens: %w", err)
+	}
+	defer secrets.Buffer(secret).Wipe()
+
+	if err := a.secrets.Set(tokensSecret(accountID(session.APIURL, session.User.ID)), secret); err != nil {
+		return fmt.Errorf("failed to store tokens: %w", err)
+	}
+	return nil
+}
+
+// loadTokens reads a session's tokens from the credential store
+func (a *App) loadTokens(session *SessionData) error {
+	if a.secrets == nil {
+		return fmt.Errorf("credential store not available")
+	}
+	secret, err := a.secrets.Get(tokensSecret(accountID(session.APIURL, session.User.ID)))
+	if err != nil {
+		return fmt.Errorf("failed to read tokens: %w", err)
+	}
+	defer secrets.Buffer(secret).Wipe()
+
+	var tokens sessionTokens
+	if err := json.Unmarshal(secret, &tokens); err != nil {
+		return fmt.Errorf("failed to unmarshal tokens: %w", err)
+	}
+	session.AccessToken = tokens.AccessToken
+	session.RefreshToken = tokens.RefreshToken
+	return nil
+}
+
+// moveTokens moves tokens that older versions wrote to the accounts file
+// into the credential store
+func (a *App) moveTokens(accounts *savedAccounts) error {
+	moved := false
+	for i := range accounts.Accounts {
+		session := &accounts.Accounts[i]
+		if session.AccessToken == "" && session.RefreshToken == "" {
+			continue
+		}
+		if err := a.storeTokens(session); err != nil {
+			return err
+		}
+		moved = true
+	}
+	if !moved {
+		return nil
+	}
+	return a.saveAccounts(accounts)
+}
+
+// find returns the index of the account with the ID, or -1
+func (s *savedAccounts) find(id string) int {
+	for i, account := range s.Accounts {
+		if accountID(account.APIURL, account.User.ID) == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// loadAccounts reads the saved accounts, importing the session saved by
+// older versions
+func (a *App) loadAccounts() (*savedAccounts, error) {
+	if a.configDir == "" {
+		return nil, fmt.Errorf("config directory not set")
+	}
+
+	accounts := &savedAccounts{Accounts: []SessionData{}}
+	data, err := os.ReadFile(filepath.Join(a.configDir, accountsFile))
+	if os.IsNotExist(err) {
+		return a.importLegacySession(accounts)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read accounts file: %w", err)
+	}
+	if err := json.Unmarshal(data, accounts); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal accounts: %w", err)
+	}
+	if err := a.moveTokens(accounts); err != nil {
+		logger.Warn("failed to move tokens to the credential store", "error", err)
+	}
+	return accounts, nil
+}
+
+// importLegacySession moves the single session.json of older versions into
+// the accounts file as the active account
+func (a *App) importLegacySession(accounts *savedAccounts) (*savedAccounts, error) {
+	legacyFile := filepath.Join(a.configDir, legacySessionFile)
+	data, err := os.ReadFile(legacyFile)
+	if os.IsNotExist(err) {
+		return accounts, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read session file: %w", err)
+	}
+
+	var session SessionData
+	if err := json.Unmarshal(data, &session); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal session data: %w", err)
+	}
+	session.APIURL = config.NormalizeAPIURL(session.APIURL)
+	accounts.Accounts = append(accounts.Accounts, session)
+	accounts.Active = accountID(session.APIURL, session.User.ID)
+
+	if err := a.storeTokens(&session); err != nil {
+		return nil, err
+	}
+	if err := a.saveAccounts(accounts); err != nil {
+		return nil, err
+	}
+	// Older versions kept WireGuard keys by user alone
+	if a.keys != nil {
+		if err := a.keys.MoveAccount(session.User.ID, accounts.Active); err != nil {
+			logger.Warn("failed to move WireGuard keys to the account", "error", err)
+		}
+	}
+	if err := secrets.Shred(legacyFile); err != nil {
+		logger.Warn("failed to delete old session file", "error", err)
+	}
+	return accounts, nil
+}
+
+// saveAccounts writes the saved accounts without their tokens, atomically
+// and readable only by the user
+func (a *App) saveAccounts(accounts *savedAccounts) error {
+	stored := savedAccounts{Active: accounts.Active, Accounts: make([]SessionData, len(accounts.Accounts))}
+	for i, session := range accounts.Accounts {
+		session.AccessToken, session.RefreshToken = "", ""
+		stored.Accounts[i] = session
+	}
+	data, err := json.Marshal(stored)
+	if err != nil {
+		return fmt.Errorf("failed to marshal accounts: %w", err)
+	}
+
+	path := filepath.Join(a.configDir, accountsFile)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("failed to write accounts file: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to write accounts file: %w", err)
+	}
+	return nil
+}
+
+// saveSession saves the tokens of the signed-in account and makes it the
+// active one
+func (a *App) saveSession(accessToken, refreshToken, apiURL string, user models.User) error {
+	accounts, err := a.loadAccounts()
+	if err != nil {
+		return err
+	}
+
+	session := SessionData{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		User:         user,
+		APIURL:       config.NormalizeAPIURL(apiURL),
+		LastUsed:     time.Now(),
+	}
+	if err := a.storeTokens(&session); err != nil {
+		return err
+	}
+	id := accountID(session.APIURL, user.ID)
+	if i := accounts.find(id); i >= 0 {
+		accounts.Accounts[i] = session
+	} else {
+		accounts.Accounts = append(accounts.Accounts, session)
+	}
+	accounts.Active = id
+	return a.saveAccounts(accounts)
+}
+
+// loadSession returns the active account, or nil if there is none
+func (a *App) loadSession() (*SessionData, error) {
+	accounts, err := a.loadAccounts()
+	if err != nil {
+		return nil, err
+	}
+
+	i := accounts.find(accounts.Active)
+	if i < 0 {
+		return nil, nil
+	}
+	return &accounts.Accounts[i], nil
+}
+
+// deleteSession forgets the active account
+func (a *App) deleteSession() error {
+	if a.configDir == "" {
+		return nil
+	}
+
+	accounts, err := a.loadAccounts()
+	if err != nil {
+		return err
+	}
+	return a.removeAccount(accounts, accounts.Active)
+}
+
+// removeAccount forgets an account and saves the rest
+func (a *App) removeAccount(accounts *savedAccounts, id string) error {
+	i := accounts.find(id)
+	if i < 0 {
+		return nil
+	}
+	accounts.Accounts = append(accounts.Accounts[:i], accounts.Accounts[i+1:]...)
+	if accounts.Active == id {
+		accounts.Active = ""
+	}
+	if err := a.saveAccounts(accounts); err != nil {
+		return err
+	}
+	if a.secrets != nil {
+		if err := a.secrets.Delete(tokensSecret(id)); err != nil {
+			logger.Warn("failed to delete account tokens", "error", err)
+		}
+	}
+	return nil
+}
+
+// bindAccount verifies a saved account against its API server, refreshing
+// an expired access token, and makes the app talk to that server as that
+// account. The app is unchanged if the account is no longer signed in.
+func (a *App) bindAccount(session *SessionData) (*models.User, error) {
+	if session.AccessToken == "" {
+		if err := a.loadTokens(session); err != nil {
+			return nil, err
+		}
+	}
+	client, err := a.newAPIClient(session.APIURL)
+	if err != nil {
+		return nil, err
+	}
+	client.SetAccessToken(session.AccessToken)
+
+	user, err := client.GetUserProfile()
+	if err != nil && session.RefreshToken != "" {
+		if token, refreshErr := client.RefreshToken(session.RefreshToken); refreshErr == nil {
+			session.AccessToken = token
+			user, err = client.GetUserProfile()
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	a.apiClient = client
+	a.apiURL = config.NormalizeAPIURL(session.APIURL)
+	session.User = *user
+
+	// Record the refreshed token and profile
+	if err := a.saveSession(session.AccessToken, session.RefreshToken, session.APIURL, *user); err != nil {
+		logger.Warn("failed to save session", "error", err)
+	}
+	return user, nil
+}
+
+// accountResult is what CheckSavedSession and SwitchAccount return for a
+// restored account
+func (a *App) accountResult(session *SessionData) map[string]interface{} {
+	return map[string]interface{}{
+		"has_session":   true,
+		"account_id":    accountID(session.APIURL, session.User.ID),
+		"user":          session.User,
+		"access_token":  session.AccessToken,
+		"refresh_token": session.RefreshToken,
+		"api_url":       session.APIURL,
+	}
+}
+
+// ListAccounts returns the saved accounts, most recently used first
+func (a *App) ListAccounts() ([]Account, error) {
+	accounts, err := a.loadAccounts()
+	if err != nil {
+		return nil, err
+	}
+
+	list := make([]Account, 0, len(accounts.Accounts))
+	for _, session := range accounts.Accounts {
+		id := accountID(session.APIURL, session.User.ID)
+		list = append(list, Account{
+			ID:       id,
+			APIURL:   session.APIURL,
+			UserID:   session.User.ID,
+			Email:    session.User.Email,
+			Username: session.User.Username,
+			Active:   id == accounts.Active,
+			LastUsed: session.LastUsed,
+		})
+	}
+	sort.SliceStable(list, func(i, j int) bool {
+		return list[i].LastUsed.After(list[j].LastUsed)
+	})
+	return list, nil
+}
+
+// SwitchAccount signs in as a saved account. The VPN is disconnected first,
+// since its session belongs to the current account, and the API client is
+// rebound to the account's server. If the account is no longer signed in,
+// the current one stays active.
+func (a *App) SwitchAccount(id string) (map[string]interface{}, error) {
+	accounts, err := a.loadAccounts()
+	if err != nil {
+		return nil, err
+	}
+	i := accounts.find(id)
+	if i < 0 {
+		return nil, fmt.Errorf("account not found: %s", id)
+	}
+	session := accounts.Accounts[i]
+
+	if err := a.DisconnectVPN(); err != nil {
+		return nil, err
+	}
+	a.stopNodeStatus()
+
+	user, err := a.bindAccount(&session)
+	if err != nil {
+		if a.currentUser() != nil {
+			a.signedIn()
+		}
+		return nil, fmt.Errorf("failed to switch account, please sign in again: %w", err)
+	}
+	logger.Info("switched account", "api_url", session.APIURL, "user_id", user.ID)
+
+	a.signOut()
+	a.setUser(user)
+	a.signedIn()
+	return a.accountResult(&session), nil
+}
+
+// switchServer moves to another API server, whose accounts are separate
+// from the current one's. The VPN is disconnected and the account most
+// recently used on that server is signed in; without one the app is signed
+// out, keeping the current account saved to switch back to.
+func (a *App) switchServer(apiURL string) error {
+	client, err := a.newAPIClient(apiURL)
+	if err != nil {
+		return err
+	}
+
+	if err := a.DisconnectVPN(); err != nil {
+		return err
+	}
+	a.stopNodeStatus()
+	a.signOut()
+	a.apiURL = apiURL
+	a.apiClient = client
+
+	saved, err := a.loadAccounts()
+	if err != nil {
+		return err
+	}
+	var sessions []SessionData
+	for _, session := range saved.Accounts {
+		if session.APIURL == apiURL {
+			sessions = append(sessions, session)
+		}
+	}
+	sort.SliceStable(sessions, func(i, j int) bool {
+		return sessions[i].LastUsed.After(sessions[j].LastUsed)
+	})
+
+	for i := range sessions {
+		user, err := a.bindAccount(&sessions[i])
+		if err != nil {
+			logger.Info("saved account is no longer signed in", "api_url", apiURL, "error", err)
+			continue
+		}
+		logger.Info("switched account", "api_url", apiURL, "user_id", user.ID)
+		a.setUser(user)
+		a.signedIn()
+		return nil
+	}
+
+	// Nothing to restore at startup until the user signs in here
+	saved.Active = ""
+	return a.saveAccounts(saved)
+}
+
+// RemoveAccount forgets a saved account, signing out if it is the current one
+func (a *App) RemoveAccount(id string) error {
+	accounts, err := a.loadAccounts()
+	if err != nil {
+		return err
+	}
+	if accounts.find(id) < 0 {
+		return fmt.Errorf("account not found: %s", id)
+	}
+
+	if user := a.currentUser(); user != nil && id == accountID(a.apiURL, user.ID) {
+		return a.Logout()
+	}
+	return a.removeAccount(accounts, id)
+}
@@ -0,0 +1,229 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/nikola43/aureo-vpn-client/internal/config"
+	"github.com/nikola43/aureo-vpn-client/internal/pac"
+	"github.com/nikola43/aureo-vpn-client/internal/secrets"
+	"github.com/nikola43/aureo-vpn-client/internal/vpn"
+)
+
+// apiProxyPasswordSecret names the API proxy password in the credential store
+const apiProxyPasswordSecret = "api-proxy-password"
+
+const (
+	// pacFetchTimeout bounds a download of the PAC file
+	pacFetchTimeout = 10 * time.Second
+	// pacRefreshInterval is how long a PAC file is used before it is
+	// fetched again
+	pacRefreshInterval = time.Hour
+	// pacRetryInterval is how long requests go direct after the PAC file
+	// could not be fetched, as browsers do
+	pacRetryInterval = time.Minute
+)
+
+// apiProxy picks the proxy for API requests from the saved settings
+type apiProxy struct {
+	settings config.APIProxy
+	password string
+
+	mu        sync.Mutex
+	script    *pac.Script
+	fetchedAt time.Time
+	// fetchErr is why the PAC file last failed to load, if it did
+	fetchErr error
+}
+
+// proxy returns the proxy URL for req, or nil to connect directly
+func (p *apiProxy) proxy(req *http.Request) (*url.URL, error) {
+	var proxyURL *url.URL
+	switch p.settings.Mode {
+	case config.ProxyModeEnvironment:
+		return http.ProxyFromEnvironment(req)
+	case config.ProxyModeNone:
+		return nil, nil
+	case config.ProxyModeHTTP, config.ProxyModeSOCKS5:
+		proxyURL = &url.URL{Scheme: p.settings.Mode, Host: p.settings.Address}
+	case config.ProxyModePAC:
+		script := p.pacScript(req.Context())
+		if script == nil {
+			return nil, nil
+		}
+		result, err := script.FindProxyForURL(req.URL)
+		if err != nil {
+			logger.Warn("PAC file failed, connecting directly", "error", err)
+			return nil, nil
+		}
+		proxyURL, err = pac.Proxy(result)
+		if err != nil || proxyURL == nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unknown proxy mode: %q", p.settings.Mode)
+	}
+
+	if p.settings.Username != "" {
+		proxyURL.User = url.UserPassword(p.settings.Username, p.password)
+	}
+	return proxyURL, nil
+}
+
+// pacScript returns the PAC file, fetching it when it is missing or stale.
+// It returns nil while the file cannot be fetched.
+func (p *apiProxy) pacScript(ctx context.Context) *pac.Script {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	interval := pacRefreshInterval
+	if p.script == nil {
+		interval = pacRetryInterval
+	}
+	if !p.fetchedAt.IsZero() && time.Since(p.fetchedAt) < interval {
+		return p.script
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, pacFetchTimeout)
+	defer cancel()
+	p.fetchedAt = time.Now()
+	script, err := pac.Fetch(ctx, p.settings.PACURL)
+	p.fetchErr = err
+	if err != nil {
+		// Keep using a stale file rather than going direct
+		logger.Warn("failed to fetch PAC file", "url", p.settings.PACURL, "error", err)
+		return p.script
+	}
+	p.script = script
+	return script
+}
+
+// setScript records a PAC file fetched when the settings were saved
+func (p *apiProxy) setScript(script *pac.Script) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.script = script
+	p.fetchedAt = time.Now()
+	p.fetchErr = nil
+}
+
+// pacError returns why the PAC file last failed to load, or ""
+func (p *apiProxy) pacError() string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.fetchErr == nil {
+		return ""
+	}
+	return p.fetchErr.Error()
+}
+
+// proxyForAPI picks the proxy for an API request. While a full tunnel is
+// up the request goes out through it instead, unless the user opted out.
+func (a *App) proxyForAPI(req *http.Request) (*url.URL, error) {
+	p := a.apiProxy.Load()
+	if p == nil {
+		return http.ProxyFromEnvironment(req)
+	}
+	if p.settings.BypassWhenConnected && a.fullTunnel.Load() {
+		return nil, nil
+	}
+	return p.proxy(req)
+}
+
+// loadAPIProxy reads the proxy settings and password for API requests
+func (a *App) loadAPIProxy() error {
+	p := &apiProxy{settings: a.settings.APIProxy}
+	if p.settings.Username != "" {
+		password, err := a.secrets.Get(apiProxyPasswordSecret)
+		if err != nil && !errors.Is(err, secrets.ErrNotFound) {
+			return fmt.Errorf("failed to read proxy password: %w", err)
+		}
+		p.password = string(password)
+		secrets.Buffer(password).Wipe()
+	}
+	a.apiProxy.Store(p)
+	if a.apiClient != nil {
+		a.apiClient.CloseIdleConnections()
+	}
+	return nil
+}
+
+// setFullTunnel records whether the connected tunnel carries all of the
+// host's traffic, including API requests. Only a system tunnel without
+// split tunneling does; a userspace tunnel only carries what is dialed
+// through it.
+func (a *App) setFullTunnel(tunnel vpn.Tunnel) {
+	full := false
+	if tunnel != nil && !a.splitTunnel {
+		_, userspace := tunnel.(vpn.Dialer)
+		full = !userspace
+	}
+	if a.fullTunnel.Swap(full) != full && a.apiClient != nil {
+		// Kept-alive connections still go through the old route
+		a.apiClient.CloseIdleConnections()
+	}
+}
+
+// GetAPIProxy returns the proxy settings for API requests, whether a
+// password is saved and why the PAC file failed to load, if it did
+func (a *App) GetAPIProxy() map[string]interface{} {
+	_, err := a.secrets.Get(apiProxyPasswordSecret)
+	result := map[string]interface{}{
+		"proxy":        a.settings.APIProxy,
+		"has_password": err == nil,
+	}
+	if p := a.apiProxy.Load(); p != nil {
+		if pacErr := p.pacError(); pacErr != "" {
+			result["pac_error"] = pacErr
+		}
+	}
+	return result
+}
+
+// SetAPIProxy saves the proxy settings for API requests. An empty password
+// keeps the saved one; clearing the username deletes it. A PAC file is
+// fetched and compiled first, so a broken one is reported rather than
+// sending requests direct.
+func (a *App) SetAPIProxy(proxy config.APIProxy, password string) error {
+	if err := proxy.Validate(); err != nil {
+		return err
+	}
+
+	var script *pac.Script
+	if proxy.Mode == config.ProxyModePAC {
+		ctx, cancel := context.WithTimeout(a.ctx, pacFetchTimeout)
+		defer cancel()
+		var err error
+		if script, err = pac.Fetch(ctx, proxy.PACURL); err != nil {
+			return err
+		}
+	}
+
+	switch {
+	case proxy.Username == "":
+		if err := a.secrets.Delete(apiProxyPasswordSecret); err != nil && !errors.Is(err, secrets.ErrNotFound) {
+			return fmt.Errorf("failed to delete proxy password: %w", err)
+		}
+	case password != "":
+		if err := a.secrets.Set(apiProxyPasswordSecret, []byte(password)); err != nil {
+			return fmt.Errorf("failed to save proxy password: %w", err)
+		}
+	}
+
+	a.settings.APIProxy = proxy
+	if err := a.settings.Save(a.configDir); err != nil {
+		return err
+	}
+	if err := a.loadAPIProxy(); err != nil {
+		return err
+	}
+	if script != nil {
+		a.apiProxy.Load().setScript(script)
+	}
+	return nil
+}
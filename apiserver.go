@@ -0,0 +1,79 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/nikola43/aureo-vpn-client/internal/api"
+	"github.com/nikola43/aureo-vpn-client/internal/config"
+)
+
+// newAPIClient creates an API client for url with the TLS settings saved
+// for it, going through the API proxy. If the settings cannot be loaded the
+// error is returned along with a client that refuses to connect.
+func (a *App) newAPIClient(url string) (*api.Client, error) {
+	client := api.NewClient(url)
+	client.SetProxy(a.proxyForAPI)
+
+	server, ok := a.settings.APIServer(url)
+	if !ok {
+		return client, nil
+	}
+	if err := client.SetTLS(apiTLSConfig(server)); err != nil {
+		return client, fmt.Errorf("invalid TLS settings for %s: %w", url, err)
+	}
+	return client, nil
+}
+
+// apiTLSConfig converts saved API server settings for the API client
+func apiTLSConfig(server config.APIServer) api.TLSConfig {
+	return api.TLSConfig{
+		CAFile:     server.CAFile,
+		ClientCert: server.ClientCert,
+		ClientKey:  server.ClientKey,
+		Pins:       server.Pins,
+	}
+}
+
+// ListAPIServers returns the saved per-URL API settings
+func (a *App) ListAPIServers() []config.APIServer {
+	return a.settings.APIServers
+}
+
+// SaveAPIServer saves the CA bundle, client certificate and pins for an API
+// URL. The files are loaded first, so broken settings are never saved. If
+// the URL is in use the client is rebuilt with the new settings.
+func (a *App) SaveAPIServer(server config.APIServer) error {
+	if err := server.Validate(); err != nil {
+		return err
+	}
+	if err := api.NewClient(server.URL).SetTLS(apiTLSConfig(server)); err != nil {
+		return fmt.Errorf("invalid TLS settings: %w", err)
+	}
+
+	if err := a.settings.PutAPIServer(server); err != nil {
+		return err
+	}
+	if err := a.settings.Save(a.configDir); err != nil {
+		return err
+	}
+
+	if config.NormalizeAPIURL(server.URL) == a.apiURL {
+		return a.SetAPIURL(a.apiURL)
+	}
+	return nil
+}
+
+// DeleteAPIServer removes the saved settings for an API URL
+func (a *App) DeleteAPIServer(url string) error {
+	if err := a.settings.DeleteAPIServer(url); err != nil {
+		return err
+	}
+	if err := a.settings.Save(a.configDir); err != nil {
+		return err
+	}
+
+	if config.NormalizeAPIURL(url) == a.apiURL {
+		return a.SetAPIURL(a.apiURL)
+	}
+	return nil
+}
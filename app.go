@@ -2,39 +2,138 @@ package main
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/nikola43/aureo-vpn-client/internal/api"
+	"github.com/nikola43/aureo-vpn-client/internal/config"
+	"github.com/nikola43/aureo-vpn-client/internal/geo"
+	"github.com/nikola43/aureo-vpn-client/internal/keys"
+	"github.com/nikola43/aureo-vpn-client/internal/logging"
 	"github.com/nikola43/aureo-vpn-client/internal/models"
+	"github.com/nikola43/aureo-vpn-client/internal/netcheck"
+	"github.com/nikola43/aureo-vpn-client/internal/nodes"
+	"github.com/nikola43/aureo-vpn-client/internal/obfs"
+	"github.com/nikola43/aureo-vpn-client/internal/policy"
+	"github.com/nikola43/aureo-vpn-client/internal/proxy"
+	"github.com/nikola43/aureo-vpn-client/internal/secrets"
+	"github.com/nikola43/aureo-vpn-client/internal/usage"
 	"github.com/nikola43/aureo-vpn-client/internal/vpn"
 )
 
+var logger = logging.For(logging.SubsystemApp)
+
 // App struct
 type App struct {
-	ctx        context.Context
-	apiClient  *api.Client
-	vpnManager *vpn.WireGuardManager
-	user       *models.User
-	session    *models.Session
-	nodeID     string
-	nodeName   string
-	configDir  string
+	ctx       context.Context
+	apiClient *api.Client
+	// apiURL is the API server apiClient talks to
+	apiURL string
+	// connMu serializes connecting, disconnecting and changes to the local
+	// proxies, which the UI, the policy engine and the stats sampler all do
+	connMu sync.Mutex
+	// stateMu guards the connection fields (tunnel, proxies, session, node,
+	// split tunnel and transport) and user. The connection fields are only
+	// written with connMu held too, so code holding connMu reads them
+	// directly; other readers take a snapshot with connection().
+	stateMu   sync.RWMutex
+	backend   vpn.Backend
+	tunnel    vpn.Tunnel
+	openVPN   *vpn.OpenVPNManager
+	proxies   []*proxy.Server
+	history   *vpn.StatsHistory
+	usage     *usage.Ledger
+	nodeStore *nodes.Store
+	keys      *keys.Manager
+	secrets   *secrets.Store
+	liveNodes *nodes.Live
+	location  *geo.Location
+	settings  *config.Settings
+	policy    *policy.Engine
+	captive   *netcheck.CaptiveMonitor
+	user      *models.User
+	session   *models.Session
+	nodeID    string
+	nodeName  string
+	configDir string
+	// splitTunnel is set when only some traffic uses the tunnel
+	splitTunnel bool
+	// egress is the result of the last egress check of the connection
+	egress atomic.Pointer[netcheck.Egress]
+	// apiProxy picks the proxy for API requests
+	apiProxy atomic.Pointer[apiProxy]
+	// fullTunnel is set while the tunnel carries all traffic, API requests
+	// included
+	fullTunnel atomic.Bool
+	// transport is how the WireGuard tunnel reaches the node
+	transport transportInfo
+	// relay bridges the tunnel to an obfuscation relay on the node
+	relay *obfs.Proxy
+
+	// Entry node of a multi-hop chain; nodeID/nodeName hold the exit node
+	entryNodeID   string
+	entryNodeName string
+
+	// stopSampling stops the background stats sampler
+	stopSampling context.CancelFunc
+	// stopNodeStream stops following node status
+	stopNodeStream context.CancelFunc
+	// locatedAt is when location was last looked up
+	locatedAt time.Time
+	// capWarned is the start of the period for which the soft cap warning was shown
+	capWarned time.Time
+}
+
+// transportInfo describes the transport a WireGuard connection settled on
+type transportInfo struct {
+	// Protocol is the outer transport: "udp", "tls" or "websocket"
+	Protocol string `json:"protocol"`
+	Endpoint string `json:"endpoint"`
+	// Fallback is set when the node's advertised endpoint did not answer
+	Fallback bool `json:"fallback"`
 }
 
-// SessionData stores user session information
+// SessionData stores user session information. The tokens are kept in the
+// credential store; the accounts file only has them if written by an older
+// version.
 type SessionData struct {
-	AccessToken  string      `json:"access_token"`
-	RefreshToken string      `json:"refresh_token"`
+	AccessToken  string      `json:"access_token,omitempty"`
+	RefreshToken string      `json:"refresh_token,omitempty"`
 	User         models.User `json:"user"`
 	APIURL       string      `json:"api_url"`
+	// LastUsed is when the account was last signed in or switched to
+	LastUsed time.Time `json:"last_used"`
 }
 
+// defaultAPIURL is the API server used until another is chosen
+const defaultAPIURL = "https://api.aureovpn.com"
+
+const (
+	// connectTimeout bounds how long bringing a tunnel up may take
+	connectTimeout = 60 * time.Second
+	// handshakeTimeout is how long a WireGuard endpoint has to answer before
+	// the next port is tried; the handshake is retried every 5 seconds
+	handshakeTimeout = 12 * time.Second
+	// disconnectTimeout bounds how long tearing a tunnel down may take
+	disconnectTimeout = 30 * time.Second
+
+	// Local proxy addresses used when the tunnel has no system interface
+	defaultSOCKSAddr     = "127.0.0.1:1080"
+	defaultHTTPProxyAddr = "127.0.0.1:3128"
+)
+
 // NewApp creates a new App application struct
 func NewApp() *App {
-	return &App{}
+	return &App{
+		history:   vpn.NewStatsHistory(int(statsHistoryWindow / statsInterval)),
+		liveNodes: nodes.NewLive(),
+	}
 }
 
 // startup is called when the app starts. The context is saved
@@ -47,109 +146,184 @@ func (a *App) startup(ctx context.Context) {
 	if err == nil {
 		a.configDir = filepath.Join(homeDir, ".aureo-vpn")
 		os.MkdirAll(a.configDir, 0700)
+
+		if err := logging.Init(filepath.Join(a.configDir, "logs")); err != nil {
+			logger.Warn("failed to open log file", "error", err)
+		}
 	}
 
-	// Initialize with default API URL - can be changed via SetAPIURL
-	a.apiClient = api.NewClient("https://api.aureovpn.com")
+	if err := logging.ApplyLevels(os.Getenv("AUREO_VPN_LOG_LEVEL")); err != nil {
+		logger.Warn("invalid AUREO_VPN_LOG_LEVEL", "error", err)
+	}
+	logger.Info("starting", "version", version)
 
-	// Initialize VPN manager
-	vpnMgr, err := vpn.NewWireGuardManager()
+	settings, err := config.Load(a.configDir)
 	if err != nil {
-		fmt.Printf("Warning: Failed to initialize VPN manager: %v\n", err)
+		logger.Warn("failed to load settings, using defaults", "error", err)
+		settings = config.Default()
 	}
-	a.vpnManager = vpnMgr
-}
-
-// currentAPIURL stores the current API URL for session saving
-var currentAPIURL string = "https://api.aureovpn.com"
-
-// SetAPIURL sets the base API URL
-func (a *App) SetAPIURL(url string) {
-	currentAPIURL = url
+	a.settings = settings
 
-	// Preserve access token if setting URL after login
-	oldToken := ""
-	if a.apiClient != nil {
-		oldToken = a.apiClient.GetAccessToken()
+	// Initialize with default API URL - can be changed via SetAPIURL
+	a.apiURL = defaultAPIURL
+	a.apiClient, err = a.newAPIClient(a.apiURL)
+	if err != nil {
+		// The client refuses to connect until the settings are fixed
+		logger.Error("failed to apply API TLS settings", "error", err)
 	}
 
-	a.apiClient = api.NewClient(url)
+	if err := a.openUsageLedger(); err != nil {
+		logger.Warn("failed to open usage ledger", "error", err)
+	}
 
-	// Restore token if it existed
-	if oldToken != "" {
-		a.apiClient.SetAccessToken(oldToken)
+	if err := a.openNodeStore(); err != nil {
+		logger.Warn("failed to open node store", "error", err)
 	}
-}
 
-// GetAPIURL returns the current API URL
-func (a *App) GetAPIURL() string {
-	return "API URL configured"
-}
+	if err := a.openKeys(); err != nil {
+		logger.Warn("failed to open key store", "error", err)
+	}
 
-// saveSession saves the session data to file
-func (a *App) saveSession(accessToken, refreshToken, apiURL string, user models.User) error {
-	if a.configDir == "" {
-		return fmt.Errorf("config directory not set")
+	if err := a.loadAPIProxy(); err != nil {
+		logger.Warn("failed to load API proxy settings", "error", err)
 	}
 
-	sessionData := SessionData{
-		AccessToken:  accessToken,
-		RefreshToken: refreshToken,
-		User:         user,
-		APIURL:       apiURL,
+	// Pick a tunnel backend based on what this host supports
+	if err := a.selectBackend(os.Getenv("AUREO_VPN_BACKEND")); err != nil {
+		logger.Warn("failed to initialize VPN backend", "error", err)
 	}
 
-	data, err := json.Marshal(sessionData)
+	openVPNMgr, err := vpn.NewOpenVPNManager(a.configDir)
 	if err != nil {
-		return fmt.Errorf("failed to marshal session data: %w", err)
+		logger.Warn("failed to initialize OpenVPN manager", "error", err)
+	}
+	a.openVPN = openVPNMgr
+	if a.tunnel == nil && openVPNMgr != nil && openVPNMgr.Restore() {
+		a.adoptTunnel(openVPNMgr)
 	}
 
-	sessionFile := filepath.Join(a.configDir, "session.json")
-	if err := os.WriteFile(sessionFile, data, 0600); err != nil {
-		return fmt.Errorf("failed to write session file: %w", err)
+	if a.activeTunnel() == nil {
+		// A tunnel left up by the last run is gone; close its history entry
+		a.recordDisconnect()
 	}
 
-	return nil
+	a.startCaptiveMonitor()
+	a.startPolicyEngine()
 }
 
-// loadSession loads the session data from file
-func (a *App) loadSession() (*SessionData, error) {
-	if a.configDir == "" {
-		return nil, fmt.Errorf("config directory not set")
+// shutdown is called when the app is closing. A connected tunnel is left
+// up and adopted again on the next start.
+func (a *App) shutdown(ctx context.Context) {
+	a.stopStatsSampling()
+	a.stopNodeStatus()
+	if a.usage != nil {
+		if err := a.usage.Close(); err != nil {
+			logger.Warn("failed to close usage ledger", "error", err)
+		}
 	}
+	logger.Info("shutting down")
+	logging.Close()
+}
 
-	sessionFile := filepath.Join(a.configDir, "session.json")
-	data, err := os.ReadFile(sessionFile)
+// selectBackend picks the WireGuard backend, preferring the named one when it
+// is available, and adopts any tunnel left up by a previous run
+func (a *App) selectBackend(preferred string) error {
+	backend, err := vpn.SelectBackend(preferred)
 	if err != nil {
-		if os.IsNotExist(err) {
-			return nil, nil // No session file exists
+		return err
+	}
+
+	a.backend = backend
+	tunnel := vpn.RestoreTunnel(backend, a.configDir)
+	if tunnel == nil {
+		return nil
+	}
+	if a.isRelayed(tunnel) {
+		// The relay of the previous run is gone, so the tunnel is dead
+		logger.Info("tearing down tunnel left up through an obfuscation relay")
+		ctx, cancel := context.WithTimeout(a.ctx, disconnectTimeout)
+		defer cancel()
+		if err := tunnel.Down(ctx); err != nil {
+			logger.Warn("failed to tear down relayed tunnel", "error", err)
 		}
-		return nil, fmt.Errorf("failed to read session file: %w", err)
+		return nil
 	}
+	a.adoptTunnel(tunnel)
+	return nil
+}
 
-	var sessionData SessionData
-	if err := json.Unmarshal(data, &sessionData); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal session data: %w", err)
+// adoptTunnel makes a tunnel left up by a previous run the current one,
+// taking its node from the open history entry
+func (a *App) adoptTunnel(tunnel vpn.Tunnel) {
+	a.stateMu.Lock()
+	a.tunnel = tunnel
+	if a.nodeStore != nil {
+		if current, ok := a.nodeStore.Current(); ok {
+			a.nodeID = current.NodeID
+			a.nodeName = current.NodeName
+		}
 	}
+	a.stateMu.Unlock()
+	a.setFullTunnel(tunnel)
+	a.startSampling(tunnel)
+}
 
-	return &sessionData, nil
+// GetTunnelBackends lists the WireGuard backends and whether each can run here
+func (a *App) GetTunnelBackends() []vpn.BackendInfo {
+	return vpn.ProbeBackends()
 }
 
-// deleteSession deletes the session file
-func (a *App) deleteSession() error {
-	if a.configDir == "" {
-		return nil
+// GetTunnelBackend returns the name of the selected WireGuard backend
+func (a *App) GetTunnelBackend() string {
+	return a.backend.Name
+}
+
+// SetTunnelBackend switches the WireGuard backend used for new connections
+func (a *App) SetTunnelBackend(name string) error {
+	a.connMu.Lock()
+	defer a.connMu.Unlock()
+
+	if a.activeTunnel() != nil {
+		return fmt.Errorf("cannot change tunnel backend while connected")
+	}
+	return a.selectBackend(name)
+}
+
+// SetAPIURL sets the base API URL and applies the TLS settings saved for it.
+// Moving to another server while signed in switches to the account last
+// used there, or signs out if there is none.
+func (a *App) SetAPIURL(url string) error {
+	normalized := config.NormalizeAPIURL(url)
+	if normalized != a.apiURL && a.currentUser() != nil {
+		return a.switchServer(normalized)
+	}
+
+	client, err := a.newAPIClient(url)
+	if err != nil {
+		return err
 	}
 
-	sessionFile := filepath.Join(a.configDir, "session.json")
-	if err := os.Remove(sessionFile); err != nil && !os.IsNotExist(err) {
-		return fmt.Errorf("failed to delete session file: %w", err)
+	// The same server with new settings keeps the signed-in account
+	if normalized == a.apiURL && a.apiClient != nil {
+		client.SetAccessToken(a.apiClient.GetAccessToken())
 	}
+	a.apiURL = normalized
+	a.apiClient = client
 
+	// Follow node status on the new server
+	if a.stopNodeStream != nil {
+		a.startNodeStream()
+	}
 	return nil
 }
 
-// CheckSavedSession checks if there's a saved session and returns it
+// GetAPIURL returns the current API URL
+func (a *App) GetAPIURL() string {
+	return a.apiURL
+}
+
+// CheckSavedSession restores the active saved account, if it is still
+// signed in
 func (a *App) CheckSavedSession() (map[string]interface{}, error) {
 	sessionData, err := a.loadSession()
 	if err != nil {
@@ -162,14 +336,10 @@ func (a *App) CheckSavedSession() (map[string]interface{}, error) {
 		}, nil
 	}
 
-	// Set API URL
-	a.apiClient = api.NewClient(sessionData.APIURL)
-	a.apiClient.SetAccessToken(sessionData.AccessToken)
-
-	// Verify token is still valid by making a test request
-	user, err := a.apiClient.GetUserProfile()
+	user, err := a.bindAccount(sessionData)
 	if err != nil {
 		// Token expired or invalid, delete session
+		logger.Info("saved session is no longer valid", "api_url", sessionData.APIURL, "error", err)
 		a.deleteSession()
 		return map[string]interface{}{
 			"has_session": false,
@@ -177,15 +347,10 @@ func (a *App) CheckSavedSession() (map[string]interface{}, error) {
 	}
 
 	// Token is valid, restore session
-	a.user = user
+	a.setUser(user)
+	a.signedIn()
 
-	return map[string]interface{}{
-		"has_session":   true,
-		"user":          user,
-		"access_token":  sessionData.AccessToken,
-		"refresh_token": sessionData.RefreshToken,
-		"api_url":       sessionData.APIURL,
-	}, nil
+	return a.accountResult(sessionData), nil
 }
 
 // Login authenticates the user
@@ -195,11 +360,12 @@ func (a *App) Login(email, password string) (map[string]interface{}, error) {
 		return nil, err
 	}
 
-	a.user = &loginResp.User
+	a.setUser(&loginResp.User)
+	a.signedIn()
 
 	// Save session to file
-	if err := a.saveSession(loginResp.AccessToken, loginResp.RefreshToken, currentAPIURL, loginResp.User); err != nil {
-		fmt.Printf("Warning: Failed to save session: %v\n", err)
+	if err := a.saveSession(loginResp.AccessToken, loginResp.RefreshToken, a.apiURL, loginResp.User); err != nil {
+		logger.Warn("failed to save session", "error", err)
 	}
 
 	return map[string]interface{}{
@@ -217,11 +383,12 @@ func (a *App) Register(email, password, username string) (map[string]interface{}
 		return nil, err
 	}
 
-	a.user = &loginResp.User
+	a.setUser(&loginResp.User)
+	a.signedIn()
 
 	// Save session to file
-	if err := a.saveSession(loginResp.AccessToken, loginResp.RefreshToken, currentAPIURL, loginResp.User); err != nil {
-		fmt.Printf("Warning: Failed to save session: %v\n", err)
+	if err := a.saveSession(loginResp.AccessToken, loginResp.RefreshToken, a.apiURL, loginResp.User); err != nil {
+		logger.Warn("failed to save session", "error", err)
 	}
 
 	return map[string]interface{}{
@@ -234,13 +401,13 @@ func (a *App) Register(email, password, username string) (map[string]interface{}
 
 // Logout clears the current user session
 func (a *App) Logout() error {
-	a.user = nil
-	a.session = nil
+	a.stopNodeStatus()
+	a.signOut()
 	a.apiClient.SetAccessToken("")
 
 	// Delete saved session
 	if err := a.deleteSession(); err != nil {
-		fmt.Printf("Warning: Failed to delete session: %v\n", err)
+		logger.Warn("failed to delete session", "error", err)
 	}
 
 	return nil
@@ -248,10 +415,33 @@ func (a *App) Logout() error {
 
 // GetCurrentUser returns the current logged-in user
 func (a *App) GetCurrentUser() (*models.User, error) {
-	if a.user == nil {
+	user := a.currentUser()
+	if user == nil {
 		return nil, fmt.Errorf("no user logged in")
 	}
-	return a.user, nil
+	return user, nil
+}
+
+// currentUser returns the signed-in user, or nil
+func (a *App) currentUser() *models.User {
+	a.stateMu.RLock()
+	defer a.stateMu.RUnlock()
+	return a.user
+}
+
+// setUser records the signed-in user
+func (a *App) setUser(user *models.User) {
+	a.stateMu.Lock()
+	defer a.stateMu.Unlock()
+	a.user = user
+}
+
+// signOut clears the signed-in user and their session
+func (a *App) signOut() {
+	a.stateMu.Lock()
+	defer a.stateMu.Unlock()
+	a.user = nil
+	a.session = nil
 }
 
 // GetNodes retrieves the list of available VPN nodes
@@ -263,9 +453,16 @@ func (a *App) GetNodes(country, protocol string) ([]models.VPNNode, error) {
 	return nodes, nil
 }
 
-// GetBestNode retrieves the best available node
+// QueryNodes returns one page of nodes filtered and sorted by the query,
+// with the total number of matching nodes
+func (a *App) QueryNodes(query api.NodeQuery) (*models.NodeListResponse, error) {
+	return a.apiClient.QueryNodes(query)
+}
+
+// GetBestNode retrieves the best available node, taking the distance from
+// the client into account when its location is known
 func (a *App) GetBestNode() (*models.VPNNode, error) {
-	return a.apiClient.GetBestNode()
+	return a.bestNode("", "")
 }
 
 // GetNode retrieves a specific node by ID
@@ -275,112 +472,666 @@ func (a *App) GetNode(nodeID string) (*models.VPNNode, error) {
 
 // ConnectToVPN creates a VPN session and returns the configuration
 func (a *App) ConnectToVPN(nodeID, protocol string) (map[string]interface{}, error) {
-	if a.user == nil {
-		return nil, fmt.Errorf("no user logged in")
-	}
+	a.connMu.Lock()
+	defer a.connMu.Unlock()
+	return a.connect(nodeID, protocol, connectOptions{})
+}
 
-	if a.vpnManager == nil {
-		return nil, fmt.Errorf("VPN manager not initialized")
+// connect brings up a tunnel to the node with the given options. The caller
+// holds connMu.
+func (a *App) connect(nodeID, protocol string, opts connectOptions) (map[string]interface{}, error) {
+	if a.currentUser() == nil {
+		return nil, fmt.Errorf("no user logged in")
 	}
 
 	// Check if already connected
-	if a.vpnManager.IsConnected() {
+	if a.activeTunnel() != nil {
 		return nil, fmt.Errorf("already connected to VPN. Disconnect first")
 	}
 
-	// For now, only support WireGuard
-	if protocol != "wireguard" {
-		return nil, fmt.Errorf("only WireGuard protocol is currently supported")
-	}
-
-	// Generate WireGuard keys
-	privateKey, publicKey, err := a.vpnManager.GenerateKeys()
-	if err != nil {
-		return nil, fmt.Errorf("failed to generate keys: %w", err)
+	if err := a.checkHardCap(); err != nil {
+		return nil, err
 	}
 
-	// Register with the VPN server
-	configResp, err := a.apiClient.RegisterWireGuardPeer(nodeID, publicKey)
-	if err != nil {
-		return nil, fmt.Errorf("failed to register with VPN server: %w", err)
+	// A manual MTU applies unless the profile sets its own
+	if opts.MTU == 0 {
+		opts.MTU = a.settings.Preferences.MTU
 	}
 
-	// Write WireGuard configuration
-	err = a.vpnManager.WriteConfig(
-		privateKey,
-		configResp.ClientIP,
-		configResp.ServerPublicKey,
-		configResp.ServerEndpoint,
-		configResp.DNS,
-	)
-	if err != nil {
-		return nil, fmt.Errorf("failed to write VPN config: %w", err)
+	var clientIP string
+	var err error
+	switch protocol {
+	case vpn.ProtocolWireGuard:
+		clientIP, err = a.connectWireGuard(nodeID, opts)
+	case vpn.ProtocolOpenVPN:
+		clientIP, err = a.connectOpenVPN(nodeID, opts)
+	default:
+		return nil, fmt.Errorf("unsupported protocol: %s", protocol)
 	}
-
-	// Connect to VPN
-	err = a.vpnManager.Connect()
 	if err != nil {
-		return nil, fmt.Errorf("failed to connect to VPN: %w", err)
+		err = a.explainConnectError(err)
+		logger.Error("connect failed", "node_id", nodeID, "protocol", protocol, "error", err)
+		a.recordFailure(nodeID, protocol, err)
+		return nil, err
 	}
+	logger.Info("connected", "node_id", nodeID, "protocol", protocol, "backend", a.tunnel.Name())
 
 	// Store connection info
+	a.stateMu.Lock()
 	a.nodeID = nodeID
+	a.splitTunnel = len(opts.Include) > 0 || len(opts.Exclude) > 0
+	a.stateMu.Unlock()
+	a.setFullTunnel(a.tunnel)
 	// Get node info
 	node, err := a.apiClient.GetNode(nodeID)
 	if err == nil {
+		a.stateMu.Lock()
 		a.nodeName = node.Name
+		a.stateMu.Unlock()
 	}
+	a.recordConnect(a.nodeID, a.nodeName, protocol)
+	a.startSampling(a.tunnel)
+	a.startEgressCheck(a.tunnel)
 
-	return map[string]interface{}{
+	result := map[string]interface{}{
 		"success":   true,
-		"client_ip": configResp.ClientIP,
+		"client_ip": clientIP,
 		"node_id":   nodeID,
+		"protocol":  protocol,
 		"connected": true,
+	}
+	if a.transport.Protocol != "" {
+		result["transport"] = a.transport
+	}
+
+	// A userspace tunnel is only reachable through local proxies
+	if dialer, ok := a.tunnel.(vpn.Dialer); ok {
+		if err := a.startTunnelProxies(dialer); err != nil {
+			a.disconnect()
+			return nil, fmt.Errorf("failed to start local proxies: %w", err)
+		}
+		result["proxies"] = a.proxyAddrs()
+	}
+
+	return result, nil
+}
+
+// connectWireGuard registers a fresh key pair with the node and brings up the tunnel
+func (a *App) connectWireGuard(nodeID string, opts connectOptions) (string, error) {
+	if a.backend.New == nil {
+		return "", fmt.Errorf("VPN backend not initialized")
+	}
+
+	tunnel, err := a.backend.New(a.configDir, vpn.DefaultInterface)
+	if err != nil {
+		return "", fmt.Errorf("failed to create tunnel: %w", err)
+	}
+
+	// Register the node's key with the VPN server
+	privateKey, configResp, err := a.registerPeer(nodeID)
+	if err != nil {
+		return "", err
+	}
+	// The tunnel keeps its own copy of the keys, if it needs one
+	defer privateKey.Wipe()
+	defer configResp.PresharedKey.Wipe()
+
+	allowedIPs, err := opts.allowedIPs(configResp.ServerEndpoint)
+	if err != nil {
+		return "", err
+	}
+
+	dns := configResp.DNS
+	if len(opts.DNS) > 0 {
+		dns = strings.Join(opts.DNS, ",")
+	}
+
+	mtu := opts.MTU
+	if mtu == 0 {
+		mtu = a.discoveredMTU(nodeID, configResp.ServerEndpoint)
+	}
+
+	cfg := vpn.TunnelConfig{
+		PrivateKey:    privateKey,
+		Address:       configResp.ClientIP,
+		DNS:           dns,
+		MTU:           mtu,
+		PeerPublicKey: configResp.ServerPublicKey,
+		AllowedIPs:    allowedIPs,
+		Keepalive:     25,
+		PresharedKey:  configResp.PresharedKey,
+	}
+
+	switch opts.Transport {
+	case obfs.TransportTLS, obfs.TransportWebSocket:
+		err = a.bringUpRelay(tunnel, cfg, opts, relaysFor(configResp.Relays, opts.Transport), false)
+	default:
+		err = a.bringUpWireGuard(tunnel, cfg, configResp.ServerEndpoint, configResp.AlternatePorts)
+		if errors.Is(err, vpn.ErrNoHandshake) && opts.Transport == "" && len(configResp.Relays) > 0 {
+			logger.Info("UDP appears blocked, trying obfuscated transports", "node_id", nodeID)
+			err = a.bringUpRelay(tunnel, cfg, opts, configResp.Relays, true)
+		}
+	}
+	if err != nil {
+		return "", err
+	}
+
+	return configResp.ClientIP, nil
+}
+
+// bringUpWireGuard brings the tunnel up on each of the node's ports in turn
+// until the server answers the handshake. A network that blocks UDP to one
+// port lets the interface come up, but no handshake ever completes.
+func (a *App) bringUpWireGuard(tunnel vpn.WireGuardTunnel, cfg vpn.TunnelConfig, endpoint string, alternatePorts []int) error {
+	endpoints, err := vpn.EndpointCandidates(endpoint, alternatePorts)
+	if err != nil {
+		return err
+	}
+
+	for i, endpoint := range endpoints {
+		cfg.Endpoint = endpoint
+		err := a.tryWireGuard(tunnel, cfg)
+		if err == nil {
+			a.setTransport(transportInfo{Protocol: "udp", Endpoint: endpoint, Fallback: i > 0})
+			if i > 0 {
+				logger.Info("connected on fallback port", "endpoint", endpoint, "advertised", endpoints[0])
+			}
+			return nil
+		}
+		if !errors.Is(err, vpn.ErrNoHandshake) {
+			return err
+		}
+	}
+
+	return fmt.Errorf("failed to connect to VPN: %w on %s; UDP may be blocked on this network", vpn.ErrNoHandshake, strings.Join(endpoints, ", "))
+}
+
+// tryWireGuard brings the tunnel up with cfg and waits for a handshake. If
+// none completes the tunnel is taken down again and ErrNoHandshake returned.
+func (a *App) tryWireGuard(tunnel vpn.WireGuardTunnel, cfg vpn.TunnelConfig) error {
+	if err := tunnel.Configure(cfg); err != nil {
+		a.discardTunnel(tunnel)
+		return fmt.Errorf("failed to write VPN config: %w", err)
+	}
+	if err := a.bringUp(tunnel); err != nil {
+		a.discardTunnel(tunnel)
+		return fmt.Errorf("failed to connect to VPN: %w", err)
+	}
+
+	err := vpn.WaitForHandshake(a.ctx, tunnel, handshakeTimeout)
+	if err == nil {
+		return nil
+	}
+
+	logger.Warn("no handshake from VPN server", "endpoint", cfg.Endpoint, "error", err)
+	ctx, cancel := context.WithTimeout(a.ctx, disconnectTimeout)
+	downErr := tunnel.Down(ctx)
+	cancel()
+	a.setTunnel(nil)
+	if downErr != nil {
+		return fmt.Errorf("failed to reset VPN tunnel: %w", downErr)
+	}
+	if errors.Is(err, vpn.ErrNoHandshake) {
+		return err
+	}
+	return fmt.Errorf("failed to connect to VPN: %w", err)
+}
+
+// discardTunnel takes down a tunnel that failed to come up, so that a
+// config file written by Configure, which may hold the keys, is shredded
+func (a *App) discardTunnel(tunnel vpn.Tunnel) {
+	ctx, cancel := context.WithTimeout(a.ctx, disconnectTimeout)
+	defer cancel()
+	if err := tunnel.Down(ctx); err != nil {
+		logger.Warn("failed to clean up VPN tunnel", "error", err)
+	}
+}
+
+// connectOpenVPN fetches an OpenVPN config for the node and starts openvpn
+func (a *App) connectOpenVPN(nodeID string, opts connectOptions) (string, error) {
+	if a.openVPN == nil {
+		return "", fmt.Errorf("OpenVPN manager not initialized")
+	}
+
+	node, err := a.apiClient.GetNode(nodeID)
+	if err != nil {
+		return "", fmt.Errorf("failed to get node: %w", err)
+	}
+	if !node.SupportsOpenVPN {
+		return "", fmt.Errorf("node %s does not support OpenVPN", node.Name)
+	}
+
+	configResp, err := a.apiClient.GenerateConfig(nodeID, vpn.ProtocolOpenVPN)
+	if err != nil {
+		return "", fmt.Errorf("failed to get OpenVPN config: %w", err)
+	}
+
+	overrides, err := vpn.OpenVPNOverrides(opts.Include, opts.Exclude, opts.DNS, opts.MTU)
+	if err != nil {
+		return "", fmt.Errorf("invalid connection options: %w", err)
+	}
+
+	if err := a.openVPN.WriteConfig(configResp.ConfigContent + overrides); err != nil {
+		return "", fmt.Errorf("failed to write VPN config: %w", err)
+	}
+
+	if err := a.bringUp(a.openVPN); err != nil {
+		return "", fmt.Errorf("failed to connect to VPN: %w", err)
+	}
+
+	return a.openVPN.LocalIP(), nil
+}
+
+// bringUp brings a tunnel up and records it as the active tunnel
+func (a *App) bringUp(tunnel vpn.Tunnel) error {
+	ctx, cancel := context.WithTimeout(a.ctx, connectTimeout)
+	defer cancel()
+
+	if err := tunnel.Up(ctx); err != nil {
+		return err
+	}
+
+	a.setTunnel(tunnel)
+	return nil
+}
+
+// setTunnel records the current tunnel. The caller holds connMu.
+func (a *App) setTunnel(tunnel vpn.Tunnel) {
+	a.stateMu.Lock()
+	defer a.stateMu.Unlock()
+	a.tunnel = tunnel
+}
+
+// setTransport records how the tunnel reaches the node. The caller holds
+// connMu.
+func (a *App) setTransport(transport transportInfo) {
+	a.stateMu.Lock()
+	defer a.stateMu.Unlock()
+	a.transport = transport
+}
+
+// startTunnelProxies exposes a tunnel without a system interface to local
+// applications through SOCKS5 and HTTP proxies
+func (a *App) startTunnelProxies(dialer vpn.Dialer) error {
+	a.stopProxies()
+
+	socks, err := proxy.Listen(proxy.Config{Kind: proxy.KindSOCKS5, Addr: defaultSOCKSAddr, Dial: dialer.DialContext})
+	if err != nil {
+		return err
+	}
+
+	httpProxy, err := proxy.Listen(proxy.Config{Kind: proxy.KindHTTP, Addr: defaultHTTPProxyAddr, Dial: dialer.DialContext})
+	if err != nil {
+		socks.Close()
+		return err
+	}
+
+	a.stateMu.Lock()
+	a.proxies = []*proxy.Server{socks, httpProxy}
+	a.stateMu.Unlock()
+	return nil
+}
+
+// StartProxy starts a local SOCKS5 or HTTP proxy whose connections leave
+// through the active tunnel, even when the tunnel does not carry the
+// system's default route. A running proxy of the same kind is replaced.
+// An empty addr uses the default port for the kind; username and password
+// are optional.
+func (a *App) StartProxy(kind, addr, username, password string) (map[string]interface{}, error) {
+	a.connMu.Lock()
+	defer a.connMu.Unlock()
+
+	tunnel := a.activeTunnel()
+	if tunnel == nil {
+		return nil, fmt.Errorf("not connected to VPN")
+	}
+
+	if addr == "" {
+		switch kind {
+		case proxy.KindSOCKS5:
+			addr = defaultSOCKSAddr
+		case proxy.KindHTTP:
+			addr = defaultHTTPProxyAddr
+		}
+	}
+
+	if (username == "") != (password == "") {
+		return nil, fmt.Errorf("both username and password are required for proxy authentication")
+	}
+
+	dial, err := tunnelDialer(tunnel)
+	if err != nil {
+		return nil, err
+	}
+
+	// Free the port before listening in case the address is unchanged
+	a.stopProxy(kind)
+
+	server, err := proxy.Listen(proxy.Config{
+		Kind:     kind,
+		Addr:     addr,
+		Dial:     dial,
+		Username: username,
+		Password: password,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to start %s proxy: %w", kind, err)
+	}
+
+	a.stateMu.Lock()
+	a.proxies = append(a.proxies, server)
+	a.stateMu.Unlock()
+
+	return map[string]interface{}{
+		"success":       true,
+		"kind":          server.Kind(),
+		"addr":          server.Addr(),
+		"auth_required": username != "",
+	}, nil
+}
+
+// StopProxy stops the local proxy of the given kind, if running
+func (a *App) StopProxy(kind string) error {
+	a.connMu.Lock()
+	defer a.connMu.Unlock()
+	a.stopProxy(kind)
+	return nil
+}
+
+// stopProxy closes the local proxy of the given kind. The caller holds
+// connMu.
+func (a *App) stopProxy(kind string) {
+	var remaining []*proxy.Server
+	for _, p := range a.proxies {
+		if p.Kind() == kind {
+			p.Close()
+			continue
+		}
+		remaining = append(remaining, p)
+	}
+	a.stateMu.Lock()
+	a.proxies = remaining
+	a.stateMu.Unlock()
+}
+
+// GetProxyStatus returns listen addresses and per-connection byte counts
+// for the running local proxies
+func (a *App) GetProxyStatus() []proxy.Status {
+	a.connMu.Lock()
+	defer a.connMu.Unlock()
+
+	statuses := make([]proxy.Status, 0, len(a.proxies))
+	for _, p := range a.proxies {
+		statuses = append(statuses, p.Status())
+	}
+	return statuses
+}
+
+// tunnelDialer returns a dialer whose connections go through the tunnel
+func tunnelDialer(tunnel vpn.Tunnel) (proxy.DialFunc, error) {
+	if dialer, ok := tunnel.(vpn.Dialer); ok {
+		return dialer.DialContext, nil
+	}
+
+	bound, ok := tunnel.(vpn.BoundTunnel)
+	if !ok {
+		return nil, fmt.Errorf("%s tunnels do not support local proxies", tunnel.Name())
+	}
+
+	iface, err := bound.SystemInterface()
+	if err != nil {
+		return nil, err
+	}
+	var dnsServers []string
+	if dns, ok := tunnel.(vpn.DNSTunnel); ok {
+		dnsServers = dns.DNSServers()
+	}
+	return proxy.InterfaceDialer(iface, dnsServers)
+}
+
+// stopProxies closes all running local proxies
+func (a *App) stopProxies() {
+	for _, p := range a.proxies {
+		p.Close()
+	}
+	a.stateMu.Lock()
+	a.proxies = nil
+	a.stateMu.Unlock()
+}
+
+// proxyAddrs returns the listen address of each running proxy keyed by
+// kind. The caller holds connMu or stateMu.
+func (a *App) proxyAddrs() map[string]string {
+	addrs := make(map[string]string)
+	for _, p := range a.proxies {
+		addrs[p.Kind()] = p.Addr()
+	}
+	return addrs
+}
+
+// activeTunnel returns the tunnel that is currently up, if any, including
+// one that is still running while it reconnects
+func (a *App) activeTunnel() vpn.Tunnel {
+	a.stateMu.RLock()
+	tunnel := a.tunnel
+	a.stateMu.RUnlock()
+
+	if tunnel != nil && vpn.IsRunning(tunnel) {
+		return tunnel
+	}
+	return nil
+}
+
+// connection is a snapshot of the connection state
+type connection struct {
+	tunnel        vpn.Tunnel
+	nodeID        string
+	nodeName      string
+	entryNodeID   string
+	entryNodeName string
+	splitTunnel   bool
+	transport     transportInfo
+	proxies       map[string]string
+}
+
+// connection returns a snapshot of the connection state, for readers that
+// do not hold connMu
+func (a *App) connection() connection {
+	a.stateMu.RLock()
+	defer a.stateMu.RUnlock()
+	return connection{
+		tunnel:        a.tunnel,
+		nodeID:        a.nodeID,
+		nodeName:      a.nodeName,
+		entryNodeID:   a.entryNodeID,
+		entryNodeName: a.entryNodeName,
+		splitTunnel:   a.splitTunnel,
+		transport:     a.transport,
+		proxies:       a.proxyAddrs(),
+	}
+}
+
+// ConnectMultiHop chains two nodes: traffic enters the VPN at entryID and
+// leaves it at exitID, with the exit tunnel nested inside the entry tunnel
+func (a *App) ConnectMultiHop(entryID, exitID string) (map[string]interface{}, error) {
+	a.connMu.Lock()
+	defer a.connMu.Unlock()
+
+	if a.currentUser() == nil {
+		return nil, fmt.Errorf("no user logged in")
+	}
+
+	if a.backend.New == nil {
+		return nil, fmt.Errorf("VPN backend not initialized")
+	}
+
+	if entryID == exitID {
+		return nil, fmt.Errorf("entry and exit nodes must be different")
+	}
+
+	// Check if already connected
+	if a.activeTunnel() != nil {
+		return nil, fmt.Errorf("already connected to VPN. Disconnect first")
+	}
+
+	if err := a.checkHardCap(); err != nil {
+		return nil, err
+	}
+
+	entry, err := a.registerHop(entryID)
+	if err != nil {
+		err = a.explainConnectError(err)
+		a.recordFailure(entryID, vpn.ProtocolWireGuard, err)
+		return nil, fmt.Errorf("entry node: %w", err)
+	}
+	// The tunnels keep their own copy of the keys, if they need one
+	defer entry.WipeKeys()
+
+	exit, err := a.registerHop(exitID)
+	if err != nil {
+		err = a.explainConnectError(err)
+		a.recordFailure(exitID, vpn.ProtocolWireGuard, err)
+		return nil, fmt.Errorf("exit node: %w", err)
+	}
+	defer exit.WipeKeys()
+
+	tunnel, err := vpn.NewMultiHop(a.backend, a.configDir, *entry, *exit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to configure multi-hop VPN: %w", err)
+	}
+
+	if err := a.bringUp(tunnel); err != nil {
+		a.discardTunnel(tunnel)
+		logger.Error("multi-hop connect failed", "entry_node_id", entryID, "exit_node_id", exitID, "error", err)
+		a.recordFailure(entryID, vpn.ProtocolWireGuard, err)
+		a.recordFailure(exitID, vpn.ProtocolWireGuard, err)
+		return nil, fmt.Errorf("failed to connect multi-hop VPN: %w", err)
+	}
+	logger.Info("connected multi-hop", "entry_node_id", entryID, "exit_node_id", exitID, "backend", tunnel.Name())
+
+	// Store connection info
+	a.stateMu.Lock()
+	a.entryNodeID = entryID
+	a.nodeID = exitID
+	a.stateMu.Unlock()
+	a.setFullTunnel(tunnel)
+	var entryName, exitName string
+	if node, err := a.apiClient.GetNode(entryID); err == nil {
+		entryName = node.Name
+	}
+	if node, err := a.apiClient.GetNode(exitID); err == nil {
+		exitName = node.Name
+	}
+	a.stateMu.Lock()
+	a.entryNodeName = entryName
+	a.nodeName = exitName
+	a.stateMu.Unlock()
+	a.recordConnect(a.entryNodeID, a.entryNodeName, vpn.ProtocolWireGuard)
+	a.recordConnect(a.nodeID, a.nodeName, vpn.ProtocolWireGuard)
+	a.startSampling(tunnel)
+	a.startEgressCheck(tunnel)
+
+	return map[string]interface{}{
+		"success":       true,
+		"client_ip":     exit.ClientIP,
+		"entry_node_id": entryID,
+		"node_id":       exitID,
+		"multi_hop":     true,
+		"connected":     true,
+	}, nil
+}
+
+// registerHop registers the account's key as a peer on a node
+func (a *App) registerHop(nodeID string) (*vpn.Hop, error) {
+	privateKey, configResp, err := a.registerPeer(nodeID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &vpn.Hop{
+		PrivateKey:      privateKey,
+		ClientIP:        configResp.ClientIP,
+		ServerPublicKey: configResp.ServerPublicKey,
+		ServerEndpoint:  configResp.ServerEndpoint,
+		DNS:             configResp.DNS,
+		PresharedKey:    configResp.PresharedKey,
 	}, nil
 }
 
 // DisconnectVPN disconnects the current VPN session
 func (a *App) DisconnectVPN() error {
-	if a.vpnManager == nil {
-		return fmt.Errorf("VPN manager not initialized")
-	}
+	a.connMu.Lock()
+	defer a.connMu.Unlock()
+	return a.disconnect()
+}
 
-	// Check if connected - if not, just clear state and return success
-	if !a.vpnManager.IsConnected() {
-		// Clear connection info even if not connected (cleanup state)
-		a.nodeID = ""
-		a.nodeName = ""
-		a.session = nil
+// disconnectTunnel disconnects tunnel if it is still the current one, for
+// background tasks whose tunnel may have been replaced meanwhile
+func (a *App) disconnectTunnel(tunnel vpn.Tunnel) error {
+	a.connMu.Lock()
+	defer a.connMu.Unlock()
+	if a.tunnel != tunnel {
 		return nil
 	}
+	return a.disconnect()
+}
 
-	// Disconnect VPN
-	err := a.vpnManager.Disconnect()
-	if err != nil {
-		return fmt.Errorf("failed to disconnect VPN: %w", err)
+// disconnect takes the tunnel down and clears the connection state. The
+// caller holds connMu.
+func (a *App) disconnect() error {
+	// Check if connected - if not, just clear state and return success
+	if tunnel := a.activeTunnel(); tunnel != nil {
+		ctx, cancel := context.WithTimeout(a.ctx, disconnectTimeout)
+		defer cancel()
+
+		// Disconnect VPN
+		if err := tunnel.Down(ctx); err != nil {
+			logger.Error("disconnect failed", "node_id", a.nodeID, "error", err)
+			return fmt.Errorf("failed to disconnect VPN: %w", err)
+		}
+		logger.Info("disconnected", "node_id", a.nodeID)
 	}
 
+	a.stopProxies()
+	a.stopStatsSampling()
+	a.recordDisconnect()
+
 	// Clear connection info
+	a.stateMu.Lock()
+	a.tunnel = nil
 	a.nodeID = ""
 	a.nodeName = ""
+	a.entryNodeID = ""
+	a.entryNodeName = ""
+	a.splitTunnel = false
+	a.transport = transportInfo{}
 	a.session = nil
+	a.stateMu.Unlock()
+	a.setFullTunnel(nil)
+	a.stopRelay()
+	a.egress.Store(nil)
 
 	return nil
 }
 
 // GetCurrentSession returns the current VPN session
 func (a *App) GetCurrentSession() (*models.Session, error) {
-	if a.session == nil {
+	a.stateMu.RLock()
+	current := a.session
+	a.stateMu.RUnlock()
+	if current == nil {
 		return nil, fmt.Errorf("no active VPN session")
 	}
 
 	// Refresh session data from server
-	session, err := a.apiClient.GetSession(a.session.ID)
+	session, err := a.apiClient.GetSession(current.ID)
 	if err != nil {
 		return nil, err
 	}
 
+	a.stateMu.Lock()
 	a.session = session
+	a.stateMu.Unlock()
 	return session, nil
 }
 
@@ -395,7 +1146,7 @@ func (a *App) GetUserProfile() (*models.User, error) {
 	if err != nil {
 		return nil, err
 	}
-	a.user = user
+	a.setUser(user)
 	return user, nil
 }
 
@@ -406,38 +1157,85 @@ func (a *App) GetUserStats() (map[string]interface{}, error) {
 
 // IsConnected returns whether there is an active VPN session
 func (a *App) IsConnected() bool {
-	if a.vpnManager == nil {
-		return false
-	}
-	return a.vpnManager.IsConnected()
+	return a.activeTunnel() != nil
 }
 
 // GetVPNStats returns VPN connection statistics
 func (a *App) GetVPNStats() (map[string]interface{}, error) {
-	if a.vpnManager == nil {
-		return nil, fmt.Errorf("VPN manager not initialized")
-	}
-
-	if !a.vpnManager.IsConnected() {
+	conn := a.connection()
+	tunnel := conn.tunnel
+	if tunnel == nil || !vpn.IsRunning(tunnel) {
 		return map[string]interface{}{
 			"connected": false,
 		}, nil
 	}
 
-	stats, err := a.vpnManager.GetStats()
-	if err != nil {
-		return nil, err
+	stats, err := tunnel.Stats(a.ctx)
+	unavailable := err != nil
+	if unavailable {
+		logger.Debug("failed to read tunnel stats", "error", err)
+		stats = vpn.Stats{Connected: tunnel.IsConnected()}
+	}
+
+	// Rates come from the background sampler
+	if sample, ok := a.history.Latest(); ok {
+		stats.RxRate = sample.RxRate
+		stats.TxRate = sample.TxRate
+	}
+
+	result := statsToMap(stats)
+	if unavailable {
+		result["stats_unavailable"] = true
+	}
+	result["protocol"] = tunnel.Protocol()
+	result["backend"] = tunnel.Name()
+	result["node_id"] = conn.nodeID
+	result["node_name"] = conn.nodeName
+	if conn.transport.Protocol != "" {
+		result["transport"] = conn.transport
+	}
+	if egress := a.egress.Load(); egress != nil {
+		result["egress"] = egress
+	}
+	if len(conn.proxies) > 0 {
+		result["proxies"] = conn.proxies
 	}
 
-	stats["node_id"] = a.nodeID
-	stats["node_name"] = a.nodeName
+	if multiHop, ok := tunnel.(*vpn.MultiHopTunnel); ok {
+		entryStats, exitStats, err := multiHop.HopStats(a.ctx)
+		if err == nil {
+			result["multi_hop"] = true
+			result["entry"] = statsToMap(entryStats)
+			result["exit"] = statsToMap(exitStats)
+		}
+		result["entry_node_id"] = conn.entryNodeID
+		result["entry_node_name"] = conn.entryNodeName
+	}
 
-	return stats, nil
+	return result, nil
+}
+
+// statsToMap converts tunnel stats to the map shape the frontend expects
+func statsToMap(stats vpn.Stats) map[string]interface{} {
+	result := map[string]interface{}{
+		"connected":      stats.Connected,
+		"bytes_sent":     stats.BytesSent,
+		"bytes_received": stats.BytesReceived,
+		"rx_rate":        stats.RxRate,
+		"tx_rate":        stats.TxRate,
+	}
+	if !stats.LastHandshake.IsZero() {
+		result["latest_handshake"] = stats.LastHandshake
+	}
+	if stats.Endpoint != "" {
+		result["endpoint"] = stats.Endpoint
+	}
+	return result
 }
 
 // IsLoggedIn returns whether a user is logged in
 func (a *App) IsLoggedIn() bool {
-	return a.user != nil && a.apiClient.GetAccessToken() != ""
+	return a.currentUser() != nil && a.apiClient.GetAccessToken() != ""
 }
 
 // GenerateConfig generates a VPN configuration without creating a session
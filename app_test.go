@@ -0,0 +1,177 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/nikola43/aureo-vpn-client/internal/api"
+	"github.com/nikola43/aureo-vpn-client/internal/config"
+	"github.com/nikola43/aureo-vpn-client/internal/models"
+	"github.com/nikola43/aureo-vpn-client/internal/vpn"
+)
+
+// newTestApp returns an App signed in to a fake API server whose WireGuard
+// tunnels are the returned FakeTunnel
+func newTestApp(t *testing.T) (*App, *vpn.FakeTunnel) {
+	t.Helper()
+
+	_, serverKey, err := vpn.GenerateKeys()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /api/v1/config/generate", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(models.WireGuardConfigResponse{
+			ServerPublicKey: serverKey,
+			ServerEndpoint:  "127.0.0.1:51820",
+			ClientIP:        "10.8.0.2/32",
+			DNS:             "10.8.0.1",
+		})
+	})
+	mux.HandleFunc("GET /api/v1/nodes/{id}", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(models.VPNNode{ID: r.PathValue("id"), Name: "Test node"})
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	fake := vpn.NewFakeTunnel(vpn.DefaultInterface)
+	a := NewApp()
+	a.ctx = ctx
+	a.configDir = t.TempDir()
+	a.settings = config.Default()
+	// A manual MTU skips path MTU discovery
+	a.settings.Preferences.MTU = 1420
+	a.user = &models.User{ID: "user-1"}
+	a.apiClient = api.NewClient(server.URL)
+	a.apiClient.SetAccessToken("token")
+	a.backend = vpn.Backend{
+		Name: vpn.BackendFake,
+		New: func(configDir, iface string) (vpn.WireGuardTunnel, error) {
+			return fake, nil
+		},
+	}
+	t.Cleanup(func() { a.DisconnectVPN() })
+	return a, fake
+}
+
+func TestConnectWireGuard(t *testing.T) {
+	a, fake := newTestApp(t)
+
+	result, err := a.ConnectToVPN("node-1", vpn.ProtocolWireGuard)
+	if err != nil {
+		t.Fatalf("ConnectToVPN: %v", err)
+	}
+	if result["client_ip"] != "10.8.0.2/32" {
+		t.Errorf("client_ip = %v", result["client_ip"])
+	}
+	if !a.IsConnected() || !fake.IsConnected() {
+		t.Fatal("tunnel is not up after connecting")
+	}
+
+	cfg := fake.Config()
+	if cfg.Address != "10.8.0.2/32" || cfg.Endpoint != "127.0.0.1:51820" || cfg.MTU != 1420 {
+		t.Errorf("unexpected tunnel config: address %q, endpoint %q, MTU %d", cfg.Address, cfg.Endpoint, cfg.MTU)
+	}
+	if a.nodeName != "Test node" {
+		t.Errorf("node name = %q", a.nodeName)
+	}
+
+	if _, err := a.ConnectToVPN("node-2", vpn.ProtocolWireGuard); err == nil {
+		t.Error("connecting twice succeeded")
+	}
+}
+
+func TestGetVPNStats(t *testing.T) {
+	a, fake := newTestApp(t)
+
+	stats, err := a.GetVPNStats()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats["connected"] != false {
+		t.Errorf("connected before connecting: %v", stats)
+	}
+
+	if _, err := a.ConnectToVPN("node-1", vpn.ProtocolWireGuard); err != nil {
+		t.Fatalf("ConnectToVPN: %v", err)
+	}
+	fake.SetCounters(vpn.Stats{BytesSent: 1200, BytesReceived: 3400, Endpoint: "127.0.0.1:51820"})
+
+	stats, err = a.GetVPNStats()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats["connected"] != true || stats["bytes_sent"] != int64(1200) || stats["bytes_received"] != int64(3400) {
+		t.Errorf("unexpected stats: %v", stats)
+	}
+	if stats["node_id"] != "node-1" || stats["backend"] != vpn.BackendFake {
+		t.Errorf("unexpected connection info: %v", stats)
+	}
+	if _, ok := stats["stats_unavailable"]; ok {
+		t.Errorf("stats reported unavailable: %v", stats)
+	}
+}
+
+func TestDisconnectVPN(t *testing.T) {
+	a, fake := newTestApp(t)
+
+	if _, err := a.ConnectToVPN("node-1", vpn.ProtocolWireGuard); err != nil {
+		t.Fatalf("ConnectToVPN: %v", err)
+	}
+	if err := a.DisconnectVPN(); err != nil {
+		t.Fatalf("DisconnectVPN: %v", err)
+	}
+
+	if a.IsConnected() || fake.IsConnected() {
+		t.Error("tunnel is still up after disconnecting")
+	}
+	if a.nodeID != "" || a.stopSampling != nil {
+		t.Error("connection state was not cleared")
+	}
+
+	// Disconnecting again is not an error
+	if err := a.DisconnectVPN(); err != nil {
+		t.Errorf("second DisconnectVPN: %v", err)
+	}
+}
+
+func TestConnectionStateConcurrentReads(t *testing.T) {
+	a, _ := newTestApp(t)
+
+	done := make(chan struct{})
+	readers := make(chan struct{})
+	go func() {
+		defer close(readers)
+		for {
+			select {
+			case <-done:
+				return
+			default:
+			}
+			a.IsConnected()
+			a.IsLoggedIn()
+			if _, err := a.GetVPNStats(); err != nil {
+				t.Error(err)
+				return
+			}
+		}
+	}()
+
+	for i := 0; i < 5; i++ {
+		if _, err := a.ConnectToVPN("node-1", vpn.ProtocolWireGuard); err != nil {
+			t.Fatalf("ConnectToVPN: %v", err)
+		}
+		if err := a.DisconnectVPN(); err != nil {
+			t.Fatalf("DisconnectVPN: %v", err)
+		}
+	}
+	close(done)
+	<-readers
+}
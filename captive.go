@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/nikola43/aureo-vpn-client/internal/netcheck"
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// captiveRecheckInterval is how often a detected portal is probed again
+const captiveRecheckInterval = 10 * time.Second
+
+// errCaptivePortal is returned when connecting is pointless until the user
+// signs into the network
+var errCaptivePortal = errors.New("sign into the network first: a captive portal is blocking Internet access")
+
+// startCaptiveMonitor reports captive portals to the UI and resumes
+// auto-connect once the user has signed in
+func (a *App) startCaptiveMonitor() {
+	a.captive = &netcheck.CaptiveMonitor{
+		OnChange: func(portal netcheck.CaptivePortal) {
+			runtime.EventsEmit(a.ctx, "network:captive", portal)
+
+			if portal.Detected {
+				logger.Warn("captive portal detected", "url", portal.URL, "reason", portal.Reason)
+				a.captive.WatchUntilClear(a.ctx, captiveRecheckInterval)
+				return
+			}
+
+			logger.Info("captive portal cleared")
+			if a.policy != nil {
+				go a.policy.Reevaluate()
+			}
+		},
+	}
+}
+
+// checkCaptivePortal probes for a portal and returns errCaptivePortal if
+// one is present. Probe failures are ignored so that connecting proceeds
+// and reports its own error.
+func (a *App) checkCaptivePortal() error {
+	ctx, cancel := context.WithTimeout(a.ctx, 2*captiveRecheckInterval)
+	defer cancel()
+
+	portal, err := a.captive.Check(ctx)
+	if err != nil || !portal.Detected {
+		return nil
+	}
+	if portal.URL != "" {
+		return fmt.Errorf("%w (%s)", errCaptivePortal, portal.URL)
+	}
+	return errCaptivePortal
+}
+
+// explainConnectError replaces a failed connect's error with a captive
+// portal message when a portal is present. Manual connects are not probed
+// beforehand, so that they are not delayed on networks without a portal;
+// the probe runs only once connecting has failed.
+func (a *App) explainConnectError(err error) error {
+	if errors.Is(err, context.Canceled) || errors.Is(err, errCaptivePortal) {
+		return err
+	}
+	if portalErr := a.checkCaptivePortal(); portalErr != nil {
+		return portalErr
+	}
+	return err
+}
+
+// CheckCaptivePortal probes for a captive portal now, e.g. after the user
+// signed into the network
+func (a *App) CheckCaptivePortal() (netcheck.CaptivePortal, error) {
+	ctx, cancel := context.WithTimeout(a.ctx, 2*captiveRecheckInterval)
+	defer cancel()
+	return a.captive.Check(ctx)
+}
+
+// GetNetworkStatus returns the current network and captive portal state
+func (a *App) GetNetworkStatus() map[string]interface{} {
+	portal := a.captive.Status()
+
+	result := map[string]interface{}{
+		"captive_portal":     portal.Detected,
+		"captive_portal_url": portal.URL,
+		"needs_sign_in":      portal.Detected,
+		"checked_at":         portal.CheckedAt,
+	}
+	if portal.Detected {
+		result["message"] = "Sign into the network first"
+	}
+	if a.policy != nil {
+		if network, ok := a.policy.Network(); ok {
+			result["network"] = network
+		}
+	}
+	return result
+}
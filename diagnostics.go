@@ -0,0 +1,155 @@
+package main
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"time"
+
+	"github.com/nikola43/aureo-vpn-client/internal/logging"
+	"github.com/nikola43/aureo-vpn-client/internal/vpn"
+)
+
+// version is the app version, set at build time with -ldflags "-X main.version=..."
+var version = "1.0.0"
+
+// diagnosticsTimeout bounds how long collecting tunnel state may take
+const diagnosticsTimeout = 10 * time.Second
+
+// SetLogLevel changes the log level (debug, info, warn, error) of a
+// subsystem (app, api, vpn)
+func (a *App) SetLogLevel(subsystem, level string) error {
+	return logging.SetLevel(subsystem, level)
+}
+
+// GetLogLevels returns the log level of each subsystem
+func (a *App) GetLogLevels() map[string]string {
+	return logging.Levels()
+}
+
+// ExportDiagnostics bundles the logs, sanitized tunnel state and version
+// info into a zip in the config directory and returns its path
+func (a *App) ExportDiagnostics() (string, error) {
+	dir := filepath.Join(a.configDir, "diagnostics")
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", fmt.Errorf("failed to create diagnostics directory: %w", err)
+	}
+
+	path := filepath.Join(dir, fmt.Sprintf("aureo-vpn-diagnostics-%s.zip", time.Now().Format("20060102-150405")))
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return "", fmt.Errorf("failed to create diagnostics file: %w", err)
+	}
+	defer f.Close()
+
+	zw := zip.NewWriter(f)
+
+	info, err := json.MarshalIndent(a.diagnosticsInfo(), "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to encode diagnostics info: %w", err)
+	}
+	if err := writeZipFile(zw, "info.json", info); err != nil {
+		return "", err
+	}
+
+	if err := writeZipFile(zw, "wireguard.txt", []byte(a.tunnelState())); err != nil {
+		return "", err
+	}
+
+	// Log records are redacted as they are written
+	for _, logPath := range logging.Files() {
+		if err := copyZipFile(zw, filepath.Join("logs", filepath.Base(logPath)), logPath); err != nil {
+			return "", err
+		}
+	}
+
+	if err := zw.Close(); err != nil {
+		return "", fmt.Errorf("failed to write diagnostics file: %w", err)
+	}
+
+	logger.Info("exported diagnostics", "path", path)
+	return path, nil
+}
+
+// diagnosticsInfo describes the app, host and connection
+func (a *App) diagnosticsInfo() map[string]interface{} {
+	info := map[string]interface{}{
+		"version":    version,
+		"go_version": runtime.Version(),
+		"os":         runtime.GOOS,
+		"arch":       runtime.GOARCH,
+		"backend":    a.backend.Name,
+		"backends":   vpn.ProbeBackends(),
+		"log_levels": logging.Levels(),
+		"connected":  a.IsConnected(),
+		"created_at": time.Now().Format(time.RFC3339),
+	}
+
+	if conn := a.connection(); conn.tunnel != nil && vpn.IsRunning(conn.tunnel) {
+		info["protocol"] = conn.tunnel.Protocol()
+		info["node_id"] = conn.nodeID
+		info["node_name"] = conn.nodeName
+		if conn.entryNodeID != "" {
+			info["entry_node_id"] = conn.entryNodeID
+		}
+	}
+
+	return info
+}
+
+// tunnelState returns the redacted device state of the active tunnel
+func (a *App) tunnelState() string {
+	tunnel := a.activeTunnel()
+	if tunnel == nil {
+		return "not connected\n"
+	}
+
+	dumper, ok := tunnel.(vpn.StateDumper)
+	if !ok {
+		return fmt.Sprintf("%s tunnels do not report device state\n", tunnel.Name())
+	}
+
+	ctx, cancel := context.WithTimeout(a.ctx, diagnosticsTimeout)
+	defer cancel()
+
+	state, err := dumper.DumpState(ctx)
+	if err != nil {
+		return fmt.Sprintf("failed to read tunnel state: %s\n", logging.Redact(err.Error()))
+	}
+	return logging.Redact(state)
+}
+
+// writeZipFile adds a file with the given contents to the archive
+func writeZipFile(zw *zip.Writer, name string, data []byte) error {
+	w, err := zw.Create(name)
+	if err != nil {
+		return fmt.Errorf("failed to add %s: %w", name, err)
+	}
+	if _, err := w.Write(data); err != nil {
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	return nil
+}
+
+// copyZipFile adds a file from disk to the archive
+func copyZipFile(zw *zip.Writer, name, path string) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	defer src.Close()
+
+	w, err := zw.Create(name)
+	if err != nil {
+		return fmt.Errorf("failed to add %s: %w", name, err)
+	}
+	if _, err := io.Copy(w, src); err != nil {
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	return nil
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/netip"
+	"strings"
+	"time"
+
+	"github.com/nikola43/aureo-vpn-client/internal/geo"
+	"github.com/nikola43/aureo-vpn-client/internal/netcheck"
+	"github.com/nikola43/aureo-vpn-client/internal/vpn"
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+const (
+	// egressCheckDelay lets routes and DNS settle before checking egress
+	egressCheckDelay = 2 * time.Second
+	// egressCheckTimeout bounds an egress check
+	egressCheckTimeout = 20 * time.Second
+)
+
+// startEgressCheck verifies in the background that traffic leaves from the
+// connected node, and emits the result as a "connection:egress" event
+func (a *App) startEgressCheck(tunnel vpn.Tunnel) {
+	a.egress.Store(nil)
+
+	go func() {
+		select {
+		case <-a.ctx.Done():
+			return
+		case <-time.After(egressCheckDelay):
+		}
+
+		result := a.checkEgress(tunnel)
+		if a.activeTunnel() != tunnel {
+			return
+		}
+		a.egress.Store(&result)
+		runtime.EventsEmit(a.ctx, "connection:egress", result)
+	}()
+}
+
+// checkEgress compares the observed public addresses with the node's
+func (a *App) checkEgress(tunnel vpn.Tunnel) netcheck.Egress {
+	conn := a.connection()
+	if conn.splitTunnel {
+		return netcheck.Egress{
+			Expected:  []string{},
+			Reason:    "split tunnel: only some traffic uses the VPN",
+			CheckedAt: time.Now(),
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(a.ctx, egressCheckTimeout)
+	defer cancel()
+
+	var dial netcheck.DialFunc
+	if dialer, ok := tunnel.(vpn.Dialer); ok {
+		dial = dialer.DialContext
+	}
+
+	expected, country := a.expectedEgress(ctx, tunnel, conn.nodeID)
+	result := netcheck.CheckEgress(ctx, expected, dial)
+
+	if result.IPv4 != "" && country != "" {
+		if path, ok := a.geoIPDatabase(); ok {
+			if loc, err := geo.LookupDB(path, netip.MustParseAddr(result.IPv4)); err == nil && loc.CountryCode != "" {
+				result.Country = loc.CountryCode
+				result.CountryMismatch = !strings.EqualFold(loc.CountryCode, country)
+			}
+		}
+	}
+
+	if result.Leak {
+		logger.Warn("possible traffic leak", "node_id", conn.nodeID, "ipv4", result.IPv4, "ipv6", result.IPv6, "expected", result.Expected)
+	} else if result.Verified {
+		logger.Info("egress verified", "node_id", conn.nodeID, "ipv4", result.IPv4)
+	}
+	return result
+}
+
+// expectedEgress returns the addresses traffic should leave from (the exit
+// node's public IP and hostname addresses, and the tunnel endpoint) and the
+// node's country code
+func (a *App) expectedEgress(ctx context.Context, tunnel vpn.Tunnel, nodeID string) ([]string, string) {
+	expected := []string{}
+	add := func(s string) {
+		addr, err := netip.ParseAddr(s)
+		if err != nil {
+			return
+		}
+		s = addr.Unmap().String()
+		for _, e := range expected {
+			if e == s {
+				return
+			}
+		}
+		expected = append(expected, s)
+	}
+
+	country := ""
+	if node, err := a.apiClient.GetNode(nodeID); err == nil {
+		country = node.CountryCode
+		add(node.PublicIP)
+		if node.Hostname != "" {
+			if addrs, err := net.DefaultResolver.LookupHost(ctx, node.Hostname); err == nil {
+				for _, addr := range addrs {
+					add(addr)
+				}
+			}
+		}
+	}
+
+	// A multi-hop tunnel's endpoint is the entry node, which is not the egress
+	if _, ok := tunnel.(*vpn.MultiHopTunnel); !ok {
+		if stats, err := tunnel.Stats(ctx); err == nil && stats.Endpoint != "" {
+			if host, _, err := net.SplitHostPort(stats.Endpoint); err == nil {
+				add(host)
+			} else {
+				add(stats.Endpoint)
+			}
+		}
+	}
+
+	return expected, country
+}
+
+// VerifyConnection checks again that traffic leaves from the connected node
+func (a *App) VerifyConnection() (*netcheck.Egress, error) {
+	tunnel := a.activeTunnel()
+	if tunnel == nil {
+		return nil, fmt.Errorf("not connected to VPN")
+	}
+
+	result := a.checkEgress(tunnel)
+	a.egress.Store(&result)
+	return &result, nil
+}
+
+// GetPublicIP returns the host's public IPv4 address and, if it has IPv6
+// connectivity, its IPv6 address
+func (a *App) GetPublicIP() (map[string]interface{}, error) {
+	ctx, cancel := context.WithTimeout(a.ctx, egressCheckTimeout)
+	defer cancel()
+
+	ipv4, err := netcheck.PublicIP(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	result := map[string]interface{}{
+		"ipv4": ipv4.String(),
+	}
+	if ipv6, err := netcheck.PublicIPv6(ctx); err == nil {
+		result["ipv6"] = ipv6.String()
+	}
+	return result, nil
+}
@@ -12,9 +12,6 @@ let connectionTimeInterval = null;
 let map = null;
 let markers = new Map();
 let connectionStartTime = null;
-let previousBytesReceived = 0;
-let previousBytesSent = 0;
-let previousStatsTime = Date.now();
 let totalBytesTransferred = 0;
 
 // ============================================
@@ -73,8 +70,9 @@ window.addEventListener('DOMContentLoaded', async () => {
     initDashboard();
     initTabNavigation();
     initQuickActions();
-    initSettings();
+    await initSettings();
     initMap();
+    initBackendEvents();
 
     // Check for saved session
     if (window.go && window.go.main && window.go.main.App) {
@@ -84,6 +82,72 @@ window.addEventListener('DOMContentLoaded', async () => {
     }
 });
 
+// ============================================
+// BACKEND EVENTS
+// ============================================
+function initBackendEvents() {
+    if (!window.runtime || !window.runtime.EventsOn) return;
+
+    // The auto-connect policy engine connected or disconnected on its own
+    window.runtime.EventsOn('policy:action', async (event) => {
+        if (event.error) {
+            showToast(`Auto ${event.action} failed: ${event.error}`, 'error');
+        } else {
+            showToast(`Auto ${event.action}: ${event.reason}`, 'success');
+        }
+        await checkConnectionStatus();
+    });
+
+    // Node status changed on the server
+    window.runtime.EventsOn('nodes:update', (deltas) => {
+        applyNodeDeltas(deltas);
+    });
+
+    // The egress check after connecting finished
+    window.runtime.EventsOn('connection:egress', (egress) => {
+        showEgress(egress);
+        if (egress.leak) {
+            showToast(`Possible leak: ${egress.reason}`, 'error', 8000);
+        } else if (egress.country_mismatch) {
+            showToast(`Your IP appears to be in ${egress.country}, not the server's country`, 'warning');
+        }
+    });
+
+    // A captive portal appeared or the user signed into it
+    window.runtime.EventsOn('network:captive', (portal) => {
+        if (portal.detected) {
+            showToast('Sign into the network first (captive portal detected)', 'warning');
+        } else {
+            showToast('Network sign-in complete', 'success');
+        }
+    });
+}
+
+// Merge node changes from the backend into the loaded list
+function applyNodeDeltas(deltas) {
+    if (!deltas || deltas.length === 0 || nodes.length === 0) return;
+
+    for (const delta of deltas) {
+        const index = nodes.findIndex(n => n.id === delta.id);
+        if (delta.removed) {
+            if (index >= 0) nodes.splice(index, 1);
+        } else if (delta.node) {
+            if (index >= 0) nodes[index] = delta.node;
+            else nodes.push(delta.node);
+        } else if (index >= 0 && delta.changes) {
+            Object.assign(nodes[index], delta.changes);
+        }
+    }
+
+    nodes.sort((a, b) => a.load_score - b.load_score);
+    filterNodes();
+
+    if (connectedNode) {
+        const current = nodes.find(n => n.id === connectedNode.id);
+        if (current) updateLoadDisplay(current.load_score);
+    }
+}
+
 // ============================================
 // SESSION MANAGEMENT
 // ============================================
@@ -411,9 +475,8 @@ async function quickConnect() {
 // ============================================
 async function fetchUserIP() {
     try {
-        const response = await fetch('https://api.ipify.org?format=json');
-        const data = await response.json();
-        document.getElementById('current-ip').textContent = data.ip;
+        const ip = await window.go.main.App.GetPublicIP();
+        document.getElementById('current-ip').textContent = ip.ipv4;
     } catch (error) {
         console.error('Failed to fetch IP:', error);
         document.getElementById('current-ip').textContent = 'Unknown';
@@ -457,9 +520,6 @@ async function checkConnectionStatus() {
 
                 // Initialize stats
                 connectionStartTime = connectionStartTime || new Date();
-                previousBytesReceived = 0;
-                previousBytesSent = 0;
-                previousStatsTime = Date.now();
 
                 startSessionUpdates();
             }
@@ -494,17 +554,27 @@ function showDisconnectedView() {
 // ============================================
 async function updateServerIP() {
     const serverIp = document.getElementById('server-ip');
-    serverIp.textContent = '...';
 
+    // The backend checks the egress address shortly after connecting
     try {
-        const response = await fetch('https://api.ipify.org?format=json');
-        const data = await response.json();
-        serverIp.textContent = data.ip;
+        const stats = await window.go.main.App.GetVPNStats();
+        if (stats && stats.egress) {
+            showEgress(stats.egress);
+        } else {
+            serverIp.textContent = '...';
+        }
     } catch (error) {
         serverIp.textContent = '-';
     }
 }
 
+function showEgress(egress) {
+    const serverIp = document.getElementById('server-ip');
+    serverIp.textContent = egress.ipv4 || '-';
+    serverIp.style.color = egress.leak ? '#EF4444' : '';
+    serverIp.title = egress.reason || (egress.verified ? 'Verified: traffic leaves from the VPN node' : '');
+}
+
 // ============================================
 // UPDATE LOAD DISPLAY
 // ============================================
@@ -706,9 +776,6 @@ async function connectToVPN() {
 
             // Reset stats
             connectionStartTime = new Date();
-            previousBytesReceived = 0;
-            previousBytesSent = 0;
-            previousStatsTime = Date.now();
             totalBytesTransferred = 0;
 
             renderNodes(nodes);
@@ -718,6 +785,9 @@ async function connectToVPN() {
             startConnectionTimer();
 
             showToast('Connected to ' + selectedNode.country, 'success');
+            if (response.transport && response.transport.fallback) {
+                showToast(`The server's usual port is blocked here; connected over ${response.transport.protocol.toUpperCase()} ${response.transport.endpoint}`, 'info', 6000);
+            }
         }
     } catch (error) {
         console.error('Connection error:', error);
@@ -789,33 +859,13 @@ function startSessionUpdates() {
             const stats = await window.go.main.App.GetVPNStats();
 
             if (stats && stats.connected) {
-                const currentTime = Date.now();
-                const timeDelta = (currentTime - previousStatsTime) / 1000;
-
-                if (stats.bytes_received !== undefined && stats.bytes_sent !== undefined) {
-                    if (previousBytesReceived === 0 && previousBytesSent === 0) {
-                        previousBytesReceived = stats.bytes_received;
-                        previousBytesSent = stats.bytes_sent;
-                        previousStatsTime = currentTime;
-                    } else if (timeDelta > 0) {
-                        const receivedDelta = stats.bytes_received - previousBytesReceived;
-                        const sentDelta = stats.bytes_sent - previousBytesSent;
-
-                        const downSpeed = receivedDelta / timeDelta;
-                        const upSpeed = sentDelta / timeDelta;
-
-                        document.getElementById('speed-down').textContent = formatSpeed(downSpeed);
-                        document.getElementById('speed-up').textContent = formatSpeed(upSpeed);
-
-                        // Update total transferred
-                        totalBytesTransferred = stats.bytes_received + stats.bytes_sent;
-                        document.getElementById('stat-data-transferred').textContent = formatBytes(totalBytesTransferred);
-
-                        previousBytesReceived = stats.bytes_received;
-                        previousBytesSent = stats.bytes_sent;
-                        previousStatsTime = currentTime;
-                    }
-                }
+                // Rates are computed by the backend's stats sampler
+                document.getElementById('speed-down').textContent = formatSpeed(stats.rx_rate || 0);
+                document.getElementById('speed-up').textContent = formatSpeed(stats.tx_rate || 0);
+
+                // Update total transferred
+                totalBytesTransferred = stats.bytes_received + stats.bytes_sent;
+                document.getElementById('stat-data-transferred').textContent = formatBytes(totalBytesTransferred);
             }
         } catch (error) {
             console.error('Failed to update session:', error);
@@ -830,9 +880,6 @@ function stopSessionUpdates() {
     }
 
     connectionStartTime = null;
-    previousBytesReceived = 0;
-    previousBytesSent = 0;
-    previousStatsTime = Date.now();
 }
 
 // ============================================
@@ -1030,14 +1077,16 @@ const defaultSettings = {
     killswitch: false,
     autoconnect: false,
     dns: true,
-    notifications: true
+    notifications: true,
+    mtu: 0,
+    keyRotationDays: 30
 };
 
 let appSettings = { ...defaultSettings };
 
-function initSettings() {
-    // Load saved settings from localStorage
-    loadSettings();
+async function initSettings() {
+    // Load saved settings from the backend
+    await loadSettings();
 
     // Protocol select
     const protocolSelect = document.getElementById('protocol-select');
@@ -1065,11 +1114,13 @@ function initSettings() {
     const autoconnectToggle = document.getElementById('autoconnect-toggle');
     if (autoconnectToggle) {
         autoconnectToggle.checked = appSettings.autoconnect;
-        autoconnectToggle.addEventListener('change', (e) => {
+        autoconnectToggle.addEventListener('change', async (e) => {
             appSettings.autoconnect = e.target.checked;
             saveSettings();
+            await saveAutoConnectRule(e.target.checked);
             showToast(`Auto Connect ${e.target.checked ? 'enabled' : 'disabled'}`, 'success');
         });
+        loadAutoConnectRule(autoconnectToggle);
     }
 
     // DNS Leak Protection toggle
@@ -1112,26 +1163,72 @@ function initSettings() {
     });
 }
 
-function loadSettings() {
+// Preferences live in the backend settings file; settings saved by older
+// versions in localStorage are moved there once
+async function loadSettings() {
+    if (!window.go || !window.go.main || !window.go.main.App) return;
     try {
-        const saved = localStorage.getItem('aureo-vpn-settings');
-        if (saved) {
-            appSettings = { ...defaultSettings, ...JSON.parse(saved) };
+        const legacy = localStorage.getItem('aureo-vpn-settings');
+        if (legacy) {
+            appSettings = { ...defaultSettings, ...JSON.parse(legacy) };
+            await saveSettings();
+            localStorage.removeItem('aureo-vpn-settings');
+            return;
         }
+
+        const prefs = await window.go.main.App.GetPreferences();
+        appSettings = {
+            ...appSettings,
+            protocol: prefs.protocol || defaultSettings.protocol,
+            killswitch: prefs.kill_switch,
+            dns: prefs.dns_leak_protection,
+            notifications: prefs.notifications,
+            mtu: prefs.mtu || 0,
+            keyRotationDays: prefs.key_rotation_days ?? defaultSettings.keyRotationDays
+        };
     } catch (error) {
         console.error('Failed to load settings:', error);
         appSettings = { ...defaultSettings };
     }
 }
 
-function saveSettings() {
+async function saveSettings() {
     try {
-        localStorage.setItem('aureo-vpn-settings', JSON.stringify(appSettings));
+        await window.go.main.App.SavePreferences({
+            protocol: appSettings.protocol,
+            kill_switch: appSettings.killswitch,
+            dns_leak_protection: appSettings.dns,
+            notifications: appSettings.notifications,
+            mtu: appSettings.mtu || 0,
+            key_rotation_days: appSettings.keyRotationDays
+        });
     } catch (error) {
         console.error('Failed to save settings:', error);
     }
 }
 
+// Auto-connect rules live in the backend so they apply before the UI loads
+async function loadAutoConnectRule(toggle) {
+    if (!window.go || !window.go.main || !window.go.main.App) return;
+    try {
+        const rules = await window.go.main.App.GetAutoConnectRules();
+        appSettings.autoconnect = rules.connect_on_startup;
+        toggle.checked = rules.connect_on_startup;
+    } catch (error) {
+        console.error('Failed to load auto-connect rules:', error);
+    }
+}
+
+async function saveAutoConnectRule(enabled) {
+    try {
+        const rules = await window.go.main.App.GetAutoConnectRules();
+        rules.connect_on_startup = enabled;
+        await window.go.main.App.SetAutoConnectRules(rules);
+    } catch (error) {
+        console.error('Failed to save auto-connect rules:', error);
+    }
+}
+
 function getPreferredProtocol() {
     return appSettings.protocol || 'wireguard';
 }
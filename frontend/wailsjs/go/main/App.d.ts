@@ -1,43 +1,168 @@
 // Cynhyrchwyd y ffeil hon yn awtomatig. PEIDIWCH Â MODIWL
 // This file is automatically generated. DO NOT EDIT
+import {netcheck} from '../models';
+import {nodes} from '../models';
 import {models} from '../models';
+import {config} from '../models';
+import {usage} from '../models';
+import {geo} from '../models';
+import {proxy} from '../models';
+import {vpn} from '../models';
+import {main} from '../models';
+import {api} from '../models';
+
+export function AddFavorite(arg1:string):Promise<void>;
+
+export function CheckCaptivePortal():Promise<netcheck.CaptivePortal>;
 
 export function CheckSavedSession():Promise<Record<string, any>>;
 
+export function ClearDiscoveredMTUs():Promise<void>;
+
+export function ConnectMultiHop(arg1:string,arg2:string):Promise<Record<string, any>>;
+
+export function ConnectProfile(arg1:string):Promise<Record<string, any>>;
+
 export function ConnectToVPN(arg1:string,arg2:string):Promise<Record<string, any>>;
 
+export function DeleteAPIServer(arg1:string):Promise<void>;
+
+export function DeleteProfile(arg1:string):Promise<void>;
+
 export function DisconnectVPN():Promise<void>;
 
+export function DiscoverMTU(arg1:string):Promise<nodes.MTU>;
+
+export function ExportDiagnostics():Promise<string>;
+
+export function ExportUsageCSV():Promise<string>;
+
 export function GenerateConfig(arg1:string,arg2:string):Promise<Record<string, any>>;
 
+export function GetAPIProxy():Promise<Record<string, any>>;
+
 export function GetAPIURL():Promise<string>;
 
 export function GetAllSessions():Promise<Array<models.Session>>;
 
+export function GetAutoConnectRules():Promise<config.AutoConnect>;
+
 export function GetBestNode():Promise<models.VPNNode>;
 
+export function GetConnectionHistory(arg1:number):Promise<Array<nodes.Attempt>>;
+
+export function GetCurrentNetwork():Promise<Record<string, any>>;
+
 export function GetCurrentSession():Promise<models.Session>;
 
 export function GetCurrentUser():Promise<models.User>;
 
+export function GetDataCaps():Promise<usage.CapStatus>;
+
+export function GetFavoriteIDs():Promise<Array<string>>;
+
+export function GetFavorites():Promise<Array<models.VPNNode>>;
+
+export function GetLiveNodes():Promise<Array<models.VPNNode>>;
+
+export function GetLocation():Promise<geo.Location>;
+
+export function GetLocationSettings():Promise<config.Location>;
+
+export function GetLogLevels():Promise<Record<string, string>>;
+
+export function GetNearestNodes(arg1:number):Promise<Array<geo.NodeDistance>>;
+
+export function GetNetworkStatus():Promise<Record<string, any>>;
+
 export function GetNode(arg1:string):Promise<models.VPNNode>;
 
+export function GetNodeDistances():Promise<Array<geo.NodeDistance>>;
+
+export function GetNodeGroups():Promise<Array<nodes.Group>>;
+
+export function GetNodeTags():Promise<Record<string, Array<string>>>;
+
 export function GetNodes(arg1:string,arg2:string):Promise<Array<models.VPNNode>>;
 
+export function GetPreferences():Promise<config.Preferences>;
+
+export function GetProxyStatus():Promise<Array<proxy.Status>>;
+
+export function GetPublicIP():Promise<Record<string, any>>;
+
+export function GetRecents(arg1:number):Promise<Array<nodes.Recent>>;
+
+export function GetStatsHistory(arg1:number):Promise<Array<vpn.Sample>>;
+
+export function GetTunnelBackend():Promise<string>;
+
+export function GetTunnelBackends():Promise<Array<vpn.BackendInfo>>;
+
+export function GetUsage(arg1:string,arg2:number):Promise<Array<usage.Rollup>>;
+
+export function GetUsageSessions(arg1:number):Promise<Array<usage.Session>>;
+
 export function GetUserProfile():Promise<models.User>;
 
 export function GetUserStats():Promise<Record<string, any>>;
 
 export function GetVPNStats():Promise<Record<string, any>>;
 
+export function GetWireGuardKeys():Promise<Array<Record<string, any>>>;
+
 export function IsConnected():Promise<boolean>;
 
 export function IsLoggedIn():Promise<boolean>;
 
+export function ListAPIServers():Promise<Array<config.APIServer>>;
+
+export function ListAccounts():Promise<Array<main.Account>>;
+
+export function ListProfiles():Promise<Array<config.Profile>>;
+
 export function Login(arg1:string,arg2:string):Promise<Record<string, any>>;
 
 export function Logout():Promise<void>;
 
+export function QueryNodes(arg1:api.NodeQuery):Promise<models.NodeListResponse>;
+
 export function Register(arg1:string,arg2:string,arg3:string):Promise<Record<string, any>>;
 
+export function RemoveAccount(arg1:string):Promise<void>;
+
+export function RemoveFavorite(arg1:string):Promise<void>;
+
+export function RotateWireGuardKeys():Promise<void>;
+
+export function SaveAPIServer(arg1:config.APIServer):Promise<void>;
+
+export function SavePreferences(arg1:config.Preferences):Promise<void>;
+
+export function SaveProfile(arg1:config.Profile):Promise<void>;
+
+export function SetAPIProxy(arg1:config.APIProxy,arg2:string):Promise<void>;
+
 export function SetAPIURL(arg1:string):Promise<void>;
+
+export function SetAutoConnectRules(arg1:config.AutoConnect):Promise<void>;
+
+export function SetDataCaps(arg1:string,arg2:number,arg3:number):Promise<void>;
+
+export function SetLocationSettings(arg1:config.Location):Promise<void>;
+
+export function SetLogLevel(arg1:string,arg2:string):Promise<void>;
+
+export function SetNodeTags(arg1:string,arg2:Array<string>):Promise<void>;
+
+export function SetTunnelBackend(arg1:string):Promise<void>;
+
+export function StartProxy(arg1:string,arg2:string,arg3:string,arg4:string):Promise<Record<string, any>>;
+
+export function StopProxy(arg1:string):Promise<void>;
+
+export function SwitchAccount(arg1:string):Promise<Record<string, any>>;
+
+export function TrustCurrentNetwork():Promise<void>;
+
+export function VerifyConnection():Promise<netcheck.Egress>;
@@ -2,22 +2,66 @@
 // Cynhyrchwyd y ffeil hon yn awtomatig. PEIDIWCH Â MODIWL
 // This file is automatically generated. DO NOT EDIT
 
+export function AddFavorite(arg1) {
+  return window['go']['main']['App']['AddFavorite'](arg1);
+}
+
+export function CheckCaptivePortal() {
+  return window['go']['main']['App']['CheckCaptivePortal']();
+}
+
 export function CheckSavedSession() {
   return window['go']['main']['App']['CheckSavedSession']();
 }
 
+export function ClearDiscoveredMTUs() {
+  return window['go']['main']['App']['ClearDiscoveredMTUs']();
+}
+
+export function ConnectMultiHop(arg1, arg2) {
+  return window['go']['main']['App']['ConnectMultiHop'](arg1, arg2);
+}
+
+export function ConnectProfile(arg1) {
+  return window['go']['main']['App']['ConnectProfile'](arg1);
+}
+
 export function ConnectToVPN(arg1, arg2) {
   return window['go']['main']['App']['ConnectToVPN'](arg1, arg2);
 }
 
+export function DeleteAPIServer(arg1) {
+  return window['go']['main']['App']['DeleteAPIServer'](arg1);
+}
+
+export function DeleteProfile(arg1) {
+  return window['go']['main']['App']['DeleteProfile'](arg1);
+}
+
 export function DisconnectVPN() {
   return window['go']['main']['App']['DisconnectVPN']();
 }
 
+export function DiscoverMTU(arg1) {
+  return window['go']['main']['App']['DiscoverMTU'](arg1);
+}
+
+export function ExportDiagnostics() {
+  return window['go']['main']['App']['ExportDiagnostics']();
+}
+
+export function ExportUsageCSV() {
+  return window['go']['main']['App']['ExportUsageCSV']();
+}
+
 export function GenerateConfig(arg1, arg2) {
   return window['go']['main']['App']['GenerateConfig'](arg1, arg2);
 }
 
+export function GetAPIProxy() {
+  return window['go']['main']['App']['GetAPIProxy']();
+}
+
 export function GetAPIURL() {
   return window['go']['main']['App']['GetAPIURL']();
 }
@@ -26,10 +70,22 @@ export function GetAllSessions() {
   return window['go']['main']['App']['GetAllSessions']();
 }
 
+export function GetAutoConnectRules() {
+  return window['go']['main']['App']['GetAutoConnectRules']();
+}
+
 export function GetBestNode() {
   return window['go']['main']['App']['GetBestNode']();
 }
 
+export function GetConnectionHistory(arg1) {
+  return window['go']['main']['App']['GetConnectionHistory'](arg1);
+}
+
+export function GetCurrentNetwork() {
+  return window['go']['main']['App']['GetCurrentNetwork']();
+}
+
 export function GetCurrentSession() {
   return window['go']['main']['App']['GetCurrentSession']();
 }
@@ -38,14 +94,98 @@ export function GetCurrentUser() {
   return window['go']['main']['App']['GetCurrentUser']();
 }
 
+export function GetDataCaps() {
+  return window['go']['main']['App']['GetDataCaps']();
+}
+
+export function GetFavoriteIDs() {
+  return window['go']['main']['App']['GetFavoriteIDs']();
+}
+
+export function GetFavorites() {
+  return window['go']['main']['App']['GetFavorites']();
+}
+
+export function GetLiveNodes() {
+  return window['go']['main']['App']['GetLiveNodes']();
+}
+
+export function GetLocation() {
+  return window['go']['main']['App']['GetLocation']();
+}
+
+export function GetLocationSettings() {
+  return window['go']['main']['App']['GetLocationSettings']();
+}
+
+export function GetLogLevels() {
+  return window['go']['main']['App']['GetLogLevels']();
+}
+
+export function GetNearestNodes(arg1) {
+  return window['go']['main']['App']['GetNearestNodes'](arg1);
+}
+
+export function GetNetworkStatus() {
+  return window['go']['main']['App']['GetNetworkStatus']();
+}
+
 export function GetNode(arg1) {
   return window['go']['main']['App']['GetNode'](arg1);
 }
 
+export function GetNodeDistances() {
+  return window['go']['main']['App']['GetNodeDistances']();
+}
+
+export function GetNodeGroups() {
+  return window['go']['main']['App']['GetNodeGroups']();
+}
+
+export function GetNodeTags() {
+  return window['go']['main']['App']['GetNodeTags']();
+}
+
 export function GetNodes(arg1, arg2) {
   return window['go']['main']['App']['GetNodes'](arg1, arg2);
 }
 
+export function GetPreferences() {
+  return window['go']['main']['App']['GetPreferences']();
+}
+
+export function GetProxyStatus() {
+  return window['go']['main']['App']['GetProxyStatus']();
+}
+
+export function GetPublicIP() {
+  return window['go']['main']['App']['GetPublicIP']();
+}
+
+export function GetRecents(arg1) {
+  return window['go']['main']['App']['GetRecents'](arg1);
+}
+
+export function GetStatsHistory(arg1) {
+  return window['go']['main']['App']['GetStatsHistory'](arg1);
+}
+
+export function GetTunnelBackend() {
+  return window['go']['main']['App']['GetTunnelBackend']();
+}
+
+export function GetTunnelBackends() {
+  return window['go']['main']['App']['GetTunnelBackends']();
+}
+
+export function GetUsage(arg1, arg2) {
+  return window['go']['main']['App']['GetUsage'](arg1, arg2);
+}
+
+export function GetUsageSessions(arg1) {
+  return window['go']['main']['App']['GetUsageSessions'](arg1);
+}
+
 export function GetUserProfile() {
   return window['go']['main']['App']['GetUserProfile']();
 }
@@ -58,6 +198,10 @@ export function GetVPNStats() {
   return window['go']['main']['App']['GetVPNStats']();
 }
 
+export function GetWireGuardKeys() {
+  return window['go']['main']['App']['GetWireGuardKeys']();
+}
+
 export function IsConnected() {
   return window['go']['main']['App']['IsConnected']();
 }
@@ -66,6 +210,18 @@ export function IsLoggedIn() {
   return window['go']['main']['App']['IsLoggedIn']();
 }
 
+export function ListAPIServers() {
+  return window['go']['main']['App']['ListAPIServers']();
+}
+
+export function ListAccounts() {
+  return window['go']['main']['App']['ListAccounts']();
+}
+
+export function ListProfiles() {
+  return window['go']['main']['App']['ListProfiles']();
+}
+
 export function Login(arg1, arg2) {
   return window['go']['main']['App']['Login'](arg1, arg2);
 }
@@ -74,10 +230,86 @@ export function Logout() {
   return window['go']['main']['App']['Logout']();
 }
 
+export function QueryNodes(arg1) {
+  return window['go']['main']['App']['QueryNodes'](arg1);
+}
+
 export function Register(arg1, arg2, arg3) {
   return window['go']['main']['App']['Register'](arg1, arg2, arg3);
 }
 
+export function RemoveAccount(arg1) {
+  return window['go']['main']['App']['RemoveAccount'](arg1);
+}
+
+export function RemoveFavorite(arg1) {
+  return window['go']['main']['App']['RemoveFavorite'](arg1);
+}
+
+export function RotateWireGuardKeys() {
+  return window['go']['main']['App']['RotateWireGuardKeys']();
+}
+
+export function SaveAPIServer(arg1) {
+  return window['go']['main']['App']['SaveAPIServer'](arg1);
+}
+
+export function SavePreferences(arg1) {
+  return window['go']['main']['App']['SavePreferences'](arg1);
+}
+
+export function SaveProfile(arg1) {
+  return window['go']['main']['App']['SaveProfile'](arg1);
+}
+
+export function SetAPIProxy(arg1, arg2) {
+  return window['go']['main']['App']['SetAPIProxy'](arg1, arg2);
+}
+
 export function SetAPIURL(arg1) {
   return window['go']['main']['App']['SetAPIURL'](arg1);
 }
+
+export function SetAutoConnectRules(arg1) {
+  return window['go']['main']['App']['SetAutoConnectRules'](arg1);
+}
+
+export function SetDataCaps(arg1, arg2, arg3) {
+  return window['go']['main']['App']['SetDataCaps'](arg1, arg2, arg3);
+}
+
+export function SetLocationSettings(arg1) {
+  return window['go']['main']['App']['SetLocationSettings'](arg1);
+}
+
+export function SetLogLevel(arg1, arg2) {
+  return window['go']['main']['App']['SetLogLevel'](arg1, arg2);
+}
+
+export function SetNodeTags(arg1, arg2) {
+  return window['go']['main']['App']['SetNodeTags'](arg1, arg2);
+}
+
+export function SetTunnelBackend(arg1) {
+  return window['go']['main']['App']['SetTunnelBackend'](arg1);
+}
+
+export function StartProxy(arg1, arg2, arg3, arg4) {
+  return window['go']['main']['App']['StartProxy'](arg1, arg2, arg3, arg4);
+}
+
+export function StopProxy(arg1) {
+  return window['go']['main']['App']['StopProxy'](arg1);
+}
+
+export function SwitchAccount(arg1) {
+  return window['go']['main']['App']['SwitchAccount'](arg1);
+}
+
+export function TrustCurrentNetwork() {
+  return window['go']['main']['App']['TrustCurrentNetwork']();
+}
+
+export function VerifyConnection() {
+  return window['go']['main']['App']['VerifyConnection']();
+}
@@ -1,57 +1,182 @@
-export namespace models {
+export namespace api {
 	
-	export class Session {
-	    id: string;
-	    user_id: string;
-	    node_id: string;
+	export class NodeQuery {
+	    country: string;
+	    city: string;
 	    protocol: string;
-	    client_ip: string;
-	    tunnel_ip: string;
 	    status: string;
-	    // Go type: time
-	    connected_at: any;
-	    // Go type: time
-	    disconnected_at?: any;
-	    bytes_sent: number;
-	    bytes_received: number;
-	    data_used_gb: number;
-	    latency: number;
-	    packet_loss: number;
-	    split_tunnel_enabled: boolean;
-	    kill_switch_enabled: boolean;
-	    dns_leak_protection: boolean;
-	    is_multi_hop: boolean;
-	    client_version: string;
-	    device_type: string;
-	    os_type: string;
+	    max_load: number;
+	    min_uptime: number;
+	    operator_owned?: boolean;
+	    sort: string;
+	    limit: number;
+	    offset: number;
 	
 	    static createFrom(source: any = {}) {
-	        return new Session(source);
+	        return new NodeQuery(source);
 	    }
 	
 	    constructor(source: any = {}) {
 	        if ('string' === typeof source) source = JSON.parse(source);
-	        this.id = source["id"];
-	        this.user_id = source["user_id"];
-	        this.node_id = source["node_id"];
+	        this.country = source["country"];
+	        this.city = source["city"];
 	        this.protocol = source["protocol"];
-	        this.client_ip = source["client_ip"];
-	        this.tunnel_ip = source["tunnel_ip"];
 	        this.status = source["status"];
-	        this.connected_at = this.convertValues(source["connected_at"], null);
-	        this.disconnected_at = this.convertValues(source["disconnected_at"], null);
-	        this.bytes_sent = source["bytes_sent"];
-	        this.bytes_received = source["bytes_received"];
-	        this.data_used_gb = source["data_used_gb"];
-	        this.latency = source["latency"];
-	        this.packet_loss = source["packet_loss"];
-	        this.split_tunnel_enabled = source["split_tunnel_enabled"];
-	        this.kill_switch_enabled = source["kill_switch_enabled"];
+	        this.max_load = source["max_load"];
+	        this.min_uptime = source["min_uptime"];
+	        this.operator_owned = source["operator_owned"];
+	        this.sort = source["sort"];
+	        this.limit = source["limit"];
+	        this.offset = source["offset"];
+	    }
+	}
+
+}
+
+export namespace config {
+	
+	export class APIProxy {
+	    mode: string;
+	    address?: string;
+	    pac_url?: string;
+	    username?: string;
+	    bypass_when_connected: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new APIProxy(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.mode = source["mode"];
+	        this.address = source["address"];
+	        this.pac_url = source["pac_url"];
+	        this.username = source["username"];
+	        this.bypass_when_connected = source["bypass_when_connected"];
+	    }
+	}
+	export class APIServer {
+	    url: string;
+	    ca_file?: string;
+	    client_cert?: string;
+	    client_key?: string;
+	    pins?: string[];
+	
+	    static createFrom(source: any = {}) {
+	        return new APIServer(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.url = source["url"];
+	        this.ca_file = source["ca_file"];
+	        this.client_cert = source["client_cert"];
+	        this.client_key = source["client_key"];
+	        this.pins = source["pins"];
+	    }
+	}
+	export class AutoConnect {
+	    connect_on_startup: boolean;
+	    connect_on_untrusted_wifi: boolean;
+	    connect_on_untrusted_networks: boolean;
+	    disconnect_on_trusted: boolean;
+	    trusted_networks: string[];
+	    profile: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new AutoConnect(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.connect_on_startup = source["connect_on_startup"];
+	        this.connect_on_untrusted_wifi = source["connect_on_untrusted_wifi"];
+	        this.connect_on_untrusted_networks = source["connect_on_untrusted_networks"];
+	        this.disconnect_on_trusted = source["disconnect_on_trusted"];
+	        this.trusted_networks = source["trusted_networks"];
+	        this.profile = source["profile"];
+	    }
+	}
+	export class Location {
+	    manual: boolean;
+	    latitude: number;
+	    longitude: number;
+	    geoip_database: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new Location(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.manual = source["manual"];
+	        this.latitude = source["latitude"];
+	        this.longitude = source["longitude"];
+	        this.geoip_database = source["geoip_database"];
+	    }
+	}
+	export class Preferences {
+	    protocol: string;
+	    kill_switch: boolean;
+	    dns_leak_protection: boolean;
+	    notifications: boolean;
+	    mtu: number;
+	    key_rotation_days: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new Preferences(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.protocol = source["protocol"];
+	        this.kill_switch = source["kill_switch"];
 	        this.dns_leak_protection = source["dns_leak_protection"];
-	        this.is_multi_hop = source["is_multi_hop"];
-	        this.client_version = source["client_version"];
-	        this.device_type = source["device_type"];
-	        this.os_type = source["os_type"];
+	        this.notifications = source["notifications"];
+	        this.mtu = source["mtu"];
+	        this.key_rotation_days = source["key_rotation_days"];
+	    }
+	}
+	export class SplitTunnel {
+	    mode: string;
+	    routes?: string[];
+	
+	    static createFrom(source: any = {}) {
+	        return new SplitTunnel(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.mode = source["mode"];
+	        this.routes = source["routes"];
+	    }
+	}
+	export class Profile {
+	    name: string;
+	    node_id?: string;
+	    country?: string;
+	    protocol: string;
+	    split_tunnel: SplitTunnel;
+	    dns?: string[];
+	    mtu?: number;
+	    kill_switch?: boolean;
+	    transport?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new Profile(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.name = source["name"];
+	        this.node_id = source["node_id"];
+	        this.country = source["country"];
+	        this.protocol = source["protocol"];
+	        this.split_tunnel = this.convertValues(source["split_tunnel"], SplitTunnel);
+	        this.dns = source["dns"];
+	        this.mtu = source["mtu"];
+	        this.kill_switch = source["kill_switch"];
+	        this.transport = source["transport"];
 	    }
 	
 		convertValues(a: any, classs: any, asMap: boolean = false): any {
@@ -72,43 +197,97 @@ export namespace models {
 		    return a;
 		}
 	}
-	export class User {
+
+}
+
+export namespace geo {
+	
+	export class Location {
+	    latitude: number;
+	    longitude: number;
+	    source: string;
+	    ip?: string;
+	    city?: string;
+	    country?: string;
+	    country_code?: string;
+	    accuracy_km?: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new Location(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.latitude = source["latitude"];
+	        this.longitude = source["longitude"];
+	        this.source = source["source"];
+	        this.ip = source["ip"];
+	        this.city = source["city"];
+	        this.country = source["country"];
+	        this.country_code = source["country_code"];
+	        this.accuracy_km = source["accuracy_km"];
+	    }
+	}
+	export class NodeDistance {
+	    node: models.VPNNode;
+	    distance_km: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new NodeDistance(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.node = this.convertValues(source["node"], models.VPNNode);
+	        this.distance_km = source["distance_km"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+
+}
+
+export namespace main {
+	
+	export class Account {
 	    id: string;
+	    api_url: string;
+	    user_id: string;
 	    email: string;
 	    username: string;
-	    full_name: string;
-	    subscription_tier: string;
-	    // Go type: time
-	    subscription_expiry: any;
-	    is_active: boolean;
-	    is_admin: boolean;
-	    data_transferred_gb: number;
-	    connection_count: number;
-	    two_factor_enabled: boolean;
+	    active: boolean;
 	    // Go type: time
-	    created_at: any;
-	    // Go type: time
-	    updated_at: any;
+	    last_used: any;
 	
 	    static createFrom(source: any = {}) {
-	        return new User(source);
+	        return new Account(source);
 	    }
 	
 	    constructor(source: any = {}) {
 	        if ('string' === typeof source) source = JSON.parse(source);
 	        this.id = source["id"];
+	        this.api_url = source["api_url"];
+	        this.user_id = source["user_id"];
 	        this.email = source["email"];
 	        this.username = source["username"];
-	        this.full_name = source["full_name"];
-	        this.subscription_tier = source["subscription_tier"];
-	        this.subscription_expiry = this.convertValues(source["subscription_expiry"], null);
-	        this.is_active = source["is_active"];
-	        this.is_admin = source["is_admin"];
-	        this.data_transferred_gb = source["data_transferred_gb"];
-	        this.connection_count = source["connection_count"];
-	        this.two_factor_enabled = source["two_factor_enabled"];
-	        this.created_at = this.convertValues(source["created_at"], null);
-	        this.updated_at = this.convertValues(source["updated_at"], null);
+	        this.active = source["active"];
+	        this.last_used = this.convertValues(source["last_used"], null);
 	    }
 	
 		convertValues(a: any, classs: any, asMap: boolean = false): any {
@@ -129,6 +308,11 @@ export namespace models {
 		    return a;
 		}
 	}
+
+}
+
+export namespace models {
+	
 	export class VPNNode {
 	    id: string;
 	    name: string;
@@ -202,6 +386,741 @@ export namespace models {
 		    return a;
 		}
 	}
+	export class NodeListResponse {
+	    nodes: VPNNode[];
+	    total_count: number;
+	    count: number;
+	    source?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new NodeListResponse(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.nodes = this.convertValues(source["nodes"], VPNNode);
+	        this.total_count = source["total_count"];
+	        this.count = source["count"];
+	        this.source = source["source"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class Session {
+	    id: string;
+	    user_id: string;
+	    node_id: string;
+	    protocol: string;
+	    client_ip: string;
+	    tunnel_ip: string;
+	    status: string;
+	    // Go type: time
+	    connected_at: any;
+	    // Go type: time
+	    disconnected_at?: any;
+	    bytes_sent: number;
+	    bytes_received: number;
+	    data_used_gb: number;
+	    latency: number;
+	    packet_loss: number;
+	    split_tunnel_enabled: boolean;
+	    kill_switch_enabled: boolean;
+	    dns_leak_protection: boolean;
+	    is_multi_hop: boolean;
+	    client_version: string;
+	    device_type: string;
+	    os_type: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new Session(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.user_id = source["user_id"];
+	        this.node_id = source["node_id"];
+	        this.protocol = source["protocol"];
+	        this.client_ip = source["client_ip"];
+	        this.tunnel_ip = source["tunnel_ip"];
+	        this.status = source["status"];
+	        this.connected_at = this.convertValues(source["connected_at"], null);
+	        this.disconnected_at = this.convertValues(source["disconnected_at"], null);
+	        this.bytes_sent = source["bytes_sent"];
+	        this.bytes_received = source["bytes_received"];
+	        this.data_used_gb = source["data_used_gb"];
+	        this.latency = source["latency"];
+	        this.packet_loss = source["packet_loss"];
+	        this.split_tunnel_enabled = source["split_tunnel_enabled"];
+	        this.kill_switch_enabled = source["kill_switch_enabled"];
+	        this.dns_leak_protection = source["dns_leak_protection"];
+	        this.is_multi_hop = source["is_multi_hop"];
+	        this.client_version = source["client_version"];
+	        this.device_type = source["device_type"];
+	        this.os_type = source["os_type"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class User {
+	    id: string;
+	    email: string;
+	    username: string;
+	    full_name: string;
+	    subscription_tier: string;
+	    // Go type: time
+	    subscription_expiry: any;
+	    is_active: boolean;
+	    is_admin: boolean;
+	    data_transferred_gb: number;
+	    connection_count: number;
+	    two_factor_enabled: boolean;
+	    // Go type: time
+	    created_at: any;
+	    // Go type: time
+	    updated_at: any;
+	
+	    static createFrom(source: any = {}) {
+	        return new User(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.email = source["email"];
+	        this.username = source["username"];
+	        this.full_name = source["full_name"];
+	        this.subscription_tier = source["subscription_tier"];
+	        this.subscription_expiry = this.convertValues(source["subscription_expiry"], null);
+	        this.is_active = source["is_active"];
+	        this.is_admin = source["is_admin"];
+	        this.data_transferred_gb = source["data_transferred_gb"];
+	        this.connection_count = source["connection_count"];
+	        this.two_factor_enabled = source["two_factor_enabled"];
+	        this.created_at = this.convertValues(source["created_at"], null);
+	        this.updated_at = this.convertValues(source["updated_at"], null);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+
+}
+
+export namespace netcheck {
+	
+	export class CaptivePortal {
+	    detected: boolean;
+	    url?: string;
+	    reason?: string;
+	    // Go type: time
+	    checked_at: any;
+	
+	    static createFrom(source: any = {}) {
+	        return new CaptivePortal(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.detected = source["detected"];
+	        this.url = source["url"];
+	        this.reason = source["reason"];
+	        this.checked_at = this.convertValues(source["checked_at"], null);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class Egress {
+	    ipv4?: string;
+	    ipv6?: string;
+	    expected: string[];
+	    verified: boolean;
+	    leak: boolean;
+	    ipv6_leak: boolean;
+	    country?: string;
+	    country_mismatch?: boolean;
+	    reason?: string;
+	    // Go type: time
+	    checked_at: any;
+	
+	    static createFrom(source: any = {}) {
+	        return new Egress(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.ipv4 = source["ipv4"];
+	        this.ipv6 = source["ipv6"];
+	        this.expected = source["expected"];
+	        this.verified = source["verified"];
+	        this.leak = source["leak"];
+	        this.ipv6_leak = source["ipv6_leak"];
+	        this.country = source["country"];
+	        this.country_mismatch = source["country_mismatch"];
+	        this.reason = source["reason"];
+	        this.checked_at = this.convertValues(source["checked_at"], null);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+
+}
+
+export namespace nodes {
+	
+	export class Attempt {
+	    node_id: string;
+	    node_name: string;
+	    protocol: string;
+	    // Go type: time
+	    started_at: any;
+	    // Go type: time
+	    ended_at: any;
+	    success: boolean;
+	    error?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new Attempt(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.node_id = source["node_id"];
+	        this.node_name = source["node_name"];
+	        this.protocol = source["protocol"];
+	        this.started_at = this.convertValues(source["started_at"], null);
+	        this.ended_at = this.convertValues(source["ended_at"], null);
+	        this.success = source["success"];
+	        this.error = source["error"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class Group {
+	    id: string;
+	    name: string;
+	    nodes: models.VPNNode[];
+	
+	    static createFrom(source: any = {}) {
+	        return new Group(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.name = source["name"];
+	        this.nodes = this.convertValues(source["nodes"], models.VPNNode);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class MTU {
+	    node_id: string;
+	    network_id: string;
+	    path_mtu: number;
+	    mtu: number;
+	    method: string;
+	    // Go type: time
+	    measured_at: any;
+	
+	    static createFrom(source: any = {}) {
+	        return new MTU(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.node_id = source["node_id"];
+	        this.network_id = source["network_id"];
+	        this.path_mtu = source["path_mtu"];
+	        this.mtu = source["mtu"];
+	        this.method = source["method"];
+	        this.measured_at = this.convertValues(source["measured_at"], null);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class Recent {
+	    node_id: string;
+	    node_name: string;
+	    protocol: string;
+	    // Go type: time
+	    last_attempt: any;
+	    last_success: boolean;
+	    last_error?: string;
+	    successes: number;
+	    failures: number;
+	    total_seconds: number;
+	    average_seconds: number;
+	    last_seconds: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new Recent(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.node_id = source["node_id"];
+	        this.node_name = source["node_name"];
+	        this.protocol = source["protocol"];
+	        this.last_attempt = this.convertValues(source["last_attempt"], null);
+	        this.last_success = source["last_success"];
+	        this.last_error = source["last_error"];
+	        this.successes = source["successes"];
+	        this.failures = source["failures"];
+	        this.total_seconds = source["total_seconds"];
+	        this.average_seconds = source["average_seconds"];
+	        this.last_seconds = source["last_seconds"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+
+}
+
+export namespace proxy {
+	
+	export class ConnStats {
+	    id: number;
+	    client: string;
+	    target: string;
+	    // Go type: time
+	    started_at: any;
+	    // Go type: time
+	    closed_at: any;
+	    bytes_up: number;
+	    bytes_down: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new ConnStats(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.client = source["client"];
+	        this.target = source["target"];
+	        this.started_at = this.convertValues(source["started_at"], null);
+	        this.closed_at = this.convertValues(source["closed_at"], null);
+	        this.bytes_up = source["bytes_up"];
+	        this.bytes_down = source["bytes_down"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class Status {
+	    kind: string;
+	    addr: string;
+	    auth_required: boolean;
+	    active_connections: number;
+	    total_connections: number;
+	    bytes_up: number;
+	    bytes_down: number;
+	    connections: ConnStats[];
+	
+	    static createFrom(source: any = {}) {
+	        return new Status(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.kind = source["kind"];
+	        this.addr = source["addr"];
+	        this.auth_required = source["auth_required"];
+	        this.active_connections = source["active_connections"];
+	        this.total_connections = source["total_connections"];
+	        this.bytes_up = source["bytes_up"];
+	        this.bytes_down = source["bytes_down"];
+	        this.connections = this.convertValues(source["connections"], ConnStats);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+
+}
+
+export namespace usage {
+	
+	export class CapStatus {
+	    period: string;
+	    soft_bytes: number;
+	    hard_bytes: number;
+	    used: number;
+	    level: string;
+	    // Go type: time
+	    period_start: any;
+	    // Go type: time
+	    period_end: any;
+	
+	    static createFrom(source: any = {}) {
+	        return new CapStatus(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.period = source["period"];
+	        this.soft_bytes = source["soft_bytes"];
+	        this.hard_bytes = source["hard_bytes"];
+	        this.used = source["used"];
+	        this.level = source["level"];
+	        this.period_start = this.convertValues(source["period_start"], null);
+	        this.period_end = this.convertValues(source["period_end"], null);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class Totals {
+	    bytes_sent: number;
+	    bytes_received: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new Totals(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.bytes_sent = source["bytes_sent"];
+	        this.bytes_received = source["bytes_received"];
+	    }
+	}
+	export class Rollup {
+	    period: string;
+	    // Go type: time
+	    start: any;
+	    // Go type: time
+	    end: any;
+	    nodes: Record<string, Totals>;
+	    bytes_sent: number;
+	    bytes_received: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new Rollup(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.period = source["period"];
+	        this.start = this.convertValues(source["start"], null);
+	        this.end = this.convertValues(source["end"], null);
+	        this.nodes = this.convertValues(source["nodes"], Totals, true);
+	        this.bytes_sent = source["bytes_sent"];
+	        this.bytes_received = source["bytes_received"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class Session {
+	    id: number;
+	    node_id: string;
+	    node_name: string;
+	    protocol: string;
+	    // Go type: time
+	    started_at: any;
+	    // Go type: time
+	    ended_at: any;
+	    bytes_sent: number;
+	    bytes_received: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new Session(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.node_id = source["node_id"];
+	        this.node_name = source["node_name"];
+	        this.protocol = source["protocol"];
+	        this.started_at = this.convertValues(source["started_at"], null);
+	        this.ended_at = this.convertValues(source["ended_at"], null);
+	        this.bytes_sent = source["bytes_sent"];
+	        this.bytes_received = source["bytes_received"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+
+}
+
+export namespace vpn {
+	
+	export class BackendInfo {
+	    name: string;
+	    available: boolean;
+	    reason?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new BackendInfo(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.name = source["name"];
+	        this.available = source["available"];
+	        this.reason = source["reason"];
+	    }
+	}
+	export class Sample {
+	    // Go type: time
+	    time: any;
+	    bytes_sent: number;
+	    bytes_received: number;
+	    rx_rate: number;
+	    tx_rate: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new Sample(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.time = this.convertValues(source["time"], null);
+	        this.bytes_sent = source["bytes_sent"];
+	        this.bytes_received = source["bytes_received"];
+	        this.rx_rate = source["rx_rate"];
+	        this.tx_rate = source["tx_rate"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
 
 }
 
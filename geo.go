@@ -0,0 +1,163 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/nikola43/aureo-vpn-client/internal/config"
+	"github.com/nikola43/aureo-vpn-client/internal/geo"
+	"github.com/nikola43/aureo-vpn-client/internal/models"
+	"github.com/nikola43/aureo-vpn-client/internal/netcheck"
+)
+
+const (
+	// geoIPDatabaseFile is looked up in the config directory when no
+	// database path is set
+	geoIPDatabaseFile = "GeoLite2-City.mmdb"
+	// locationTTL is how long a GeoIP location is reused while disconnected
+	locationTTL = 10 * time.Minute
+	// locateTimeout bounds the public IP lookup
+	locateTimeout = 10 * time.Second
+)
+
+// locate returns the client's location: the manual setting, or a GeoIP
+// lookup of the public IP. While connected the public IP is the node's, so
+// the last lookup made without the tunnel is reused. The lookup sends the
+// real IP to a third party, so it is only made when lookup is set, i.e.
+// when the user asked for the location; otherwise only an earlier result
+// is used.
+func (a *App) locate(lookup bool) (*geo.Location, error) {
+	settings := a.settings.Location
+	if settings.Manual {
+		loc := &geo.Location{
+			Point:  geo.Point{Latitude: settings.Latitude, Longitude: settings.Longitude},
+			Source: geo.SourceManual,
+		}
+		return loc, nil
+	}
+
+	if a.location != nil && (a.IsConnected() || time.Since(a.locatedAt) < locationTTL) {
+		return a.location, nil
+	}
+	if a.IsConnected() {
+		return nil, fmt.Errorf("location unknown: disconnect once or set it manually")
+	}
+	if !lookup {
+		return nil, fmt.Errorf("location unknown: set it manually or look it up first")
+	}
+
+	path, ok := a.geoIPDatabase()
+	if !ok {
+		return nil, fmt.Errorf("no GeoIP database at %s: set a location manually or install %s", path, geoIPDatabaseFile)
+	}
+
+	ctx, cancel := context.WithTimeout(a.ctx, locateTimeout)
+	defer cancel()
+	ip, err := netcheck.PublicIP(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	loc, err := geo.LookupDB(path, ip)
+	if err != nil {
+		return nil, err
+	}
+	a.location = &loc
+	a.locatedAt = time.Now()
+	return a.location, nil
+}
+
+// geoIPDatabase returns the GeoIP database path and whether the file exists
+func (a *App) geoIPDatabase() (string, bool) {
+	path := a.settings.Location.GeoIPDatabase
+	if path == "" {
+		path = filepath.Join(a.configDir, geoIPDatabaseFile)
+	}
+	_, err := os.Stat(path)
+	return path, err == nil
+}
+
+// bestNode picks a node automatically, optionally within a country. With a
+// known location, active nodes are scored by load and distance; without
+// one the API's best node, or the least loaded node in the country, is used.
+// It never looks the location up itself, as it runs on auto-connect.
+func (a *App) bestNode(country, protocol string) (*models.VPNNode, error) {
+	loc, locErr := a.locate(false)
+	if locErr != nil && country == "" {
+		logger.Debug("choosing best node without location", "error", locErr)
+		return a.apiClient.GetBestNode()
+	}
+
+	nodes, err := a.apiClient.GetNodes(country, protocol)
+	if err != nil {
+		return nil, err
+	}
+
+	if locErr == nil {
+		if node, ok := geo.Best(loc.Point, nodes); ok {
+			return &node, nil
+		}
+	} else {
+		var best *models.VPNNode
+		for i := range nodes {
+			if nodes[i].IsActive && (best == nil || nodes[i].LoadScore < best.LoadScore) {
+				best = &nodes[i]
+			}
+		}
+		if best != nil {
+			return best, nil
+		}
+	}
+
+	if country != "" {
+		return nil, fmt.Errorf("no active nodes in %s", country)
+	}
+	return nil, fmt.Errorf("no active nodes")
+}
+
+// GetLocation returns the client's estimated location, looking up the
+// public IP if needed
+func (a *App) GetLocation() (*geo.Location, error) {
+	return a.locate(true)
+}
+
+// GetLocationSettings returns how the location is determined
+func (a *App) GetLocationSettings() config.Location {
+	return a.settings.Location
+}
+
+// SetLocationSettings sets a manual location or the GeoIP database path
+func (a *App) SetLocationSettings(loc config.Location) error {
+	if loc.Manual {
+		if p := (geo.Point{Latitude: loc.Latitude, Longitude: loc.Longitude}); !p.Valid() {
+			return fmt.Errorf("invalid coordinates: %g, %g", loc.Latitude, loc.Longitude)
+		}
+	}
+
+	a.settings.Location = loc
+	a.location = nil
+	return a.settings.Save(a.configDir)
+}
+
+// GetNodeDistances returns every node with its distance from the client,
+// nearest first
+func (a *App) GetNodeDistances() ([]geo.NodeDistance, error) {
+	return a.GetNearestNodes(0)
+}
+
+// GetNearestNodes returns the n nodes nearest to the client; 0 returns all
+func (a *App) GetNearestNodes(n int) ([]geo.NodeDistance, error) {
+	loc, err := a.locate(true)
+	if err != nil {
+		return nil, err
+	}
+
+	nodes, err := a.apiClient.GetNodes("", "")
+	if err != nil {
+		return nil, err
+	}
+	return geo.Nearest(loc.Point, nodes, n), nil
+}
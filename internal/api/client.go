@@ -3,31 +3,73 @@ package api
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
+	"strings"
 	"time"
 
+	"github.com/nikola43/aureo-vpn-client/internal/logging"
 	"github.com/nikola43/aureo-vpn-client/internal/models"
+	"github.com/nikola43/aureo-vpn-client/internal/secrets"
 )
 
+var logger = logging.For(logging.SubsystemAPI)
+
+// ErrUnexpectedResponse is returned when the server answers with something
+// other than JSON, typically a captive portal's HTML page
+var ErrUnexpectedResponse = errors.New("unexpected non-JSON response from API")
+
 // Client represents the API client
 type Client struct {
 	baseURL     string
 	httpClient  *http.Client
 	accessToken string
+	// proxy picks the proxy for each request; nil follows the environment
+	proxy func(*http.Request) (*url.URL, error)
 }
 
-// NewClient creates a new API client
+// NewClient creates a new API client. The production host's keys are
+// pinned; use SetTLS for a custom CA, client certificate or pins.
 func NewClient(baseURL string) *Client {
-	return &Client{
+	c := &Client{
 		baseURL: baseURL,
 		httpClient: &http.Client{
 			Timeout: 30 * time.Second,
 		},
 		accessToken: "",
 	}
+	if err := c.SetTLS(TLSConfig{}); err != nil {
+		// SetTLS left the client refusing to connect
+		logger.Error("invalid built-in API pins, API requests will fail", "error", err)
+	}
+	return c
+}
+
+// SetProxy sets the function that picks the proxy for each request, as
+// http.Transport.Proxy does. A nil proxy follows the environment.
+func (c *Client) SetProxy(proxy func(*http.Request) (*url.URL, error)) {
+	c.proxy = proxy
+	if transport, ok := c.httpClient.Transport.(*http.Transport); ok {
+		transport.Proxy = c.proxyFunc()
+		transport.CloseIdleConnections()
+	}
+}
+
+// CloseIdleConnections drops kept-alive connections, so the next requests
+// pick their proxy again
+func (c *Client) CloseIdleConnections() {
+	c.httpClient.CloseIdleConnections()
+}
+
+// proxyFunc returns the proxy function for the transport
+func (c *Client) proxyFunc() func(*http.Request) (*url.URL, error) {
+	if c.proxy == nil {
+		return http.ProxyFromEnvironment
+	}
+	return c.proxy
 }
 
 // SetAccessToken sets the access token for authenticated requests
@@ -62,18 +104,29 @@ func (c *Client) doRequest(method, path string, body interface{}, requiresAuth b
 		req.Header.Set("Authorization", "Bearer "+c.accessToken)
 	}
 
+	start := time.Now()
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
+		logger.Warn("request failed", "method", method, "path", path, "error", err)
 		return nil, fmt.Errorf("failed to perform request: %w", err)
 	}
 	defer resp.Body.Close()
 
+	logger.Debug("request", "method", method, "path", path, "status", resp.StatusCode, "duration", time.Since(start))
+
 	respBody, err := io.ReadAll(resp.Body)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read response body: %w", err)
 	}
 
+	if isHTML(resp, respBody) {
+		logger.Warn("non-JSON response", "method", method, "path", path, "status", resp.StatusCode, "content_type", resp.Header.Get("Content-Type"))
+		return nil, fmt.Errorf("%w (status %d)", ErrUnexpectedResponse, resp.StatusCode)
+	}
+
 	if resp.StatusCode >= 400 {
+		logger.Warn("API error", "method", method, "path", path, "status", resp.StatusCode)
+
 		var errResp models.ErrorResponse
 		if err := json.Unmarshal(respBody, &errResp); err == nil {
 			if errResp.Message != "" {
@@ -89,6 +142,15 @@ func (c *Client) doRequest(method, path string, body interface{}, requiresAuth b
 	return respBody, nil
 }
 
+// isHTML reports whether a response is an HTML page rather than JSON
+func isHTML(resp *http.Response, body []byte) bool {
+	if strings.Contains(resp.Header.Get("Content-Type"), "text/html") {
+		return true
+	}
+	trimmed := bytes.TrimSpace(body)
+	return len(trimmed) > 0 && trimmed[0] == '<'
+}
+
 // ============================================
 // HEALTH & STATUS
 // ============================================
@@ -264,31 +326,13 @@ func (c *Client) GetUserSessions() ([]models.Session, error) {
 // NODE ENDPOINTS
 // ============================================
 
-// GetNodes retrieves the list of available VPN nodes
+// GetNodes retrieves the list of available VPN nodes, reading every page
 func (c *Client) GetNodes(country, protocol string) ([]models.VPNNode, error) {
-	path := "/api/v1/nodes"
-	params := url.Values{}
-	if country != "" {
-		params.Add("country", country)
-	}
-	if protocol != "" {
-		params.Add("protocol", protocol)
-	}
-	if len(params) > 0 {
-		path += "?" + params.Encode()
-	}
-
-	respBody, err := c.doRequest("GET", path, nil, true)
+	resp, err := c.QueryNodes(NodeQuery{Country: country, Protocol: protocol})
 	if err != nil {
 		return nil, err
 	}
-
-	var nodeListResp models.NodeListResponse
-	if err := json.Unmarshal(respBody, &nodeListResp); err != nil {
-		return nil, fmt.Errorf("failed to parse nodes response: %w", err)
-	}
-
-	return nodeListResp.Nodes, nil
+	return resp.Nodes, nil
 }
 
 // GetBestNode retrieves the best available node
@@ -390,17 +434,24 @@ func (c *Client) GenerateConfig(nodeID, protocol string) (*models.GenerateConfig
 	return &configResp, nil
 }
 
-// RegisterWireGuardPeer registers a WireGuard peer with the VPN server
-func (c *Client) RegisterWireGuardPeer(nodeID, publicKey string) (*models.WireGuardConfigResponse, error) {
-	reqBody := map[string]string{
+// RegisterWireGuardPeer registers a WireGuard peer with the VPN server.
+// presharedKey, if set, is proposed as the peer's pre-shared key; the one
+// the server settled on is returned in the response.
+func (c *Client) RegisterWireGuardPeer(nodeID, publicKey string, presharedKey secrets.Buffer) (*models.WireGuardConfigResponse, error) {
+	reqBody := map[string]interface{}{
 		"node_id":    nodeID,
 		"public_key": publicKey,
 	}
+	if len(presharedKey) > 0 {
+		reqBody["preshared_key"] = presharedKey
+	}
 
 	respBody, err := c.doRequest("POST", "/api/v1/config/generate", reqBody, true)
 	if err != nil {
 		return nil, err
 	}
+	// The response carries the pre-shared key
+	defer secrets.Buffer(respBody).Wipe()
 
 	var configResp models.WireGuardConfigResponse
 	if err := json.Unmarshal(respBody, &configResp); err != nil {
@@ -410,6 +461,17 @@ func (c *Client) RegisterWireGuardPeer(nodeID, publicKey string) (*models.WireGu
 	return &configResp, nil
 }
 
+// RevokeWireGuardPeer removes a previously registered public key from a node
+func (c *Client) RevokeWireGuardPeer(nodeID, publicKey string) error {
+	reqBody := map[string]string{
+		"node_id":    nodeID,
+		"public_key": publicKey,
+	}
+
+	_, err := c.doRequest("POST", "/api/v1/config/revoke", reqBody, true)
+	return err
+}
+
 // ============================================
 // OPERATOR ENDPOINTS
 // ============================================
@@ -0,0 +1,268 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/nikola43/aureo-vpn-client/internal/models"
+)
+
+const (
+	// nodePageSize is the page size requested from the server
+	nodePageSize = 100
+	// maxNodePages bounds pagination against a server that never stops
+	maxNodePages = 50
+)
+
+// Node sort keys; prefix with "-" for descending order
+const (
+	SortByLoad        = "load"
+	SortByLatency     = "latency"
+	SortByUptime      = "uptime"
+	SortByName        = "name"
+	SortByCountry     = "country"
+	SortByConnections = "connections"
+)
+
+// NodeQuery filters, sorts and pages the node list. Zero values leave a
+// field unfiltered. Filters are sent to the server and applied again
+// locally, so results are the same whether or not the server supports them.
+type NodeQuery struct {
+	Country  string `json:"country"`
+	City     string `json:"city"`
+	Protocol string `json:"protocol"`
+	Status   string `json:"status"`
+	// MaxLoad keeps nodes with a load score at or below it
+	MaxLoad float64 `json:"max_load"`
+	// MinUptime keeps nodes with an uptime percentage at or above it
+	MinUptime float64 `json:"min_uptime"`
+	// OperatorOwned keeps only community-operated (true) or first-party
+	// (false) nodes when set
+	OperatorOwned *bool `json:"operator_owned"`
+	// Sort is one of the SortBy keys, optionally prefixed with "-"
+	Sort   string `json:"sort"`
+	Limit  int    `json:"limit"`
+	Offset int    `json:"offset"`
+}
+
+// Validate checks the sort key and paging values
+func (q NodeQuery) Validate() error {
+	if q.Sort != "" {
+		if _, _, err := nodeSortKey(q.Sort); err != nil {
+			return err
+		}
+	}
+	if q.Limit < 0 || q.Offset < 0 {
+		return fmt.Errorf("limit and offset must not be negative")
+	}
+	return nil
+}
+
+// params returns the filter and sort parameters for the server
+func (q NodeQuery) params() url.Values {
+	params := url.Values{}
+	set := func(key, value string) {
+		if value != "" {
+			params.Set(key, value)
+		}
+	}
+	set("country", q.Country)
+	set("city", q.City)
+	set("protocol", q.Protocol)
+	set("status", q.Status)
+	if q.MaxLoad > 0 {
+		params.Set("max_load", strconv.FormatFloat(q.MaxLoad, 'f', -1, 64))
+	}
+	if q.MinUptime > 0 {
+		params.Set("min_uptime", strconv.FormatFloat(q.MinUptime, 'f', -1, 64))
+	}
+	if q.OperatorOwned != nil {
+		params.Set("operator_owned", strconv.FormatBool(*q.OperatorOwned))
+	}
+	set("sort", q.Sort)
+	return params
+}
+
+// Matches reports whether a node passes the query's filters
+func (q NodeQuery) Matches(node models.VPNNode) bool {
+	if q.Country != "" && !strings.EqualFold(node.CountryCode, q.Country) && !strings.EqualFold(node.Country, q.Country) {
+		return false
+	}
+	if q.City != "" && !strings.EqualFold(node.City, q.City) {
+		return false
+	}
+	switch strings.ToLower(q.Protocol) {
+	case "wireguard":
+		if !node.SupportsWireGuard {
+			return false
+		}
+	case "openvpn":
+		if !node.SupportsOpenVPN {
+			return false
+		}
+	}
+	if q.Status != "" && !strings.EqualFold(node.Status, q.Status) {
+		return false
+	}
+	if q.MaxLoad > 0 && node.LoadScore > q.MaxLoad {
+		return false
+	}
+	if q.MinUptime > 0 && node.UptimePercentage < q.MinUptime {
+		return false
+	}
+	if q.OperatorOwned != nil && node.IsOperatorOwned != *q.OperatorOwned {
+		return false
+	}
+	return true
+}
+
+// Apply filters, sorts and pages a node list locally. It returns the page
+// and the number of nodes that matched before paging.
+func (q NodeQuery) Apply(nodes []models.VPNNode) ([]models.VPNNode, int, error) {
+	if err := q.Validate(); err != nil {
+		return nil, 0, err
+	}
+
+	matched := []models.VPNNode{}
+	for _, node := range nodes {
+		if q.Matches(node) {
+			matched = append(matched, node)
+		}
+	}
+
+	if q.Sort != "" {
+		less, desc, _ := nodeSortKey(q.Sort)
+		sort.SliceStable(matched, func(i, j int) bool {
+			if desc {
+				return less(matched[j], matched[i])
+			}
+			return less(matched[i], matched[j])
+		})
+	}
+
+	total := len(matched)
+	if q.Offset >= total {
+		return []models.VPNNode{}, total, nil
+	}
+	matched = matched[q.Offset:]
+	if q.Limit > 0 && len(matched) > q.Limit {
+		matched = matched[:q.Limit]
+	}
+	return matched, total, nil
+}
+
+// nodeSortKey returns the comparison for a sort key and whether it is descending
+func nodeSortKey(key string) (func(a, b models.VPNNode) bool, bool, error) {
+	desc := strings.HasPrefix(key, "-")
+	switch strings.TrimPrefix(key, "-") {
+	case SortByLoad:
+		return func(a, b models.VPNNode) bool { return a.LoadScore < b.LoadScore }, desc, nil
+	case SortByLatency:
+		// Unmeasured latency sorts last
+		return func(a, b models.VPNNode) bool {
+			if (a.Latency > 0) != (b.Latency > 0) {
+				return a.Latency > 0
+			}
+			return a.Latency < b.Latency
+		}, desc, nil
+	case SortByUptime:
+		return func(a, b models.VPNNode) bool { return a.UptimePercentage < b.UptimePercentage }, desc, nil
+	case SortByName:
+		return func(a, b models.VPNNode) bool { return strings.ToLower(a.Name) < strings.ToLower(b.Name) }, desc, nil
+	case SortByCountry:
+		return func(a, b models.VPNNode) bool {
+			if a.Country != b.Country {
+				return a.Country < b.Country
+			}
+			return a.City < b.City
+		}, desc, nil
+	case SortByConnections:
+		return func(a, b models.VPNNode) bool { return a.CurrentConnections < b.CurrentConnections }, desc, nil
+	}
+	return nil, false, fmt.Errorf("unknown sort key: %s", key)
+}
+
+// QueryNodes fetches every page of nodes matching the query and returns the
+// requested page. Filters the server ignores are applied locally, and the
+// caller's limit and offset are applied to the filtered result, so
+// TotalCount is the number of matching nodes.
+func (c *Client) QueryNodes(q NodeQuery) (*models.NodeListResponse, error) {
+	if err := q.Validate(); err != nil {
+		return nil, err
+	}
+
+	all, err := c.fetchNodePages(q.params())
+	if err != nil {
+		return nil, err
+	}
+
+	page, total, err := q.Apply(all)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.NodeListResponse{
+		Nodes:      page,
+		TotalCount: total,
+		Count:      len(page),
+	}, nil
+}
+
+// fetchNodePages requests /api/v1/nodes page by page until TotalCount
+// nodes have been read. A server that ignores limit/offset is detected by
+// an oversized or repeated page, which is then taken as the full list.
+func (c *Client) fetchNodePages(params url.Values) ([]models.VPNNode, error) {
+	var all []models.VPNNode
+	seen := map[string]bool{}
+
+	for page := 0; page < maxNodePages; page++ {
+		params.Set("limit", strconv.Itoa(nodePageSize))
+		params.Set("offset", strconv.Itoa(len(all)))
+
+		respBody, err := c.doRequest("GET", "/api/v1/nodes?"+params.Encode(), nil, true)
+		if err != nil {
+			return nil, err
+		}
+
+		var nodeListResp models.NodeListResponse
+		if err := json.Unmarshal(respBody, &nodeListResp); err != nil {
+			return nil, fmt.Errorf("failed to parse nodes response: %w", err)
+		}
+
+		nodes := nodeListResp.Nodes
+		if len(nodes) > nodePageSize {
+			// Pagination ignored: this is everything
+			return nodes, nil
+		}
+
+		added := 0
+		for _, node := range nodes {
+			if seen[node.ID] {
+				continue
+			}
+			seen[node.ID] = true
+			all = append(all, node)
+			added++
+		}
+
+		switch {
+		case added == 0:
+			// Empty or repeated page
+			if nodeListResp.TotalCount > len(all) {
+				logger.Warn("node list pagination stalled", "nodes", len(all), "total_count", nodeListResp.TotalCount)
+			}
+			return all, nil
+		case nodeListResp.TotalCount > 0 && len(all) >= nodeListResp.TotalCount:
+			return all, nil
+		case nodeListResp.TotalCount == 0 && len(nodes) < nodePageSize:
+			return all, nil
+		}
+	}
+
+	logger.Warn("node list truncated", "pages", maxNodePages, "nodes", len(all))
+	return all, nil
+}
@@ -0,0 +1,283 @@
+package api
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+const (
+	nodeStreamWSPath  = "/api/v1/nodes/stream"
+	nodeStreamSSEPath = "/api/v1/nodes/events"
+
+	streamMinBackoff = time.Second
+	streamMaxBackoff = time.Minute
+	// streamStableAfter is how long a connection must last for the backoff
+	// to be reset
+	streamStableAfter = 30 * time.Second
+	// streamReadTimeout closes a stream that has gone silent, including
+	// pings and SSE comments
+	streamReadTimeout = 90 * time.Second
+)
+
+// Node status message types
+const (
+	NodeStatusSnapshot = "snapshot"
+	NodeStatusUpdate   = "update"
+	NodeStatusRemove   = "remove"
+)
+
+// ErrStreamUnsupported is returned by StreamNodeStatus when the server has
+// neither a WebSocket nor a server-sent events endpoint
+var ErrStreamUnsupported = errors.New("node status stream not supported by server")
+
+// NodeStatus is a partial node update. Only the fields present in the
+// message are set.
+type NodeStatus struct {
+	ID                 string     `json:"id"`
+	Status             *string    `json:"status,omitempty"`
+	IsActive           *bool      `json:"is_active,omitempty"`
+	LoadScore          *float64   `json:"load_score,omitempty"`
+	Latency            *int       `json:"latency,omitempty"`
+	CurrentConnections *int       `json:"current_connections,omitempty"`
+	UptimePercentage   *float64   `json:"uptime_percentage,omitempty"`
+	LastHeartbeat      *time.Time `json:"last_heartbeat,omitempty"`
+}
+
+// NodeStatusMessage is one message of the node status feed
+type NodeStatusMessage struct {
+	Type  string       `json:"type"`
+	Nodes []NodeStatus `json:"nodes"`
+}
+
+// StreamNodeStatus subscribes to the node status feed and calls handle for
+// every message until ctx is cancelled. The WebSocket endpoint is tried
+// first, then server-sent events. Dropped connections are retried with
+// exponential backoff. onConnect, if set, is called after each successful
+// (re)connect, so the caller can resynchronize anything missed. It returns
+// ErrStreamUnsupported if the server offers neither endpoint.
+func (c *Client) StreamNodeStatus(ctx context.Context, handle func(NodeStatusMessage), onConnect func()) error {
+	backoff := streamMinBackoff
+	useSSE := false
+	connected := func() {
+		if onConnect != nil {
+			onConnect()
+		}
+	}
+
+	for {
+		start := time.Now()
+		var err error
+		if useSSE {
+			err = c.streamSSE(ctx, handle, connected)
+			if errors.Is(err, ErrStreamUnsupported) {
+				return err
+			}
+		} else {
+			err = c.streamWebSocket(ctx, handle, connected)
+			if errors.Is(err, ErrStreamUnsupported) {
+				logger.Info("node stream falling back to server-sent events")
+				useSSE = true
+				continue
+			}
+		}
+
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		if time.Since(start) > streamStableAfter {
+			backoff = streamMinBackoff
+		}
+		wait := backoff/2 + time.Duration(rand.Int63n(int64(backoff/2)+1))
+		logger.Debug("node stream disconnected", "error", err, "retry_in", wait)
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(wait):
+		}
+
+		backoff *= 2
+		if backoff > streamMaxBackoff {
+			backoff = streamMaxBackoff
+		}
+	}
+}
+
+// streamHeaders returns the headers for a stream request
+func (c *Client) streamHeaders() http.Header {
+	header := http.Header{}
+	if c.accessToken != "" {
+		header.Set("Authorization", "Bearer "+c.accessToken)
+	}
+	return header
+}
+
+// streamWebSocket reads messages from the WebSocket endpoint until the
+// connection fails
+func (c *Client) streamWebSocket(ctx context.Context, handle func(NodeStatusMessage), connected func()) error {
+	wsURL := c.baseURL + nodeStreamWSPath
+	switch {
+	case strings.HasPrefix(wsURL, "https://"):
+		wsURL = "wss://" + strings.TrimPrefix(wsURL, "https://")
+	case strings.HasPrefix(wsURL, "http://"):
+		wsURL = "ws://" + strings.TrimPrefix(wsURL, "http://")
+	}
+
+	dialer := websocket.Dialer{
+		Proxy:            http.ProxyFromEnvironment,
+		HandshakeTimeout: 15 * time.Second,
+	}
+	if transport, ok := c.httpClient.Transport.(*http.Transport); ok {
+		dialer.Proxy = transport.Proxy
+		dialer.TLSClientConfig = transport.TLSClientConfig
+		dialer.NetDialContext = transport.DialContext
+	}
+
+	conn, resp, err := dialer.DialContext(ctx, wsURL, c.streamHeaders())
+	if err != nil {
+		if resp != nil && streamUnsupported(resp.StatusCode) {
+			return ErrStreamUnsupported
+		}
+		return fmt.Errorf("failed to open node stream: %w", err)
+	}
+	defer conn.Close()
+
+	// Unblock ReadMessage when ctx is cancelled
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	conn.SetPingHandler(func(data string) error {
+		conn.SetReadDeadline(time.Now().Add(streamReadTimeout))
+		return conn.WriteControl(websocket.PongMessage, []byte(data), time.Now().Add(5*time.Second))
+	})
+
+	logger.Info("node stream connected", "transport", "websocket")
+	connected()
+
+	for {
+		conn.SetReadDeadline(time.Now().Add(streamReadTimeout))
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			return fmt.Errorf("node stream closed: %w", err)
+		}
+		c.dispatchNodeStatus(data, handle)
+	}
+}
+
+// streamSSE reads messages from the server-sent events endpoint until the
+// connection fails
+func (c *Client) streamSSE(ctx context.Context, handle func(NodeStatusMessage), connected func()) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+nodeStreamSSEPath, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header = c.streamHeaders()
+	req.Header.Set("Accept", "text/event-stream")
+	req.Header.Set("Cache-Control", "no-cache")
+
+	// The regular client's timeout would cut the stream off
+	client := &http.Client{Transport: c.httpClient.Transport}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to open node stream: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		if streamUnsupported(resp.StatusCode) {
+			return ErrStreamUnsupported
+		}
+		return fmt.Errorf("failed to open node stream: status %d", resp.StatusCode)
+	}
+	if !strings.HasPrefix(resp.Header.Get("Content-Type"), "text/event-stream") {
+		return fmt.Errorf("failed to open node stream: %w", ErrUnexpectedResponse)
+	}
+
+	logger.Info("node stream connected", "transport", "sse")
+	connected()
+
+	// Cancel the request if nothing, not even a comment, arrives in time
+	activity := make(chan struct{}, 1)
+	go func() {
+		timer := time.NewTimer(streamReadTimeout)
+		defer timer.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-activity:
+				timer.Reset(streamReadTimeout)
+			case <-timer.C:
+				cancel()
+				return
+			}
+		}
+	}()
+
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	var data strings.Builder
+	for scanner.Scan() {
+		select {
+		case activity <- struct{}{}:
+		default:
+		}
+
+		line := scanner.Text()
+		switch {
+		case line == "":
+			// A blank line ends the event
+			if data.Len() > 0 {
+				c.dispatchNodeStatus([]byte(data.String()), handle)
+				data.Reset()
+			}
+		case strings.HasPrefix(line, "data:"):
+			if data.Len() > 0 {
+				data.WriteByte('\n')
+			}
+			data.WriteString(strings.TrimPrefix(strings.TrimPrefix(line, "data:"), " "))
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("node stream closed: %w", err)
+	}
+	return fmt.Errorf("node stream closed by server")
+}
+
+// streamUnsupported reports whether a failed stream request's status means
+// the endpoint does not exist, rather than a transient error. A plain 200
+// to a WebSocket upgrade means the server does not speak WebSocket there.
+func streamUnsupported(status int) bool {
+	switch status {
+	case http.StatusOK, http.StatusBadRequest, http.StatusNotFound, http.StatusMethodNotAllowed, http.StatusNotImplemented:
+		return true
+	}
+	return false
+}
+
+// dispatchNodeStatus decodes a message and passes it to handle
+func (c *Client) dispatchNodeStatus(data []byte, handle func(NodeStatusMessage)) {
+	var msg NodeStatusMessage
+	if err := json.Unmarshal(data, &msg); err != nil {
+		logger.Warn("invalid node stream message", "error", err)
+		return
+	}
+	if msg.Type == "" {
+		msg.Type = NodeStatusUpdate
+	}
+	handle(msg)
+}
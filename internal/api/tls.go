@@ -0,0 +1,179 @@
+package api
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// ProductionHost is the production API host, whose keys are always pinned
+const ProductionHost = "api.aureovpn.com"
+
+// defaultProductionPins are the SPKI pins of the production API: the root
+// keys of the public CAs its certificates may be issued by. Any of them is
+// a backup for the others, so the certificate can be replaced, or moved to
+// another of these CAs, without an app update.
+var defaultProductionPins = []string{
+	"C5+lpZ7tcVwmwQIMcRtPbsQtWLABXhQzejna0wHFr8M=", // ISRG Root X1 (Let's Encrypt)
+	"diGVwiVYbubAI3RW4hB9xU8e/CH2GnkuvVFZE8zmgzI=", // ISRG Root X2 (Let's Encrypt)
+	"hxqRlPTu1bMS/0DITB1SSu0vd4u/8l8TjPgfaAp63Gc=", // GTS Root R1
+	"Vfd95BwDeSQo+NUYxVEEIlvkOlWY2SalKK1lPhzOx78=", // GTS Root R2
+	"QXnt2YHvdHR3tJYmQIr0Paosp6t/nggsEGD4QJZ3Q0g=", // GTS Root R3
+	"mEflZT5enoR1FuXLgYYGqnVEoZvmf9c2bVBpiOjYQ0c=", // GTS Root R4
+	"++MBgDH5WGvL9Bcn5Be30cRcL0f5O+NyoXuWtQdX1aI=", // Amazon Root CA 1
+	"f0KW/FtqTjs108NpYj42SrGvOB2PpxIVM8nWxjPqJGE=", // Amazon Root CA 2
+	"NqvDJlas/GRcYbcWE8S/IceH9cq77kg0jVhZeAPXq8k=", // Amazon Root CA 3
+	"9+ze1cZgR9KO1kZrVDxA4HQ6voHRCSVNz4RdTCx4U8U=", // Amazon Root CA 4
+	"KwccWaCgrnaw6tsrrSO61FgLacNgG2MMLq8GE6+oP5I=", // Starfield Services Root CA G2
+	"r/mIkG3eEpVdm+u/ko/cwxzOMo1bk4TyHIlByibiA5E=", // DigiCert Global Root CA
+	"i7WTqTvh0OioIruIfFR4kMPnBqrS2rdiVPl/s2uC/CY=", // DigiCert Global Root G2
+	"uUwZgwDOxcBXrQcntwu+kYFpkiVkOaezL0WYEZ3anJc=", // DigiCert Global Root G3
+	"x4QzPSC810K5/cMjb05Qm4k3Bw5zBn4lTdO/nEW/Td4=", // USERTrust RSA (Sectigo)
+	"ICGRfpgmOUXIWcQ/HXPLQTkFPEFPoDyjvH7ohhQpjzs=", // USERTrust ECC (Sectigo)
+	"cGuxAXyFXFkWm61cF4HPWX8S0srS9j0aSqN0k4AP+4A=", // GlobalSign Root R3
+	"aCdH+LpiG4fN07wpXtXKvOciocDANj0daLOJKNJ4fx4=", // GlobalSign Root R6
+	"rn+WLLnmp9v3uDP7GPqbcaiRdd+UnCMrap73yz3yu/w=", // GlobalSign Root R46
+	"4EoCLOMvTM8sf2BGKHuCijKpCfXnUUR/g/0scfb9gXM=", // GlobalSign Root E46
+}
+
+// productionPins replaces defaultProductionPins when set, as comma-separated
+// base64 SHA-256 hashes, e.g. to pin the API's own current and backup keys:
+// -ldflags "-X github.com/nikola43/aureo-vpn-client/internal/api.productionPins=...".
+var productionPins = ""
+
+// pinPrefix is the optional prefix of a pin, as used by HPKP and curl
+const pinPrefix = "sha256/"
+
+// TLSConfig configures how the client verifies the API server and
+// authenticates to it
+type TLSConfig struct {
+	// CAFile is a PEM bundle trusted instead of the system roots
+	CAFile string
+	// ClientCert and ClientKey are PEM files presented for mutual TLS
+	ClientCert string
+	ClientKey  string
+	// Pins are base64 SHA-256 hashes of a SubjectPublicKeyInfo. When set, a
+	// certificate in the verified chain must match one of them.
+	Pins []string
+}
+
+// SetTLS rebuilds the client's transport with cfg. The production host's
+// pins apply on top of any given pins. If cfg cannot be loaded the client
+// refuses to connect until SetTLS succeeds, rather than falling back to
+// unpinned defaults.
+func (c *Client) SetTLS(cfg TLSConfig) error {
+	tlsConfig, err := buildTLSConfig(c.baseURL, cfg)
+	if err != nil {
+		c.httpClient.Transport = refusingTransport(fmt.Errorf("invalid API TLS settings: %w", err))
+		return err
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+	transport.Proxy = c.proxyFunc()
+	c.httpClient.Transport = transport
+	return nil
+}
+
+// buildTLSConfig loads the CA bundle, client certificate and pins for baseURL
+func buildTLSConfig(baseURL string, cfg TLSConfig) (*tls.Config, error) {
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+
+	if cfg.CAFile != "" {
+		pem, err := os.ReadFile(cfg.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA bundle: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in %s", cfg.CAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if cfg.ClientCert != "" || cfg.ClientKey != "" {
+		if cfg.ClientCert == "" || cfg.ClientKey == "" {
+			return nil, fmt.Errorf("client certificate and key must be set together")
+		}
+		cert, err := tls.LoadX509KeyPair(cfg.ClientCert, cfg.ClientKey)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	pins := cfg.Pins
+	if isProductionURL(baseURL) {
+		pins = append(builtinPins(), pins...)
+	}
+	if len(pins) > 0 {
+		hashes, err := parsePins(pins)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.VerifyConnection = verifyPins(hashes)
+	}
+
+	return tlsConfig, nil
+}
+
+// builtinPins returns the production pins, overridden at build time or the
+// defaults
+func builtinPins() []string {
+	if productionPins != "" {
+		return strings.Split(productionPins, ",")
+	}
+	return defaultProductionPins
+}
+
+// refusingTransport fails every connection with err
+func refusingTransport(err error) *http.Transport {
+	refuse := func(context.Context, string, string) (net.Conn, error) {
+		return nil, err
+	}
+	return &http.Transport{DialContext: refuse, DialTLSContext: refuse}
+}
+
+// parsePins decodes base64 SHA-256 pins, with or without the sha256/ prefix
+func parsePins(pins []string) (map[[sha256.Size]byte]bool, error) {
+	hashes := map[[sha256.Size]byte]bool{}
+	for _, pin := range pins {
+		pin = strings.TrimPrefix(strings.TrimSpace(pin), pinPrefix)
+		raw, err := base64.StdEncoding.DecodeString(pin)
+		if err != nil || len(raw) != sha256.Size {
+			return nil, fmt.Errorf("invalid pin %q: must be a base64 SHA-256 hash", pin)
+		}
+		hashes[[sha256.Size]byte(raw)] = true
+	}
+	return hashes, nil
+}
+
+// verifyPins accepts a connection whose verified chain contains a pinned
+// key. It runs after normal verification, so an intermediate or root can
+// be pinned as well as the server's own key.
+func verifyPins(hashes map[[sha256.Size]byte]bool) func(tls.ConnectionState) error {
+	return func(cs tls.ConnectionState) error {
+		for _, chain := range cs.VerifiedChains {
+			for _, cert := range chain {
+				if hashes[sha256.Sum256(cert.RawSubjectPublicKeyInfo)] {
+					return nil
+				}
+			}
+		}
+		return fmt.Errorf("API server certificate does not match any pinned key")
+	}
+}
+
+// isProductionURL reports whether baseURL points at the production API
+func isProductionURL(baseURL string) bool {
+	u, err := url.Parse(baseURL)
+	return err == nil && strings.EqualFold(u.Hostname(), ProductionHost)
+}
@@ -0,0 +1,177 @@
+// Package config stores the app's settings in a versioned JSON file in the
+// config directory. Files written by older versions are migrated on load.
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+const settingsFile = "settings.json"
+
+// DefaultKeyRotationDays is the default WireGuard key lifetime
+const DefaultKeyRotationDays = 30
+
+// Settings is the persisted app configuration
+type Settings struct {
+	// Version is the schema version the file was written with
+	Version     int         `json:"version"`
+	Preferences Preferences `json:"preferences"`
+	AutoConnect AutoConnect `json:"auto_connect"`
+	Profiles    []Profile   `json:"profiles"`
+	Location    Location    `json:"location"`
+	// APIServers holds TLS settings per API URL
+	APIServers []APIServer `json:"api_servers"`
+	// APIProxy is the proxy API requests go through
+	APIProxy APIProxy `json:"api_proxy"`
+}
+
+// Preferences are the general options from the settings screen
+type Preferences struct {
+	Protocol          string `json:"protocol"`
+	KillSwitch        bool   `json:"kill_switch"`
+	DNSLeakProtection bool   `json:"dns_leak_protection"`
+	Notifications     bool   `json:"notifications"`
+	// MTU overrides the discovered tunnel MTU; 0 discovers it per network
+	// and node
+	MTU int `json:"mtu"`
+	// KeyRotationDays is how old a node's WireGuard key may get before a
+	// new one is registered on connect; 0 never rotates
+	KeyRotationDays int `json:"key_rotation_days"`
+}
+
+// Validate checks the preferences before they are saved
+func (p Preferences) Validate() error {
+	if p.MTU != 0 && (p.MTU < minMTU || p.MTU > maxMTU) {
+		return fmt.Errorf("MTU must be between %d and %d", minMTU, maxMTU)
+	}
+	if p.KeyRotationDays < 0 {
+		return fmt.Errorf("key rotation interval cannot be negative")
+	}
+	return nil
+}
+
+// Location selects how the client's position is estimated for distance
+// based node selection
+type Location struct {
+	// Manual uses Latitude and Longitude instead of a GeoIP lookup
+	Manual    bool    `json:"manual"`
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+	// GeoIPDatabase is the path of a MaxMind City database; empty uses
+	// GeoLite2-City.mmdb in the config directory
+	GeoIPDatabase string `json:"geoip_database"`
+}
+
+// AutoConnect holds the rules for connecting and disconnecting automatically
+type AutoConnect struct {
+	// ConnectOnStartup connects once the user is signed in after the app starts
+	ConnectOnStartup bool `json:"connect_on_startup"`
+	// ConnectOnUntrustedWiFi connects when joining a Wi-Fi network that is not trusted
+	ConnectOnUntrustedWiFi bool `json:"connect_on_untrusted_wifi"`
+	// ConnectOnUntrustedNetworks connects when joining any network that is not trusted
+	ConnectOnUntrustedNetworks bool `json:"connect_on_untrusted_networks"`
+	// DisconnectOnTrusted disconnects when joining a trusted network
+	DisconnectOnTrusted bool `json:"disconnect_on_trusted"`
+	// TrustedNetworks lists network IDs (Wi-Fi SSIDs or connection names)
+	TrustedNetworks []string `json:"trusted_networks"`
+
+	// Profile names the connection profile to use; empty connects to the
+	// best node with the preferred protocol
+	Profile string `json:"profile"`
+}
+
+// Default returns the settings used before anything is saved
+func Default() *Settings {
+	return &Settings{
+		Version: CurrentVersion,
+		Preferences: Preferences{
+			Protocol:          "wireguard",
+			DNSLeakProtection: true,
+			Notifications:     true,
+			KeyRotationDays:   DefaultKeyRotationDays,
+		},
+		AutoConnect: AutoConnect{
+			TrustedNetworks: []string{},
+		},
+		Profiles:   []Profile{},
+		APIServers: []APIServer{},
+		APIProxy: APIProxy{
+			BypassWhenConnected: true,
+		},
+	}
+}
+
+// Load reads the settings from dir, returning defaults if none are saved.
+// Older files are migrated, saved in the current format, and the original
+// is kept as settings.json.v<N>.
+func Load(dir string) (*Settings, error) {
+	path := filepath.Join(dir, settingsFile)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return Default(), nil
+		}
+		return nil, fmt.Errorf("failed to read settings: %w", err)
+	}
+
+	migrated, version, err := migrate(data)
+	if err != nil {
+		return nil, err
+	}
+
+	settings := Default()
+	if err := json.Unmarshal(migrated, settings); err != nil {
+		return nil, fmt.Errorf("failed to parse settings: %w", err)
+	}
+	settings.Version = CurrentVersion
+
+	if version < CurrentVersion {
+		backup := path + ".v" + strconv.Itoa(version)
+		if err := os.WriteFile(backup, data, 0600); err != nil {
+			return nil, fmt.Errorf("failed to back up settings: %w", err)
+		}
+		if err := settings.Save(dir); err != nil {
+			return nil, err
+		}
+	}
+
+	return settings, nil
+}
+
+// Save writes the settings to dir, replacing the file atomically
+func (s *Settings) Save(dir string) error {
+	s.Version = CurrentVersion
+
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal settings: %w", err)
+	}
+
+	path := filepath.Join(dir, settingsFile)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("failed to write settings: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to write settings: %w", err)
+	}
+	return nil
+}
+
+// IsTrusted reports whether the network ID is on the trusted list
+func (r AutoConnect) IsTrusted(id string) bool {
+	if id == "" {
+		return false
+	}
+	for _, trusted := range r.TrustedNetworks {
+		if trusted == id {
+			return true
+		}
+	}
+	return false
+}
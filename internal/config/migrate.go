@@ -0,0 +1,92 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// CurrentVersion is the schema version written by Save
+const CurrentVersion = 2
+
+// migrations[v] upgrades a raw settings document from version v to v+1
+var migrations = map[int]func(doc map[string]interface{}) error{
+	1: migrateV1,
+}
+
+// migrate upgrades raw settings JSON to CurrentVersion and returns it along
+// with the version it was written in. Files without a version field predate
+// versioning and are version 1.
+func migrate(data []byte) ([]byte, int, error) {
+	var doc map[string]interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, 0, fmt.Errorf("failed to parse settings: %w", err)
+	}
+
+	version := 1
+	if v, ok := doc["version"].(float64); ok {
+		version = int(v)
+	}
+
+	if version > CurrentVersion {
+		return nil, 0, fmt.Errorf("settings file version %d is newer than this app supports (%d)", version, CurrentVersion)
+	}
+	if version == CurrentVersion {
+		return data, version, nil
+	}
+
+	for v := version; v < CurrentVersion; v++ {
+		step, ok := migrations[v]
+		if !ok {
+			return nil, 0, fmt.Errorf("no migration from settings version %d", v)
+		}
+		if err := step(doc); err != nil {
+			return nil, 0, fmt.Errorf("failed to migrate settings from version %d: %w", v, err)
+		}
+		doc["version"] = v + 1
+	}
+
+	migrated, err := json.Marshal(doc)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to encode migrated settings: %w", err)
+	}
+	return migrated, version, nil
+}
+
+// migrateV1 moves the auto-connect node and protocol into a profile and
+// adds preferences, which were kept by the frontend in version 1
+func migrateV1(doc map[string]interface{}) error {
+	autoConnect, _ := doc["auto_connect"].(map[string]interface{})
+	if autoConnect == nil {
+		autoConnect = map[string]interface{}{}
+		doc["auto_connect"] = autoConnect
+	}
+
+	protocol, _ := autoConnect["protocol"].(string)
+	if protocol == "" {
+		protocol = "wireguard"
+	}
+
+	profiles := []interface{}{}
+	if nodeID, _ := autoConnect["node_id"].(string); nodeID != "" {
+		profiles = append(profiles, map[string]interface{}{
+			"name":     "Auto-connect",
+			"node_id":  nodeID,
+			"protocol": protocol,
+			"split_tunnel": map[string]interface{}{
+				"mode": SplitTunnelOff,
+			},
+		})
+		autoConnect["profile"] = "Auto-connect"
+	}
+	delete(autoConnect, "node_id")
+	delete(autoConnect, "protocol")
+
+	doc["profiles"] = profiles
+	doc["preferences"] = map[string]interface{}{
+		"protocol":            protocol,
+		"kill_switch":         false,
+		"dns_leak_protection": true,
+		"notifications":       true,
+	}
+	return nil
+}
@@ -0,0 +1,153 @@
+package config
+
+import (
+	"fmt"
+	"net/netip"
+	"strings"
+)
+
+// Split tunnel modes
+const (
+	SplitTunnelOff     = "off"
+	SplitTunnelInclude = "include"
+	SplitTunnelExclude = "exclude"
+)
+
+// MTU bounds accepted for profiles and preferences; 0 uses the tunnel's default
+const (
+	minMTU = 1280
+	maxMTU = 1500
+)
+
+// Profile is a named set of connection options
+type Profile struct {
+	Name string `json:"name"`
+
+	// NodeID selects a specific node. When empty, the least loaded node in
+	// Country is used, or the best node overall if Country is empty too.
+	NodeID  string `json:"node_id,omitempty"`
+	Country string `json:"country,omitempty"`
+
+	Protocol    string      `json:"protocol"`
+	SplitTunnel SplitTunnel `json:"split_tunnel"`
+	// DNS overrides the servers pushed by the node
+	DNS []string `json:"dns,omitempty"`
+	// MTU overrides the tunnel MTU; 0 keeps the default
+	MTU int `json:"mtu,omitempty"`
+	// KillSwitch overrides the kill switch preference; nil keeps it
+	KillSwitch *bool `json:"kill_switch,omitempty"`
+	// Transport carries WireGuard over "udp", "tls" or "websocket"; empty
+	// tries UDP first and falls back to an obfuscated transport
+	Transport string `json:"transport,omitempty"`
+}
+
+// KillSwitchEnabled reports whether the kill switch applies to connections
+// made with the profile
+func (p Profile) KillSwitchEnabled(prefs Preferences) bool {
+	if p.KillSwitch != nil {
+		return *p.KillSwitch
+	}
+	return prefs.KillSwitch
+}
+
+// SplitTunnel selects which traffic uses the tunnel. In include mode only
+// Routes go through the tunnel; in exclude mode everything except Routes does.
+type SplitTunnel struct {
+	Mode   string   `json:"mode"`
+	Routes []string `json:"routes,omitempty"`
+}
+
+// Validate checks a profile before it is saved
+func (p Profile) Validate() error {
+	if strings.TrimSpace(p.Name) == "" {
+		return fmt.Errorf("profile name is required")
+	}
+
+	switch p.Protocol {
+	case "wireguard", "openvpn":
+	default:
+		return fmt.Errorf("unsupported protocol: %s", p.Protocol)
+	}
+
+	switch p.Transport {
+	case "", "udp":
+	case "tls", "websocket":
+		if p.Protocol != "wireguard" {
+			return fmt.Errorf("transport %s requires WireGuard", p.Transport)
+		}
+	default:
+		return fmt.Errorf("unsupported transport: %s", p.Transport)
+	}
+
+	switch p.SplitTunnel.Mode {
+	case "", SplitTunnelOff:
+	case SplitTunnelInclude, SplitTunnelExclude:
+		if len(p.SplitTunnel.Routes) == 0 {
+			return fmt.Errorf("split tunnel %s mode needs at least one route", p.SplitTunnel.Mode)
+		}
+	default:
+		return fmt.Errorf("unknown split tunnel mode: %s", p.SplitTunnel.Mode)
+	}
+
+	for _, route := range p.SplitTunnel.Routes {
+		if !strings.Contains(route, "/") {
+			route += "/32"
+		}
+		prefix, err := netip.ParsePrefix(strings.TrimSpace(route))
+		if err != nil || !prefix.Addr().Is4() {
+			return fmt.Errorf("invalid split tunnel route %q: expected an IPv4 address or CIDR", route)
+		}
+	}
+
+	for _, server := range p.DNS {
+		if _, err := netip.ParseAddr(strings.TrimSpace(server)); err != nil {
+			return fmt.Errorf("invalid DNS server %q", server)
+		}
+	}
+
+	if p.MTU != 0 && (p.MTU < minMTU || p.MTU > maxMTU) {
+		return fmt.Errorf("MTU must be between %d and %d", minMTU, maxMTU)
+	}
+
+	return nil
+}
+
+// Profile returns the profile with the given name
+func (s *Settings) Profile(name string) (Profile, bool) {
+	for _, p := range s.Profiles {
+		if p.Name == name {
+			return p, true
+		}
+	}
+	return Profile{}, false
+}
+
+// PutProfile adds a profile or replaces the one with the same name
+func (s *Settings) PutProfile(profile Profile) error {
+	if err := profile.Validate(); err != nil {
+		return err
+	}
+
+	for i, p := range s.Profiles {
+		if p.Name == profile.Name {
+			s.Profiles[i] = profile
+			return nil
+		}
+	}
+	s.Profiles = append(s.Profiles, profile)
+	return nil
+}
+
+// DeleteProfile removes the named profile
+func (s *Settings) DeleteProfile(name string) error {
+	for i, p := range s.Profiles {
+		if p.Name == name {
+			s.Profiles = append(s.Profiles[:i], s.Profiles[i+1:]...)
+			if s.AutoConnect.Profile == name {
+				s.AutoConnect.Profile = ""
+			}
+			return nil
+		}
+	}
+	return fmt.Errorf("profile not found: %s", name)
+}
@@ -0,0 +1,53 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+)
+
+// API proxy modes
+const (
+	// ProxyModeEnvironment follows HTTP_PROXY, HTTPS_PROXY and NO_PROXY
+	ProxyModeEnvironment = ""
+	ProxyModeNone        = "none"
+	ProxyModeHTTP        = "http"
+	ProxyModeSOCKS5      = "socks5"
+	// ProxyModePAC picks a proxy per request with a proxy auto-config file
+	ProxyModePAC = "pac"
+)
+
+// APIProxy is the proxy API requests go through. The password is kept in
+// the credential store rather than in the settings file.
+type APIProxy struct {
+	Mode string `json:"mode"`
+	// Address is the proxy's host:port for the http and socks5 modes
+	Address string `json:"address,omitempty"`
+	// PACURL is the http, https or file URL of the PAC file
+	PACURL string `json:"pac_url,omitempty"`
+	// Username authenticates to the proxy, including proxies chosen by the
+	// PAC file
+	Username string `json:"username,omitempty"`
+	// BypassWhenConnected sends API requests directly while a full tunnel
+	// is up, since they leave through the VPN anyway
+	BypassWhenConnected bool `json:"bypass_when_connected"`
+}
+
+// Validate checks an API proxy before it is saved
+func (p APIProxy) Validate() error {
+	switch p.Mode {
+	case ProxyModeEnvironment, ProxyModeNone:
+	case ProxyModeHTTP, ProxyModeSOCKS5:
+		if _, port, err := net.SplitHostPort(p.Address); err != nil || port == "" {
+			return fmt.Errorf("invalid proxy address %q: must be host:port", p.Address)
+		}
+	case ProxyModePAC:
+		u, err := url.Parse(p.PACURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https" && u.Scheme != "file") {
+			return fmt.Errorf("invalid PAC URL: %q", p.PACURL)
+		}
+	default:
+		return fmt.Errorf("unknown proxy mode: %q", p.Mode)
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// APIServer holds the connection settings for one API URL
+type APIServer struct {
+	URL string `json:"url"`
+	// CAFile is a PEM bundle trusted instead of the system roots, for
+	// deployments behind a private CA
+	CAFile string `json:"ca_file,omitempty"`
+	// ClientCert and ClientKey are PEM files presented for mutual TLS
+	ClientCert string `json:"client_cert,omitempty"`
+	ClientKey  string `json:"client_key,omitempty"`
+	// Pins are base64 SHA-256 hashes of the server's public key or one of
+	// its issuers'; include a backup pin so the key can be replaced
+	Pins []string `json:"pins,omitempty"`
+}
+
+// Validate checks an API server before it is saved
+func (s APIServer) Validate() error {
+	u, err := url.Parse(s.URL)
+	if err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") {
+		return fmt.Errorf("invalid API URL: %q", s.URL)
+	}
+	if (s.ClientCert == "") != (s.ClientKey == "") {
+		return fmt.Errorf("client certificate and key must be set together")
+	}
+	if u.Scheme == "http" && (s.CAFile != "" || s.ClientCert != "" || len(s.Pins) > 0) {
+		return fmt.Errorf("TLS settings require an https API URL")
+	}
+	return nil
+}
+
+// APIServer returns the settings saved for an API URL
+func (s *Settings) APIServer(apiURL string) (APIServer, bool) {
+	apiURL = NormalizeAPIURL(apiURL)
+	for _, server := range s.APIServers {
+		if server.URL == apiURL {
+			return server, true
+		}
+	}
+	return APIServer{}, false
+}
+
+// PutAPIServer adds an API server or replaces the one with the same URL
+func (s *Settings) PutAPIServer(server APIServer) error {
+	server.URL = NormalizeAPIURL(server.URL)
+	if err := server.Validate(); err != nil {
+		return err
+	}
+
+	for i, existing := range s.APIServers {
+		if existing.URL == server.URL {
+			s.APIServers[i] = server
+			return nil
+		}
+	}
+	s.APIServers = append(s.APIServers, server)
+	return nil
+}
+
+// DeleteAPIServer removes the settings for an API URL
+func (s *Settings) DeleteAPIServer(apiURL string) error {
+	apiURL = NormalizeAPIURL(apiURL)
+	for i, server := range s.APIServers {
+		if server.URL == apiURL {
+			s.APIServers = append(s.APIServers[:i], s.APIServers[i+1:]...)
+			return nil
+		}
+	}
+	return fmt.Errorf("API server not found: %s", apiURL)
+}
+
+// NormalizeAPIURL drops surrounding space and trailing slashes, so a URL
+// matches however it was typed
+func NormalizeAPIURL(apiURL string) string {
+	return strings.TrimRight(strings.TrimSpace(apiURL), "/")
+}
@@ -0,0 +1,117 @@
+// Package geo estimates the client's location and ranks nodes by
+// great-circle distance from it
+package geo
+
+import (
+	"math"
+	"sort"
+
+	"github.com/nikola43/aureo-vpn-client/internal/models"
+)
+
+// earthRadiusKm is the mean radius of the Earth
+const earthRadiusKm = 6371.0088
+
+// distanceScaleKm is the distance at which a node gets the worst distance
+// score; latency differences flatten out beyond a continent
+const distanceScaleKm = 5000
+
+// Weights of load and distance in node scores; they sum to 1
+const (
+	loadWeight     = 0.6
+	distanceWeight = 0.4
+)
+
+// Point is a position in decimal degrees
+type Point struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+}
+
+// Valid reports whether the point is within range and not the 0,0
+// placeholder used for unknown positions
+func (p Point) Valid() bool {
+	if p.Latitude == 0 && p.Longitude == 0 {
+		return false
+	}
+	return p.Latitude >= -90 && p.Latitude <= 90 && p.Longitude >= -180 && p.Longitude <= 180
+}
+
+// NodePoint returns a node's position
+func NodePoint(node models.VPNNode) Point {
+	return Point{Latitude: node.Latitude, Longitude: node.Longitude}
+}
+
+// Distance returns the great-circle distance between two points in
+// kilometres, using the haversine formula
+func Distance(a, b Point) float64 {
+	lat1 := a.Latitude * math.Pi / 180
+	lat2 := b.Latitude * math.Pi / 180
+	dLat := lat2 - lat1
+	dLon := (b.Longitude - a.Longitude) * math.Pi / 180
+
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadiusKm * math.Asin(math.Min(1, math.Sqrt(h)))
+}
+
+// NodeDistance is a node and its distance from the client
+type NodeDistance struct {
+	Node       models.VPNNode `json:"node"`
+	DistanceKm float64        `json:"distance_km"`
+}
+
+// Distances returns every node with a known position, nearest first
+func Distances(from Point, nodes []models.VPNNode) []NodeDistance {
+	result := []NodeDistance{}
+	for _, node := range nodes {
+		p := NodePoint(node)
+		if !p.Valid() {
+			continue
+		}
+		result = append(result, NodeDistance{Node: node, DistanceKm: Distance(from, p)})
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].DistanceKm < result[j].DistanceKm
+	})
+	return result
+}
+
+// Nearest returns up to n nodes nearest to from
+func Nearest(from Point, nodes []models.VPNNode, n int) []NodeDistance {
+	result := Distances(from, nodes)
+	if n > 0 && len(result) > n {
+		result = result[:n]
+	}
+	return result
+}
+
+// Score rates a node for automatic selection; lower is better. Load
+// (0-100) and distance (capped at distanceScaleKm) are both normalized to
+// 0-1 and weighted. Nodes without a known position count as far away.
+func Score(from Point, node models.VPNNode) float64 {
+	load := math.Min(math.Max(node.LoadScore, 0), 100) / 100
+
+	distance := 1.0
+	if p := NodePoint(node); p.Valid() {
+		distance = math.Min(Distance(from, p), distanceScaleKm) / distanceScaleKm
+	}
+
+	return loadWeight*load + distanceWeight*distance
+}
+
+// Best returns the active node with the lowest score
+func Best(from Point, nodes []models.VPNNode) (models.VPNNode, bool) {
+	var best models.VPNNode
+	bestScore := math.Inf(1)
+	found := false
+	for _, node := range nodes {
+		if !node.IsActive {
+			continue
+		}
+		if score := Score(from, node); score < bestScore {
+			best, bestScore, found = node, score, true
+		}
+	}
+	return best, found
+}
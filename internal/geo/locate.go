@@ -0,0 +1,72 @@
+package geo
+
+import (
+	"fmt"
+	"net"
+	"net/netip"
+
+	"github.com/oschwald/maxminddb-golang"
+)
+
+// Location sources
+const (
+	SourceGeoIP  = "geoip"
+	SourceManual = "manual"
+)
+
+// Location is the client's estimated position
+type Location struct {
+	Point
+	Source      string `json:"source"`
+	IP          string `json:"ip,omitempty"`
+	City        string `json:"city,omitempty"`
+	Country     string `json:"country,omitempty"`
+	CountryCode string `json:"country_code,omitempty"`
+	// AccuracyKm is the database's accuracy radius, if known
+	AccuracyKm int `json:"accuracy_km,omitempty"`
+}
+
+// cityRecord is the subset of a GeoIP2/GeoLite2 City record that is read
+type cityRecord struct {
+	City struct {
+		Names map[string]string `maxminddb:"names"`
+	} `maxminddb:"city"`
+	Country struct {
+		ISOCode string            `maxminddb:"iso_code"`
+		Names   map[string]string `maxminddb:"names"`
+	} `maxminddb:"country"`
+	Location struct {
+		Latitude       float64 `maxminddb:"latitude"`
+		Longitude      float64 `maxminddb:"longitude"`
+		AccuracyRadius int     `maxminddb:"accuracy_radius"`
+	} `maxminddb:"location"`
+}
+
+// LookupDB looks ip up in a MaxMind City database (GeoLite2-City.mmdb or
+// a compatible file)
+func LookupDB(path string, ip netip.Addr) (Location, error) {
+	db, err := maxminddb.Open(path)
+	if err != nil {
+		return Location{}, fmt.Errorf("failed to open GeoIP database: %w", err)
+	}
+	defer db.Close()
+
+	var record cityRecord
+	if err := db.Lookup(net.IP(ip.AsSlice()), &record); err != nil {
+		return Location{}, fmt.Errorf("failed to look up %s: %w", ip, err)
+	}
+
+	loc := Location{
+		Point:       Point{Latitude: record.Location.Latitude, Longitude: record.Location.Longitude},
+		Source:      SourceGeoIP,
+		IP:          ip.String(),
+		City:        record.City.Names["en"],
+		Country:     record.Country.Names["en"],
+		CountryCode: record.Country.ISOCode,
+		AccuracyKm:  record.Location.AccuracyRadius,
+	}
+	if !loc.Valid() {
+		return Location{}, fmt.Errorf("no location for %s in GeoIP database", ip)
+	}
+	return loc, nil
+}
@@ -0,0 +1,257 @@
+// Package keys keeps one WireGuard key pair per account and node, so the
+// node sees the same peer on every connect. Private and pre-shared keys
+// live in the system credential store; the public half and its age are
+// kept in a JSON file to decide when a key is due for rotation.
+package keys
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/nikola43/aureo-vpn-client/internal/secrets"
+)
+
+// Key describes a stored key pair
+type Key struct {
+	Account   string    `json:"account"`
+	NodeID    string    `json:"node_id"`
+	PublicKey string    `json:"public_key"`
+	CreatedAt time.Time `json:"created_at"`
+	// Rotate forces a new key on the next connect
+	Rotate bool `json:"rotate,omitempty"`
+}
+
+// Material is the secret part of a key pair
+type Material struct {
+	PrivateKey   secrets.Buffer `json:"private_key"`
+	PresharedKey secrets.Buffer `json:"preshared_key,omitempty"`
+}
+
+// Wipe clears the keys from memory
+func (m *Material) Wipe() {
+	m.PrivateKey.Wipe()
+	m.PresharedKey.Wipe()
+}
+
+// Due reports whether the key should be replaced. A zero maxAge only
+// rotates keys marked for rotation.
+func (k Key) Due(maxAge time.Duration) bool {
+	return k.Rotate || (maxAge > 0 && time.Since(k.CreatedAt) > maxAge)
+}
+
+// Manager stores key pairs
+type Manager struct {
+	path    string
+	secrets *secrets.Store
+
+	mu   sync.Mutex
+	keys map[string]Key
+}
+
+// Open loads the key index at path; private keys are kept in store
+func Open(path string, store *secrets.Store) (*Manager, error) {
+	m := &Manager{path: path, secrets: store, keys: map[string]Key{}}
+
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return m, nil
+		}
+		return nil, fmt.Errorf("failed to read key index: %w", err)
+	}
+	if err := json.Unmarshal(raw, &m.keys); err != nil {
+		return nil, fmt.Errorf("failed to parse key index: %w", err)
+	}
+	return m, nil
+}
+
+// Get returns the key pair for a node. ok is false if none is stored, or
+// if the secret has gone missing from the credential store.
+func (m *Manager) Get(account, nodeID string) (key Key, material Material, ok bool, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	id := keyID(account, nodeID)
+	key, ok = m.keys[id]
+	if !ok {
+		return Key{}, Material{}, false, nil
+	}
+
+	secret, err := m.secrets.Get(id)
+	if errors.Is(err, secrets.ErrNotFound) {
+		return Key{}, Material{}, false, nil
+	}
+	if err != nil {
+		return Key{}, Material{}, false, fmt.Errorf("failed to read private key: %w", err)
+	}
+
+	if err := json.Unmarshal(secret, &material); err != nil {
+		// Keys stored before pre-shared keys were added are the bare private key
+		return key, Material{PrivateKey: secrets.Buffer(secret)}, true, nil
+	}
+	secrets.Buffer(secret).Wipe()
+	return key, material, true, nil
+}
+
+// Lookup returns the index entry for a node's key, whether or not its
+// secret can still be read
+func (m *Manager) Lookup(account, nodeID string) (Key, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	key, ok := m.keys[keyID(account, nodeID)]
+	return key, ok
+}
+
+// Put stores a new key pair for a node, replacing any previous one
+func (m *Manager) Put(account, nodeID, publicKey string, material Material) (Key, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	id := keyID(account, nodeID)
+	if err := m.setMaterial(id, material); err != nil {
+		return Key{}, err
+	}
+
+	key := Key{Account: account, NodeID: nodeID, PublicKey: publicKey, CreatedAt: time.Now()}
+	m.keys[id] = key
+	return key, m.save()
+}
+
+// SetMaterial replaces the secrets of a stored key pair, e.g. when the
+// server settled on a different pre-shared key
+func (m *Manager) SetMaterial(account, nodeID string, material Material) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	id := keyID(account, nodeID)
+	if _, ok := m.keys[id]; !ok {
+		return fmt.Errorf("no key stored for node %s", nodeID)
+	}
+	return m.setMaterial(id, material)
+}
+
+// setMaterial writes the secret part of a key pair; the caller holds m.mu
+func (m *Manager) setMaterial(id string, material Material) error {
+	secret, err := json.Marshal(material)
+	if err != nil {
+		return fmt.Errorf("failed to encode private key: %w", err)
+	}
+	defer secrets.Buffer(secret).Wipe()
+
+	if err := m.secrets.Set(id, secret); err != nil {
+		return fmt.Errorf("failed to store private key: %w", err)
+	}
+	return nil
+}
+
+// Delete forgets a node's key pair
+func (m *Manager) Delete(account, nodeID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	id := keyID(account, nodeID)
+	if err := m.secrets.Delete(id); err != nil {
+		return fmt.Errorf("failed to delete private key: %w", err)
+	}
+	delete(m.keys, id)
+	return m.save()
+}
+
+// MoveAccount reassigns the keys of one account to another, e.g. when
+// accounts gain a new ID
+func (m *Manager) MoveAccount(from, to string) error {
+	if from == to {
+		return nil
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for id, key := range m.keys {
+		if key.Account != from {
+			continue
+		}
+		secret, err := m.secrets.Get(id)
+		if errors.Is(err, secrets.ErrNotFound) {
+			delete(m.keys, id)
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read private key: %w", err)
+		}
+		newID := keyID(to, key.NodeID)
+		err = m.secrets.Set(newID, secret)
+		secrets.Buffer(secret).Wipe()
+		if err != nil {
+			return fmt.Errorf("failed to store private key: %w", err)
+		}
+		if err := m.secrets.Delete(id); err != nil {
+			return fmt.Errorf("failed to delete private key: %w", err)
+		}
+
+		delete(m.keys, id)
+		key.Account = to
+		m.keys[newID] = key
+	}
+	return m.save()
+}
+
+// List returns an account's keys, oldest first
+func (m *Manager) List(account string) []Key {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	list := []Key{}
+	for _, key := range m.keys {
+		if key.Account == account {
+			list = append(list, key)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].CreatedAt.Before(list[j].CreatedAt) })
+	return list
+}
+
+// RotateAll marks every key of an account for rotation on its next connect
+func (m *Manager) RotateAll(account string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for id, key := range m.keys {
+		if key.Account == account {
+			key.Rotate = true
+			m.keys[id] = key
+		}
+	}
+	return m.save()
+}
+
+// save writes the key index atomically; the caller holds m.mu
+func (m *Manager) save() error {
+	raw, err := json.MarshalIndent(m.keys, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal key index: %w", err)
+	}
+
+	tmp := m.path + ".tmp"
+	if err := os.WriteFile(tmp, raw, 0600); err != nil {
+		return fmt.Errorf("failed to write key index: %w", err)
+	}
+	if err := os.Rename(tmp, m.path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to write key index: %w", err)
+	}
+	return nil
+}
+
+// keyID names a key pair in the index and the credential store. Account
+// and node IDs come from the API, so they are hashed into a safe name.
+func keyID(account, nodeID string) string {
+	sum := sha256.Sum256([]byte(account + "|" + nodeID))
+	return "wg-" + hex.EncodeToString(sum[:16])
+}
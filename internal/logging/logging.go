@@ -0,0 +1,197 @@
+// Package logging provides the app's structured logger. Records are written
+// as JSON to a size-rotated file, secrets are redacted before they reach the
+// file, and each subsystem has its own level.
+package logging
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Subsystems with independent log levels
+const (
+	SubsystemApp = "app"
+	SubsystemAPI = "api"
+	SubsystemVPN = "vpn"
+)
+
+const (
+	logFileName = "aureo-vpn.log"
+
+	// maxLogSize is the size at which the log file is rotated
+	maxLogSize = 5 * 1024 * 1024
+	// maxLogBackups is how many rotated files are kept
+	maxLogBackups = 3
+)
+
+var (
+	mu     sync.Mutex
+	out    = &switchWriter{w: os.Stderr}
+	file   *rotatingFile
+	levels = make(map[string]*slog.LevelVar)
+
+	base = slog.NewJSONHandler(out, &slog.HandlerOptions{
+		Level:       slog.LevelDebug,
+		ReplaceAttr: replaceAttr,
+	})
+)
+
+// Init starts writing logs to a rotating file in dir. Loggers obtained with
+// For before Init write to stderr until then.
+func Init(dir string) error {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return fmt.Errorf("failed to create log directory: %w", err)
+	}
+
+	f, err := openRotatingFile(filepath.Join(dir, logFileName), maxLogSize, maxLogBackups)
+	if err != nil {
+		return err
+	}
+
+	mu.Lock()
+	old := file
+	file = f
+	mu.Unlock()
+
+	out.set(f)
+	if old != nil {
+		old.Close()
+	}
+	return nil
+}
+
+// Close flushes and closes the log file; later records go to stderr
+func Close() error {
+	mu.Lock()
+	f := file
+	file = nil
+	mu.Unlock()
+
+	out.set(os.Stderr)
+	if f != nil {
+		return f.Close()
+	}
+	return nil
+}
+
+// Files returns the current log file followed by its rotated backups
+func Files() []string {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if file == nil {
+		return nil
+	}
+	return file.files()
+}
+
+// For returns the logger for a subsystem
+func For(subsystem string) *slog.Logger {
+	handler := &levelHandler{
+		inner: base.WithAttrs([]slog.Attr{slog.String("subsystem", subsystem)}),
+		level: levelFor(subsystem),
+	}
+	return slog.New(handler)
+}
+
+// SetLevel changes a subsystem's level (debug, info, warn or error)
+func SetLevel(subsystem, level string) error {
+	var l slog.Level
+	if err := l.UnmarshalText([]byte(level)); err != nil {
+		return fmt.Errorf("invalid log level %q", level)
+	}
+	levelFor(subsystem).Set(l)
+	return nil
+}
+
+// Levels returns the level of every subsystem that has a logger
+func Levels() map[string]string {
+	mu.Lock()
+	defer mu.Unlock()
+
+	result := make(map[string]string, len(levels))
+	for subsystem, level := range levels {
+		result[subsystem] = strings.ToLower(level.Level().String())
+	}
+	return result
+}
+
+// ApplyLevels parses a spec such as "debug" or "vpn=debug,api=warn". A bare
+// level applies to every subsystem.
+func ApplyLevels(spec string) error {
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		subsystem, level, ok := strings.Cut(part, "=")
+		if !ok {
+			for _, s := range subsystems() {
+				if err := SetLevel(s, part); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+
+		if err := SetLevel(strings.TrimSpace(subsystem), strings.TrimSpace(level)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// subsystems returns the known subsystems, including the built-in ones
+func subsystems() []string {
+	for _, s := range []string{SubsystemApp, SubsystemAPI, SubsystemVPN} {
+		levelFor(s)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	names := make([]string, 0, len(levels))
+	for name := range levels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// levelFor returns the shared level variable for a subsystem, defaulting to info
+func levelFor(subsystem string) *slog.LevelVar {
+	mu.Lock()
+	defer mu.Unlock()
+
+	level, ok := levels[subsystem]
+	if !ok {
+		level = new(slog.LevelVar)
+		levels[subsystem] = level
+	}
+	return level
+}
+
+// switchWriter lets Init redirect the shared handler to a new destination
+type switchWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (s *switchWriter) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.w.Write(p)
+}
+
+func (s *switchWriter) set(w io.Writer) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.w = w
+}
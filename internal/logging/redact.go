@@ -0,0 +1,95 @@
+package logging
+
+import (
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strings"
+)
+
+const redacted = "[REDACTED]"
+
+// sensitiveKeys are attribute key fragments whose values are always redacted
+var sensitiveKeys = []string{
+	"private_key",
+	"privatekey",
+	"preshared",
+	"psk",
+	"password",
+	"secret",
+	"token",
+	"authorization",
+}
+
+// sensitiveValues match secrets embedded in free-form text such as error
+// messages, config files and wg output
+var sensitiveValues = []*regexp.Regexp{
+	// "PrivateKey = ...", "private key: ...", "password=..."
+	regexp.MustCompile(`(?i)((?:private|preshared)[ _]?key|password|secret|(?:access|refresh)?[ _]?token)("?\s*[=:]\s*"?)[^\s",}]+`),
+	// Authorization headers
+	regexp.MustCompile(`(?i)(bearer|basic)(\s+)[A-Za-z0-9._~+/=-]+`),
+	// JWTs
+	regexp.MustCompile(`()()eyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+`),
+}
+
+// Redact masks keys, passwords and tokens in free-form text
+func Redact(s string) string {
+	for _, re := range sensitiveValues {
+		s = re.ReplaceAllString(s, "${1}${2}"+redacted)
+	}
+	return s
+}
+
+// replaceAttr redacts sensitive attributes before they are written
+func replaceAttr(groups []string, a slog.Attr) slog.Attr {
+	if isSensitiveKey(a.Key) {
+		return slog.String(a.Key, redacted)
+	}
+
+	switch a.Value.Kind() {
+	case slog.KindString:
+		return slog.String(a.Key, Redact(a.Value.String()))
+	case slog.KindGroup:
+		members := a.Value.Group()
+		groups = append(groups[:len(groups):len(groups)], a.Key)
+		redactedMembers := make([]slog.Attr, len(members))
+		for i, member := range members {
+			redactedMembers[i] = replaceAttr(groups, member)
+		}
+		return slog.Attr{Key: a.Key, Value: slog.GroupValue(redactedMembers...)}
+	case slog.KindAny:
+		return slog.Attr{Key: a.Key, Value: redactAny(a.Value)}
+	}
+	return a
+}
+
+// redactAny redacts values of any other type. Errors, stringers and byte
+// slices are logged as redacted text; other values, such as structs and
+// maps, are replaced by their redacted text only if it contains a secret.
+func redactAny(v slog.Value) slog.Value {
+	switch x := v.Any().(type) {
+	case error:
+		return slog.StringValue(Redact(x.Error()))
+	case fmt.Stringer:
+		return slog.StringValue(Redact(x.String()))
+	case []byte:
+		return slog.StringValue(Redact(string(x)))
+	}
+
+	text := fmt.Sprintf("%+v", v.Any())
+	if masked := Redact(text); masked != text {
+		return slog.StringValue(masked)
+	}
+	return v
+}
+
+// isSensitiveKey reports whether an attribute key names a secret
+func isSensitiveKey(key string) bool {
+	key = strings.ToLower(key)
+	for _, fragment := range sensitiveKeys {
+		if strings.Contains(key, fragment) {
+			return true
+		}
+	}
+	return false
+}
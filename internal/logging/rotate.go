@@ -0,0 +1,128 @@
+package logging
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+)
+
+// rotatingFile is an append-only file that is renamed to path.1 (shifting
+// older backups up) once it reaches maxSize
+type rotatingFile struct {
+	path       string
+	maxSize    int64
+	maxBackups int
+
+	mu     sync.Mutex
+	file   *os.File
+	size   int64
+	closed bool
+}
+
+// openRotatingFile opens path for appending, continuing an existing file
+func openRotatingFile(path string, maxSize int64, maxBackups int) (*rotatingFile, error) {
+	r := &rotatingFile{path: path, maxSize: maxSize, maxBackups: maxBackups}
+	if err := r.open(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *rotatingFile) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.closed {
+		return 0, os.ErrClosed
+	}
+	// A failed rotation may have left the file closed
+	if r.file == nil {
+		if err := r.open(); err != nil {
+			return 0, err
+		}
+	}
+
+	// If rotating fails, the entry goes to the current file and rotation is
+	// retried on the next write
+	if r.size > 0 && r.size+int64(len(p)) > r.maxSize {
+		if err := r.rotate(); err != nil && r.file == nil {
+			return 0, err
+		}
+	}
+
+	n, err := r.file.Write(p)
+	r.size += int64(n)
+	return n, err
+}
+
+// Close closes the current file
+func (r *rotatingFile) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.closed = true
+	if r.file == nil {
+		return nil
+	}
+	err := r.file.Close()
+	r.file = nil
+	return err
+}
+
+// files returns the paths of the current file and existing backups
+func (r *rotatingFile) files() []string {
+	paths := []string{r.path}
+	for i := 1; i <= r.maxBackups; i++ {
+		backup := r.backupPath(i)
+		if _, err := os.Stat(backup); err == nil {
+			paths = append(paths, backup)
+		}
+	}
+	return paths
+}
+
+// open opens the log file and records its current size
+func (r *rotatingFile) open() error {
+	f, err := os.OpenFile(r.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open log file: %w", err)
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return fmt.Errorf("failed to stat log file: %w", err)
+	}
+
+	r.file = f
+	r.size = info.Size()
+	return nil
+}
+
+// rotate shifts the backups, moves the current file to path.1 and reopens.
+// If the file cannot be moved, it is reopened in place.
+func (r *rotatingFile) rotate() error {
+	if err := r.file.Close(); err != nil {
+		return fmt.Errorf("failed to close log file: %w", err)
+	}
+	r.file = nil
+
+	os.Remove(r.backupPath(r.maxBackups))
+	for i := r.maxBackups - 1; i >= 1; i-- {
+		os.Rename(r.backupPath(i), r.backupPath(i+1))
+	}
+	if err := os.Rename(r.path, r.backupPath(1)); err != nil {
+		if openErr := r.open(); openErr != nil {
+			return openErr
+		}
+		return fmt.Errorf("failed to rotate log file: %w", err)
+	}
+
+	return r.open()
+}
+
+// backupPath returns the path of the n-th rotated file
+func (r *rotatingFile) backupPath(n int) string {
+	return r.path + "." + strconv.Itoa(n)
+}
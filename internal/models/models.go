@@ -1,6 +1,10 @@
 package models
 
-import "time"
+import (
+	"time"
+
+	"github.com/nikola43/aureo-vpn-client/internal/secrets"
+)
 
 // ============================================
 // AUTH MODELS
@@ -196,6 +200,23 @@ type WireGuardConfigResponse struct {
 	DNS             string `json:"dns"`
 	AllowedIPs      string `json:"allowed_ips"`
 	Keepalive       int    `json:"keepalive"`
+	// AlternatePorts are other UDP ports the server accepts WireGuard on
+	AlternatePorts []int `json:"alternate_ports,omitempty"`
+	// Relays are the node's obfuscation relays for networks that block UDP
+	Relays []ObfuscationRelay `json:"relays,omitempty"`
+	// PresharedKey is the peer's pre-shared key; empty if the server does
+	// not use one
+	PresharedKey secrets.Buffer `json:"preshared_key,omitempty"`
+}
+
+// ObfuscationRelay is a node-side endpoint that unwraps WireGuard packets
+// carried over TLS or WebSocket
+type ObfuscationRelay struct {
+	// Transport is "tls" or "websocket"
+	Transport string `json:"transport"`
+	// Address is host:port for TLS, or a wss:// URL for WebSocket
+	Address    string `json:"address"`
+	ServerName string `json:"server_name,omitempty"`
 }
 
 // ============================================
@@ -0,0 +1,183 @@
+// Package netcheck probes the network the host is on: captive portals,
+// egress addresses and path MTU
+package netcheck
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// captiveProbeURL returns 204 with an empty body when the Internet is
+	// reachable directly
+	captiveProbeURL = "http://connectivitycheck.gstatic.com/generate_204"
+	// captiveFallbackURL returns a page containing "Success"
+	captiveFallbackURL = "http://captive.apple.com/hotspot-detect.html"
+
+	captiveProbeTimeout = 5 * time.Second
+)
+
+// CaptivePortal is the result of a captive portal probe
+type CaptivePortal struct {
+	Detected bool `json:"detected"`
+	// URL is the portal's sign-in page when the probe was redirected
+	URL       string    `json:"url,omitempty"`
+	Reason    string    `json:"reason,omitempty"`
+	CheckedAt time.Time `json:"checked_at"`
+}
+
+// DetectCaptivePortal probes well-known connectivity endpoints over plain
+// HTTP. A redirect or an unexpected body means a portal intercepted the
+// request. An error means neither endpoint could be reached.
+func DetectCaptivePortal(ctx context.Context) (CaptivePortal, error) {
+	client := &http.Client{
+		Timeout: captiveProbeTimeout,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+
+	result, err := probeGenerate204(ctx, client)
+	if err != nil {
+		result, err = probeHotspotDetect(ctx, client)
+	}
+	result.CheckedAt = time.Now()
+	return result, err
+}
+
+// probeGenerate204 expects an empty 204 response
+func probeGenerate204(ctx context.Context, client *http.Client) (CaptivePortal, error) {
+	resp, body, err := probe(ctx, client, captiveProbeURL)
+	if err != nil {
+		return CaptivePortal{}, err
+	}
+
+	switch {
+	case isRedirect(resp.StatusCode):
+		return CaptivePortal{Detected: true, URL: resp.Header.Get("Location"), Reason: "connectivity check was redirected"}, nil
+	case resp.StatusCode == http.StatusNoContent && len(body) == 0:
+		return CaptivePortal{}, nil
+	default:
+		return CaptivePortal{Detected: true, Reason: fmt.Sprintf("connectivity check returned %d with %d bytes", resp.StatusCode, len(body))}, nil
+	}
+}
+
+// probeHotspotDetect expects a page containing "Success"
+func probeHotspotDetect(ctx context.Context, client *http.Client) (CaptivePortal, error) {
+	resp, body, err := probe(ctx, client, captiveFallbackURL)
+	if err != nil {
+		return CaptivePortal{}, err
+	}
+
+	switch {
+	case isRedirect(resp.StatusCode):
+		return CaptivePortal{Detected: true, URL: resp.Header.Get("Location"), Reason: "connectivity check was redirected"}, nil
+	case resp.StatusCode == http.StatusOK && strings.Contains(string(body), "Success"):
+		return CaptivePortal{}, nil
+	default:
+		return CaptivePortal{Detected: true, Reason: fmt.Sprintf("connectivity check returned %d with unexpected content", resp.StatusCode)}, nil
+	}
+}
+
+// probe fetches url without following redirects
+func probe(ctx context.Context, client *http.Client, url string) (*http.Response, []byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	req.Header.Set("Cache-Control", "no-cache")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, nil, fmt.Errorf("connectivity check failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	if err != nil {
+		return nil, nil, fmt.Errorf("connectivity check failed: %w", err)
+	}
+	return resp, body, nil
+}
+
+// isRedirect reports whether status is an HTTP redirect
+func isRedirect(status int) bool {
+	return status >= 300 && status < 400
+}
+
+// CaptiveMonitor remembers the last probe result and keeps re-probing while
+// a portal is present
+type CaptiveMonitor struct {
+	// OnChange is called when a portal appears or disappears
+	OnChange func(CaptivePortal)
+
+	mu       sync.Mutex
+	last     CaptivePortal
+	watching bool
+}
+
+// Check probes now and records the result. Probe errors leave the previous
+// state unchanged.
+func (m *CaptiveMonitor) Check(ctx context.Context) (CaptivePortal, error) {
+	result, err := DetectCaptivePortal(ctx)
+	if err != nil {
+		return m.Status(), err
+	}
+
+	m.mu.Lock()
+	changed := result.Detected != m.last.Detected
+	m.last = result
+	m.mu.Unlock()
+
+	if changed && m.OnChange != nil {
+		m.OnChange(result)
+	}
+	return result, nil
+}
+
+// Status returns the last recorded result
+func (m *CaptiveMonitor) Status() CaptivePortal {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.last
+}
+
+// WatchUntilClear re-probes every interval while a portal is present, so
+// OnChange fires once the user has signed in. Only one watch runs at a time.
+func (m *CaptiveMonitor) WatchUntilClear(ctx context.Context, interval time.Duration) {
+	m.mu.Lock()
+	if m.watching || !m.last.Detected {
+		m.mu.Unlock()
+		return
+	}
+	m.watching = true
+	m.mu.Unlock()
+
+	go func() {
+		defer func() {
+			m.mu.Lock()
+			m.watching = false
+			m.mu.Unlock()
+		}()
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			if result, err := m.Check(ctx); err == nil && !result.Detected {
+				return
+			}
+		}
+	}()
+}
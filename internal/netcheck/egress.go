@@ -0,0 +1,151 @@
+package netcheck
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+	"time"
+)
+
+const egressProbeTimeout = 5 * time.Second
+
+// Echo services returning the caller's address as plain text
+var (
+	egressIPv4URLs = []string{
+		"https://api.ipify.org",
+		"https://ipv4.icanhazip.com",
+	}
+	egressIPv6URLs = []string{
+		"https://api6.ipify.org",
+		"https://ipv6.icanhazip.com",
+	}
+)
+
+// DialFunc dials a network connection
+type DialFunc func(ctx context.Context, network, address string) (net.Conn, error)
+
+// Egress is the result of checking which addresses traffic leaves with
+type Egress struct {
+	// IPv4 is the observed public IPv4 address
+	IPv4 string `json:"ipv4,omitempty"`
+	// IPv6 is the observed public IPv6 address; empty when the host has no
+	// IPv6 connectivity
+	IPv6 string `json:"ipv6,omitempty"`
+	// Expected lists the addresses traffic should leave from
+	Expected []string `json:"expected"`
+	// Verified is set when the IPv4 address is one of Expected
+	Verified bool `json:"verified"`
+	// Leak is set when traffic was seen leaving from an unexpected address
+	Leak bool `json:"leak"`
+	// IPv6Leak is set when IPv6 traffic bypasses an IPv4-only tunnel
+	IPv6Leak bool `json:"ipv6_leak"`
+	// Country is the GeoIP country of the IPv4 address, when a database is
+	// available; CountryMismatch is set if it differs from the node's
+	Country         string    `json:"country,omitempty"`
+	CountryMismatch bool      `json:"country_mismatch,omitempty"`
+	Reason          string    `json:"reason,omitempty"`
+	CheckedAt       time.Time `json:"checked_at"`
+}
+
+// PublicIP returns the IPv4 address the host's traffic leaves the network
+// with, as seen by a public echo service
+func PublicIP(ctx context.Context) (netip.Addr, error) {
+	return publicAddr(ctx, "tcp4", nil, egressIPv4URLs)
+}
+
+// PublicIPv6 returns the host's public IPv6 address, or an error if it has
+// no IPv6 connectivity
+func PublicIPv6(ctx context.Context) (netip.Addr, error) {
+	return publicAddr(ctx, "tcp6", nil, egressIPv6URLs)
+}
+
+// CheckEgress compares the observed public addresses with the expected
+// ones. With dial set, the IPv4 address is looked up through it (for
+// tunnels without a system interface) and IPv6 is not checked, since system
+// traffic is not meant to use the tunnel. Otherwise any IPv6 connectivity
+// counts as a leak, as the tunnel only carries IPv4.
+func CheckEgress(ctx context.Context, expected []string, dial DialFunc) Egress {
+	result := Egress{Expected: expected, CheckedAt: time.Now()}
+
+	ipv4, err := publicAddr(ctx, "tcp4", dial, egressIPv4URLs)
+	if err != nil {
+		result.Reason = err.Error()
+	} else {
+		result.IPv4 = ipv4.String()
+		result.Verified = containsAddr(expected, ipv4)
+		if !result.Verified {
+			result.Leak = true
+			result.Reason = fmt.Sprintf("traffic leaves from %s, not the VPN node", ipv4)
+		}
+	}
+
+	if dial == nil {
+		if ipv6, err := PublicIPv6(ctx); err == nil && !containsAddr(expected, ipv6) {
+			result.IPv6 = ipv6.String()
+			result.IPv6Leak = true
+			result.Leak = true
+			if result.Reason == "" {
+				result.Reason = fmt.Sprintf("IPv6 traffic bypasses the VPN from %s", ipv6)
+			}
+		}
+	}
+
+	return result
+}
+
+// publicAddr asks each echo service in turn for the caller's address,
+// connecting over network ("tcp4" or "tcp6") or through dial
+func publicAddr(ctx context.Context, network string, dial DialFunc, urls []string) (netip.Addr, error) {
+	transport := &http.Transport{
+		DialContext: func(ctx context.Context, _, addr string) (net.Conn, error) {
+			if dial != nil {
+				return dial(ctx, network, addr)
+			}
+			var d net.Dialer
+			return d.DialContext(ctx, network, addr)
+		},
+		TLSHandshakeTimeout: egressProbeTimeout,
+	}
+	defer transport.CloseIdleConnections()
+	client := &http.Client{Timeout: egressProbeTimeout, Transport: transport}
+
+	var lastErr error
+	for _, url := range urls {
+		addr, err := fetchAddr(ctx, client, url)
+		if err == nil {
+			return addr, nil
+		}
+		lastErr = err
+	}
+	return netip.Addr{}, fmt.Errorf("failed to determine public IP: %w", lastErr)
+}
+
+// fetchAddr fetches url and parses the body as an IP address
+func fetchAddr(ctx context.Context, client *http.Client, url string) (netip.Addr, error) {
+	resp, body, err := probe(ctx, client, url)
+	if err != nil {
+		return netip.Addr{}, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return netip.Addr{}, fmt.Errorf("%s returned %d", url, resp.StatusCode)
+	}
+
+	addr, err := netip.ParseAddr(strings.TrimSpace(string(body)))
+	if err != nil {
+		return netip.Addr{}, fmt.Errorf("%s returned an invalid address", url)
+	}
+	return addr.Unmap(), nil
+}
+
+// containsAddr reports whether addr is in list
+func containsAddr(list []string, addr netip.Addr) bool {
+	for _, s := range list {
+		if a, err := netip.ParseAddr(s); err == nil && a.Unmap() == addr {
+			return true
+		}
+	}
+	return false
+}
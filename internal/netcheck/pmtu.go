@@ -0,0 +1,165 @@
+package netcheck
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"time"
+)
+
+const (
+	// Path MTU search bounds. IPv6 requires at least 1280 on every link.
+	minPathMTU = 1280
+	maxPathMTU = 1500
+
+	// ICMP echo and UDP header sizes on top of the IP header
+	ipv4HeaderSize = 20
+	ipv6HeaderSize = 40
+	icmpHeaderSize = 8
+	udpHeaderSize  = 8
+
+	pingTimeout = 2 * time.Second
+	// udpProbeWait gives routers time to answer an oversized probe with an
+	// ICMP "fragmentation needed" before the size is retried
+	udpProbeWait = 200 * time.Millisecond
+)
+
+// Path MTU probe methods
+const (
+	MTUMethodICMP = "icmp"
+	MTUMethodUDP  = "udp"
+)
+
+// PathMTU is the result of probing the path to an endpoint
+type PathMTU struct {
+	// MTU is the largest IP packet that reaches the endpoint unfragmented
+	MTU    int    `json:"mtu"`
+	Method string `json:"method"`
+	// IPv6 is set when the endpoint is reached over IPv6
+	IPv6 bool `json:"ipv6"`
+}
+
+// ProbePathMTU finds the path MTU to host with a binary search of
+// don't-fragment probes. ICMP echo (through the system ping) is tried first,
+// since replies confirm delivery. If the host does not answer pings, DF
+// UDP datagrams are sent to port and sizes the kernel rejects, locally or
+// after an ICMP "fragmentation needed" from a router, are too big.
+func ProbePathMTU(ctx context.Context, host string, port int) (PathMTU, error) {
+	ip, err := resolveHost(ctx, host)
+	if err != nil {
+		return PathMTU{}, err
+	}
+	ipv6 := ip.To4() == nil
+
+	if pingDF(ctx, ip, minPathMTU) {
+		mtu := searchMTU(ctx, func(size int) bool { return pingDF(ctx, ip, size) })
+		if ctx.Err() != nil {
+			return PathMTU{}, ctx.Err()
+		}
+		return PathMTU{MTU: mtu, Method: MTUMethodICMP, IPv6: ipv6}, nil
+	}
+	if ctx.Err() != nil {
+		return PathMTU{}, ctx.Err()
+	}
+
+	conn, err := dialDF(ctx, ip, port)
+	if err != nil {
+		return PathMTU{}, fmt.Errorf("failed to probe path MTU: %w", err)
+	}
+	defer conn.Close()
+
+	if !sendDF(ctx, conn, ipv6, minPathMTU) {
+		return PathMTU{}, fmt.Errorf("failed to probe path MTU: %s is unreachable", ip)
+	}
+	mtu := searchMTU(ctx, func(size int) bool { return sendDF(ctx, conn, ipv6, size) })
+	if ctx.Err() != nil {
+		return PathMTU{}, ctx.Err()
+	}
+	return PathMTU{MTU: mtu, Method: MTUMethodUDP, IPv6: ipv6}, nil
+}
+
+// searchMTU returns the largest size in [minPathMTU, maxPathMTU] that fits,
+// assuming minPathMTU does
+func searchMTU(ctx context.Context, fits func(size int) bool) int {
+	if fits(maxPathMTU) {
+		return maxPathMTU
+	}
+
+	lo, hi := minPathMTU, maxPathMTU-1
+	for lo < hi && ctx.Err() == nil {
+		mid := (lo + hi + 1) / 2
+		if fits(mid) {
+			lo = mid
+		} else {
+			hi = mid - 1
+		}
+	}
+	return lo
+}
+
+// pingDF sends one ICMP echo of the given packet size with fragmentation
+// disabled and reports whether it was answered
+func pingDF(ctx context.Context, ip net.IP, size int) bool {
+	header := ipv4HeaderSize + icmpHeaderSize
+	if ip.To4() == nil {
+		header = ipv6HeaderSize + icmpHeaderSize
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, pingTimeout)
+	defer cancel()
+
+	output, err := pingCommand(ctx, ip, size-header).CombinedOutput()
+	return err == nil && pingReplied(string(output))
+}
+
+// dialDF opens a connected UDP socket with the don't-fragment flag set
+func dialDF(ctx context.Context, ip net.IP, port int) (net.Conn, error) {
+	network := "udp4"
+	if ip.To4() == nil {
+		network = "udp6"
+	}
+
+	dialer := net.Dialer{Control: setDontFragment}
+	return dialer.DialContext(ctx, network, net.JoinHostPort(ip.String(), fmt.Sprint(port)))
+}
+
+// sendDF reports whether a UDP datagram making an IP packet of the given
+// size can be sent without fragmentation. Each size is sent twice, so an
+// ICMP error caused by the first one is seen by the second.
+func sendDF(ctx context.Context, conn net.Conn, ipv6 bool, size int) bool {
+	header := ipv4HeaderSize + udpHeaderSize
+	if ipv6 {
+		header = ipv6HeaderSize + udpHeaderSize
+	}
+	payload := make([]byte, size-header)
+
+	for i := 0; i < 2; i++ {
+		if _, err := conn.Write(payload); err != nil && isMessageTooBig(err) {
+			return false
+		}
+		select {
+		case <-ctx.Done():
+			return false
+		case <-time.After(udpProbeWait):
+		}
+	}
+	return true
+}
+
+// resolveHost returns host's address, preferring IPv4
+func resolveHost(ctx context.Context, host string) (net.IP, error) {
+	if ip := net.ParseIP(host); ip != nil {
+		return ip, nil
+	}
+
+	ips, err := net.DefaultResolver.LookupIP(ctx, "ip", host)
+	if err != nil || len(ips) == 0 {
+		return nil, fmt.Errorf("failed to resolve %s: %w", host, err)
+	}
+	for _, ip := range ips {
+		if ip.To4() != nil {
+			return ip, nil
+		}
+	}
+	return ips[0], nil
+}
@@ -0,0 +1,49 @@
+package netcheck
+
+import (
+	"context"
+	"errors"
+	"net"
+	"os/exec"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// pingCommand pings ip once with payload bytes and fragmentation prohibited
+func pingCommand(ctx context.Context, ip net.IP, payload int) *exec.Cmd {
+	if ip.To4() == nil {
+		// -m stops ping6 from fragmenting to the minimum IPv6 MTU
+		return exec.CommandContext(ctx, "ping6", "-c", "1", "-m", "-s", strconv.Itoa(payload), ip.String())
+	}
+	return exec.CommandContext(ctx, "ping", "-c", "1", "-W", "1000", "-D", "-s", strconv.Itoa(payload), ip.String())
+}
+
+// pingReplied reports whether ping's output shows a reply
+func pingReplied(output string) bool {
+	return strings.Contains(output, "1 packets received")
+}
+
+// setDontFragment sets IP_DONTFRAG (or IPV6_DONTFRAG) on the socket
+func setDontFragment(network, address string, c syscall.RawConn) error {
+	var sockErr error
+	err := c.Control(func(fd uintptr) {
+		if network == "udp6" {
+			sockErr = unix.SetsockoptInt(int(fd), unix.IPPROTO_IPV6, unix.IPV6_DONTFRAG, 1)
+		} else {
+			sockErr = unix.SetsockoptInt(int(fd), unix.IPPROTO_IP, unix.IP_DONTFRAG, 1)
+		}
+	})
+	if err != nil {
+		return err
+	}
+	return sockErr
+}
+
+// isMessageTooBig reports whether a send failed because the datagram
+// exceeds the path MTU
+func isMessageTooBig(err error) bool {
+	return errors.Is(err, unix.EMSGSIZE)
+}
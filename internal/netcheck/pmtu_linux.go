@@ -0,0 +1,50 @@
+package netcheck
+
+import (
+	"context"
+	"errors"
+	"net"
+	"os/exec"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// pingCommand pings ip once with payload bytes and fragmentation prohibited
+func pingCommand(ctx context.Context, ip net.IP, payload int) *exec.Cmd {
+	family := "-4"
+	if ip.To4() == nil {
+		family = "-6"
+	}
+	return exec.CommandContext(ctx, "ping", family, "-c", "1", "-W", "1", "-M", "do", "-s", strconv.Itoa(payload), ip.String())
+}
+
+// pingReplied reports whether ping's output shows a reply
+func pingReplied(output string) bool {
+	return strings.Contains(output, "1 received") || strings.Contains(output, "1 packets received")
+}
+
+// setDontFragment sets IP_MTU_DISCOVER to IP_PMTUDISC_DO, so the kernel
+// never fragments and reports EMSGSIZE above the known path MTU
+func setDontFragment(network, address string, c syscall.RawConn) error {
+	var sockErr error
+	err := c.Control(func(fd uintptr) {
+		if network == "udp6" {
+			sockErr = unix.SetsockoptInt(int(fd), unix.IPPROTO_IPV6, unix.IPV6_MTU_DISCOVER, unix.IPV6_PMTUDISC_DO)
+		} else {
+			sockErr = unix.SetsockoptInt(int(fd), unix.IPPROTO_IP, unix.IP_MTU_DISCOVER, unix.IP_PMTUDISC_DO)
+		}
+	})
+	if err != nil {
+		return err
+	}
+	return sockErr
+}
+
+// isMessageTooBig reports whether a send failed because the datagram
+// exceeds the path MTU
+func isMessageTooBig(err error) bool {
+	return errors.Is(err, unix.EMSGSIZE)
+}
@@ -0,0 +1,62 @@
+package netcheck
+
+import (
+	"context"
+	"errors"
+	"net"
+	"os/exec"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"golang.org/x/sys/windows"
+)
+
+// IP_DONTFRAGMENT and IPV6_DONTFRAG from ws2ipdef.h
+const (
+	ipDontFragment = 14
+	ipv6DontFrag   = 14
+)
+
+// pingCommand pings ip once with payload bytes and fragmentation prohibited
+func pingCommand(ctx context.Context, ip net.IP, payload int) *exec.Cmd {
+	args := []string{"-n", "1", "-w", "1000", "-l", strconv.Itoa(payload)}
+	if ip.To4() == nil {
+		// IPv6 packets are never fragmented by routers
+		args = append(args, "-6")
+	} else {
+		args = append(args, "-4", "-f")
+	}
+	cmd := exec.CommandContext(ctx, "ping", append(args, ip.String())...)
+	cmd.SysProcAttr = &syscall.SysProcAttr{HideWindow: true}
+	return cmd
+}
+
+// pingReplied reports whether ping's output shows a reply; ping exits with
+// success on some errors, such as an unreachable destination
+func pingReplied(output string) bool {
+	output = strings.ToLower(output)
+	return strings.Contains(output, "time=") || strings.Contains(output, "time<")
+}
+
+// setDontFragment sets IP_DONTFRAGMENT (or IPV6_DONTFRAG) on the socket
+func setDontFragment(network, address string, c syscall.RawConn) error {
+	var sockErr error
+	err := c.Control(func(fd uintptr) {
+		if network == "udp6" {
+			sockErr = windows.SetsockoptInt(windows.Handle(fd), windows.IPPROTO_IPV6, ipv6DontFrag, 1)
+		} else {
+			sockErr = windows.SetsockoptInt(windows.Handle(fd), windows.IPPROTO_IP, ipDontFragment, 1)
+		}
+	})
+	if err != nil {
+		return err
+	}
+	return sockErr
+}
+
+// isMessageTooBig reports whether a send failed because the datagram
+// exceeds the path MTU
+func isMessageTooBig(err error) bool {
+	return errors.Is(err, windows.WSAEMSGSIZE)
+}
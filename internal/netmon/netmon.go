@@ -0,0 +1,110 @@
+// Package netmon identifies the network the host is on and reports when it
+// changes
+package netmon
+
+import (
+	"context"
+	"time"
+)
+
+// Network types
+const (
+	TypeWiFi  = "wifi"
+	TypeWired = "wired"
+	TypeOther = "other"
+	TypeNone  = "none"
+)
+
+const (
+	// settleDelay lets bursts of interface and route events settle before
+	// the network is identified again
+	settleDelay = 2 * time.Second
+	// pollInterval is used where no change notifications are available
+	pollInterval = 10 * time.Second
+)
+
+// Network identifies the network the host's default route uses
+type Network struct {
+	// ID is the Wi-Fi SSID, or the connection or interface name otherwise
+	ID   string `json:"id"`
+	Type string `json:"type"`
+	// Interface is the system interface carrying the default route
+	Interface string `json:"interface"`
+}
+
+// Current identifies the network the host is on
+func Current(ctx context.Context) (Network, error) {
+	return current(ctx)
+}
+
+// Watch sends the current network, and again each time it changes, until
+// ctx is done
+func Watch(ctx context.Context) <-chan Network {
+	out := make(chan Network, 1)
+
+	go func() {
+		defer close(out)
+
+		triggers := changes(ctx)
+		var last Network
+		first := true
+
+		for {
+			if n, err := current(ctx); err == nil && (first || n != last) {
+				select {
+				case out <- n:
+				case <-ctx.Done():
+					return
+				}
+				last = n
+				first = false
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-triggers:
+			}
+
+			// Coalesce the events that make up a single change
+			timer := time.NewTimer(settleDelay)
+		settle:
+			for {
+				select {
+				case <-ctx.Done():
+					timer.Stop()
+					return
+				case <-triggers:
+				case <-timer.C:
+					break settle
+				}
+			}
+		}
+	}()
+
+	return out
+}
+
+// pollChanges triggers a check every pollInterval
+func pollChanges(ctx context.Context) <-chan struct{} {
+	ch := make(chan struct{}, 1)
+
+	go func() {
+		ticker := time.NewTicker(pollInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				select {
+				case ch <- struct{}{}:
+				default:
+				}
+			}
+		}
+	}()
+
+	return ch
+}
@@ -0,0 +1,51 @@
+package netmon
+
+import (
+	"bufio"
+	"context"
+	"os/exec"
+	"strings"
+)
+
+// current finds the default route's interface and its Wi-Fi SSID, if any
+func current(ctx context.Context) (Network, error) {
+	output, err := exec.CommandContext(ctx, "route", "-n", "get", "default").Output()
+	if err != nil {
+		return Network{Type: TypeNone}, nil
+	}
+
+	iface := fieldValue(string(output), "interface")
+	if iface == "" {
+		return Network{Type: TypeNone}, nil
+	}
+
+	n := Network{ID: iface, Type: TypeWired, Interface: iface}
+
+	// ipconfig reports the SSID of Wi-Fi interfaces
+	summary, err := exec.CommandContext(ctx, "ipconfig", "getsummary", iface).Output()
+	if err == nil && strings.Contains(string(summary), "InterfaceType : WiFi") {
+		n.Type = TypeWiFi
+		if ssid := fieldValue(string(summary), "SSID"); ssid != "" {
+			n.ID = ssid
+		}
+	}
+
+	return n, nil
+}
+
+// fieldValue returns the value of a "key : value" line
+func fieldValue(output, key string) string {
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		k, v, ok := strings.Cut(scanner.Text(), ":")
+		if ok && strings.TrimSpace(k) == key {
+			return strings.TrimSpace(v)
+		}
+	}
+	return ""
+}
+
+// changes polls, as macOS has no notification API reachable without cgo
+func changes(ctx context.Context) <-chan struct{} {
+	return pollChanges(ctx)
+}
@@ -0,0 +1,141 @@
+package netmon
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/vishvananda/netlink"
+)
+
+const (
+	nmDest          = "org.freedesktop.NetworkManager"
+	nmPath          = "/org/freedesktop/NetworkManager"
+	nmActiveConnIfc = "org.freedesktop.NetworkManager.Connection.Active"
+)
+
+// current asks NetworkManager for the primary connection and falls back to
+// inspecting the default route when NetworkManager is not running
+func current(ctx context.Context) (Network, error) {
+	iface, ok := defaultRouteInterface()
+	if !ok {
+		return Network{Type: TypeNone}, nil
+	}
+
+	if n, err := networkManagerPrimary(); err == nil && n.Type != TypeNone {
+		n.Interface = iface
+		return n, nil
+	}
+
+	n := Network{ID: iface, Type: TypeWired, Interface: iface}
+	if _, err := os.Stat(filepath.Join("/sys/class/net", iface, "wireless")); err == nil {
+		n.Type = TypeWiFi
+		if output, err := exec.CommandContext(ctx, "iwgetid", "-r", iface).Output(); err == nil {
+			if ssid := strings.TrimSpace(string(output)); ssid != "" {
+				n.ID = ssid
+			}
+		}
+	}
+	return n, nil
+}
+
+// defaultRouteInterface returns the interface of the main table's IPv4
+// default route
+func defaultRouteInterface() (string, bool) {
+	routes, err := netlink.RouteList(nil, netlink.FAMILY_V4)
+	if err != nil {
+		return "", false
+	}
+
+	for _, route := range routes {
+		if route.Dst != nil && !route.Dst.IP.IsUnspecified() {
+			continue
+		}
+		link, err := netlink.LinkByIndex(route.LinkIndex)
+		if err != nil {
+			continue
+		}
+		return link.Attrs().Name, true
+	}
+	return "", false
+}
+
+// networkManagerPrimary reads NetworkManager's primary connection. For
+// Wi-Fi the connection ID defaults to the SSID.
+func networkManagerPrimary() (Network, error) {
+	conn, err := dbus.ConnectSystemBus()
+	if err != nil {
+		return Network{}, err
+	}
+	defer conn.Close()
+
+	nm := conn.Object(nmDest, nmPath)
+	primary, err := nm.GetProperty(nmDest + ".PrimaryConnection")
+	if err != nil {
+		return Network{}, err
+	}
+
+	path, ok := primary.Value().(dbus.ObjectPath)
+	if !ok || path == "/" {
+		return Network{Type: TypeNone}, nil
+	}
+
+	active := conn.Object(nmDest, path)
+	id, err := active.GetProperty(nmActiveConnIfc + ".Id")
+	if err != nil {
+		return Network{}, err
+	}
+	connType, err := active.GetProperty(nmActiveConnIfc + ".Type")
+	if err != nil {
+		return Network{}, err
+	}
+
+	n := Network{ID: id.Value().(string), Type: TypeOther}
+	switch connType.Value().(string) {
+	case "802-11-wireless":
+		n.Type = TypeWiFi
+	case "802-3-ethernet":
+		n.Type = TypeWired
+	}
+	return n, nil
+}
+
+// changes triggers on netlink link, address and route events, falling back
+// to polling if the subscriptions fail
+func changes(ctx context.Context) <-chan struct{} {
+	links := make(chan netlink.LinkUpdate, 16)
+	addrs := make(chan netlink.AddrUpdate, 16)
+	routes := make(chan netlink.RouteUpdate, 16)
+
+	done := ctx.Done()
+	if err := netlink.LinkSubscribe(links, done); err != nil {
+		return pollChanges(ctx)
+	}
+	if err := netlink.AddrSubscribe(addrs, done); err != nil {
+		return pollChanges(ctx)
+	}
+	if err := netlink.RouteSubscribe(routes, done); err != nil {
+		return pollChanges(ctx)
+	}
+
+	ch := make(chan struct{}, 1)
+	go func() {
+		for {
+			select {
+			case <-done:
+				return
+			case <-links:
+			case <-addrs:
+			case <-routes:
+			}
+			select {
+			case ch <- struct{}{}:
+			default:
+			}
+		}
+	}()
+	return ch
+}
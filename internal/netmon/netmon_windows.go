@@ -0,0 +1,60 @@
+package netmon
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"os/exec"
+	"strings"
+	"syscall"
+)
+
+// current reports the connected Wi-Fi network, or the first active
+// Ethernet adapter
+func current(ctx context.Context) (Network, error) {
+	cmd := exec.CommandContext(ctx, "netsh", "wlan", "show", "interfaces")
+	cmd.SysProcAttr = &syscall.SysProcAttr{HideWindow: true}
+	if output, err := cmd.Output(); err == nil {
+		fields := parseNetsh(string(output))
+		if strings.EqualFold(fields["State"], "connected") && fields["SSID"] != "" {
+			return Network{ID: fields["SSID"], Type: TypeWiFi, Interface: fields["Name"]}, nil
+		}
+	}
+
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return Network{}, err
+	}
+	for _, ifi := range ifaces {
+		if ifi.Flags&net.FlagUp == 0 || ifi.Flags&net.FlagLoopback != 0 || len(ifi.HardwareAddr) == 0 {
+			continue
+		}
+		if strings.Contains(strings.ToLower(ifi.Name), "ethernet") {
+			return Network{ID: ifi.Name, Type: TypeWired, Interface: ifi.Name}, nil
+		}
+	}
+
+	return Network{Type: TypeNone}, nil
+}
+
+// parseNetsh reads "Key : Value" lines from netsh output
+func parseNetsh(output string) map[string]string {
+	fields := make(map[string]string)
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		k, v, ok := strings.Cut(scanner.Text(), ":")
+		if !ok {
+			continue
+		}
+		key := strings.TrimSpace(k)
+		if _, seen := fields[key]; !seen {
+			fields[key] = strings.TrimSpace(v)
+		}
+	}
+	return fields
+}
+
+// changes polls for network changes
+func changes(ctx context.Context) <-chan struct{} {
+	return pollChanges(ctx)
+}
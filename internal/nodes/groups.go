@@ -0,0 +1,119 @@
+package nodes
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/nikola43/aureo-vpn-client/internal/models"
+)
+
+// Group IDs
+const (
+	GroupFastestInCountry = "fastest_in_country"
+	GroupLeastLoaded      = "least_loaded"
+	GroupOperatorOwned    = "operator_owned"
+	GroupFirstParty       = "first_party"
+	GroupStreaming        = "streaming"
+)
+
+// TagStreaming marks nodes that work with streaming services
+const TagStreaming = "streaming"
+
+// leastLoadedCount is the size of the least loaded group
+const leastLoadedCount = 10
+
+// Group is a named, computed list of nodes
+type Group struct {
+	ID    string           `json:"id"`
+	Name  string           `json:"name"`
+	Nodes []models.VPNNode `json:"nodes"`
+}
+
+// Groups builds the computed groups from a node list. Inactive nodes are
+// left out. tags maps node IDs to the user's tags.
+func Groups(list []models.VPNNode, tags map[string][]string) []Group {
+	var active []models.VPNNode
+	for _, node := range list {
+		if node.IsActive {
+			active = append(active, node)
+		}
+	}
+
+	return []Group{
+		{ID: GroupFastestInCountry, Name: "Fastest in each country", Nodes: FastestInCountry(active)},
+		{ID: GroupLeastLoaded, Name: "Least loaded", Nodes: LeastLoaded(active, leastLoadedCount)},
+		{ID: GroupOperatorOwned, Name: "Community operators", Nodes: filter(active, func(n models.VPNNode) bool { return n.IsOperatorOwned })},
+		{ID: GroupFirstParty, Name: "Aureo servers", Nodes: filter(active, func(n models.VPNNode) bool { return !n.IsOperatorOwned })},
+		{ID: GroupStreaming, Name: "Streaming", Nodes: filter(active, func(n models.VPNNode) bool { return HasTag(tags[n.ID], TagStreaming) })},
+	}
+}
+
+// FastestInCountry returns the fastest node of each country, ordered by
+// country. Nodes with a measured latency beat those without; ties are
+// broken by load.
+func FastestInCountry(list []models.VPNNode) []models.VPNNode {
+	best := map[string]models.VPNNode{}
+	for _, node := range list {
+		key := node.CountryCode
+		if key == "" {
+			key = node.Country
+		}
+		if current, ok := best[key]; !ok || faster(node, current) {
+			best[key] = node
+		}
+	}
+
+	result := make([]models.VPNNode, 0, len(best))
+	for _, node := range best {
+		result = append(result, node)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Country < result[j].Country
+	})
+	return result
+}
+
+// LeastLoaded returns up to n nodes with the lowest load score
+func LeastLoaded(list []models.VPNNode, n int) []models.VPNNode {
+	result := append([]models.VPNNode{}, list...)
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].LoadScore < result[j].LoadScore
+	})
+	if len(result) > n {
+		result = result[:n]
+	}
+	return result
+}
+
+// HasTag reports whether tags contains tag, ignoring case
+func HasTag(tags []string, tag string) bool {
+	for _, t := range tags {
+		if strings.EqualFold(t, tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// faster reports whether a should be preferred over b
+func faster(a, b models.VPNNode) bool {
+	switch {
+	case a.Latency > 0 && b.Latency <= 0:
+		return true
+	case a.Latency <= 0 && b.Latency > 0:
+		return false
+	case a.Latency != b.Latency:
+		return a.Latency < b.Latency
+	}
+	return a.LoadScore < b.LoadScore
+}
+
+func filter(list []models.VPNNode, keep func(models.VPNNode) bool) []models.VPNNode {
+	result := []models.VPNNode{}
+	for _, node := range list {
+		if keep(node) {
+			result = append(result, node)
+		}
+	}
+	return result
+}
@@ -0,0 +1,206 @@
+package nodes
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/nikola43/aureo-vpn-client/internal/api"
+	"github.com/nikola43/aureo-vpn-client/internal/models"
+)
+
+// Delta describes how one node changed
+type Delta struct {
+	ID      string `json:"id"`
+	Added   bool   `json:"added,omitempty"`
+	Removed bool   `json:"removed,omitempty"`
+	// Node is the full node when it was added or changed beyond its status
+	Node *models.VPNNode `json:"node,omitempty"`
+	// Changes maps JSON field names to their new values
+	Changes map[string]interface{} `json:"changes,omitempty"`
+}
+
+// Live is the in-memory node list, kept current by the status feed
+type Live struct {
+	mu    sync.Mutex
+	nodes map[string]models.VPNNode
+}
+
+// NewLive creates an empty live node list
+func NewLive() *Live {
+	return &Live{nodes: map[string]models.VPNNode{}}
+}
+
+// Nodes returns the current nodes, least loaded first
+func (l *Live) Nodes() []models.VPNNode {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	list := make([]models.VPNNode, 0, len(l.nodes))
+	for _, node := range l.nodes {
+		list = append(list, node)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].LoadScore != list[j].LoadScore {
+			return list[i].LoadScore < list[j].LoadScore
+		}
+		return list[i].ID < list[j].ID
+	})
+	return list
+}
+
+// Node returns a node by ID
+func (l *Live) Node(id string) (models.VPNNode, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	node, ok := l.nodes[id]
+	return node, ok
+}
+
+// Replace swaps in a freshly fetched node list and returns what changed
+func (l *Live) Replace(list []models.VPNNode) []Delta {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	deltas := []Delta{}
+	next := make(map[string]models.VPNNode, len(list))
+	for _, node := range list {
+		node := node
+		next[node.ID] = node
+
+		old, ok := l.nodes[node.ID]
+		if !ok {
+			deltas = append(deltas, Delta{ID: node.ID, Added: true, Node: &node})
+			continue
+		}
+		if changes := statusChanges(old, node); len(changes) > 0 || !sameStatic(old, node) {
+			delta := Delta{ID: node.ID, Changes: changes}
+			if !sameStatic(old, node) {
+				delta.Node = &node
+			}
+			deltas = append(deltas, delta)
+		}
+	}
+	for id := range l.nodes {
+		if _, ok := next[id]; !ok {
+			deltas = append(deltas, Delta{ID: id, Removed: true})
+		}
+	}
+
+	l.nodes = next
+	return deltas
+}
+
+// Apply merges a status message and returns what changed, along with the
+// IDs of nodes that are not in the list and need a full refresh. A snapshot
+// lists every node, so nodes missing from it are removed.
+func (l *Live) Apply(msg api.NodeStatusMessage) ([]Delta, []string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	deltas := []Delta{}
+	var unknown []string
+	if msg.Type == api.NodeStatusSnapshot {
+		listed := make(map[string]bool, len(msg.Nodes))
+		for _, status := range msg.Nodes {
+			listed[status.ID] = true
+		}
+		for id := range l.nodes {
+			if !listed[id] {
+				delete(l.nodes, id)
+				deltas = append(deltas, Delta{ID: id, Removed: true})
+			}
+		}
+	}
+	for _, status := range msg.Nodes {
+		node, ok := l.nodes[status.ID]
+		if msg.Type == api.NodeStatusRemove {
+			if ok {
+				delete(l.nodes, status.ID)
+				deltas = append(deltas, Delta{ID: status.ID, Removed: true})
+			}
+			continue
+		}
+		if !ok {
+			unknown = append(unknown, status.ID)
+			continue
+		}
+
+		updated := node
+		mergeStatus(&updated, status)
+		if changes := statusChanges(node, updated); len(changes) > 0 {
+			l.nodes[status.ID] = updated
+			deltas = append(deltas, Delta{ID: status.ID, Changes: changes})
+		}
+	}
+	return deltas, unknown
+}
+
+// mergeStatus copies the fields present in a status update into node
+func mergeStatus(node *models.VPNNode, status api.NodeStatus) {
+	if status.Status != nil {
+		node.Status = *status.Status
+	}
+	if status.IsActive != nil {
+		node.IsActive = *status.IsActive
+	}
+	if status.LoadScore != nil {
+		node.LoadScore = *status.LoadScore
+	}
+	if status.Latency != nil {
+		node.Latency = *status.Latency
+	}
+	if status.CurrentConnections != nil {
+		node.CurrentConnections = *status.CurrentConnections
+	}
+	if status.UptimePercentage != nil {
+		node.UptimePercentage = *status.UptimePercentage
+	}
+	if status.LastHeartbeat != nil {
+		node.LastHeartbeat = *status.LastHeartbeat
+	}
+}
+
+// statusChanges returns the status fields that differ between two versions
+// of a node, keyed by JSON name
+func statusChanges(old, node models.VPNNode) map[string]interface{} {
+	changes := map[string]interface{}{}
+	if old.Status != node.Status {
+		changes["status"] = node.Status
+	}
+	if old.IsActive != node.IsActive {
+		changes["is_active"] = node.IsActive
+	}
+	if old.LoadScore != node.LoadScore {
+		changes["load_score"] = node.LoadScore
+	}
+	if old.Latency != node.Latency {
+		changes["latency"] = node.Latency
+	}
+	if old.CurrentConnections != node.CurrentConnections {
+		changes["current_connections"] = node.CurrentConnections
+	}
+	if old.UptimePercentage != node.UptimePercentage {
+		changes["uptime_percentage"] = node.UptimePercentage
+	}
+	if !old.LastHeartbeat.Equal(node.LastHeartbeat) {
+		changes["last_heartbeat"] = node.LastHeartbeat
+	}
+	if len(changes) == 0 {
+		return nil
+	}
+	return changes
+}
+
+// sameStatic reports whether two versions of a node differ only in status fields
+func sameStatic(old, node models.VPNNode) bool {
+	mergeStatus(&old, api.NodeStatus{
+		Status:             &node.Status,
+		IsActive:           &node.IsActive,
+		LoadScore:          &node.LoadScore,
+		Latency:            &node.Latency,
+		CurrentConnections: &node.CurrentConnections,
+		UptimePercentage:   &node.UptimePercentage,
+		LastHeartbeat:      &node.LastHeartbeat,
+	})
+	return old == node
+}
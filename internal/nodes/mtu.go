@@ -0,0 +1,54 @@
+package nodes
+
+import "time"
+
+// MTU is a tunnel MTU discovered for a node from one network
+type MTU struct {
+	NodeID    string `json:"node_id"`
+	NetworkID string `json:"network_id"`
+	// PathMTU is the measured path MTU to the node's endpoint and MTU the
+	// tunnel MTU derived from it
+	PathMTU    int       `json:"path_mtu"`
+	MTU        int       `json:"mtu"`
+	Method     string    `json:"method"`
+	MeasuredAt time.Time `json:"measured_at"`
+}
+
+// mtuKey identifies the MTU entry of a node on a network
+func mtuKey(networkID, nodeID string) string {
+	return networkID + "|" + nodeID
+}
+
+// MTU returns the discovered MTU of a node on a network if it was measured
+// within maxAge
+func (s *Store) MTU(networkID, nodeID string, maxAge time.Duration) (MTU, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.data.MTUs[mtuKey(networkID, nodeID)]
+	if !ok || time.Since(entry.MeasuredAt) > maxAge {
+		return MTU{}, false
+	}
+	return entry, true
+}
+
+// SetMTU records a discovered MTU
+func (s *Store) SetMTU(entry MTU) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.data.MTUs[mtuKey(entry.NetworkID, entry.NodeID)] = entry
+	return s.save()
+}
+
+// ClearMTUs forgets every discovered MTU
+func (s *Store) ClearMTUs() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.data.MTUs) == 0 {
+		return nil
+	}
+	s.data.MTUs = map[string]MTU{}
+	return s.save()
+}
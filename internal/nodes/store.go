@@ -0,0 +1,305 @@
+// Package nodes keeps the user's favorite nodes, connection history, tags
+// and discovered MTUs in a local JSON file, builds computed groups from node
+// lists and holds the live node list updated by the status feed.
+package nodes
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+// maxAttempts bounds the connection history kept on disk
+const maxAttempts = 500
+
+// Attempt is a single connection attempt to a node
+type Attempt struct {
+	NodeID    string    `json:"node_id"`
+	NodeName  string    `json:"node_name"`
+	Protocol  string    `json:"protocol"`
+	StartedAt time.Time `json:"started_at"`
+	// EndedAt is zero while a successful connection is still up
+	EndedAt time.Time `json:"ended_at"`
+	Success bool      `json:"success"`
+	Error   string    `json:"error,omitempty"`
+}
+
+// Duration returns how long the connection was up
+func (a Attempt) Duration() time.Duration {
+	if !a.Success || a.EndedAt.IsZero() {
+		return 0
+	}
+	return a.EndedAt.Sub(a.StartedAt)
+}
+
+// Recent summarizes the connection history of one node
+type Recent struct {
+	NodeID         string    `json:"node_id"`
+	NodeName       string    `json:"node_name"`
+	Protocol       string    `json:"protocol"`
+	LastAttempt    time.Time `json:"last_attempt"`
+	LastSuccess    bool      `json:"last_success"`
+	LastError      string    `json:"last_error,omitempty"`
+	Successes      int       `json:"successes"`
+	Failures       int       `json:"failures"`
+	TotalSeconds   int64     `json:"total_seconds"`
+	AverageSeconds int64     `json:"average_seconds"`
+	LastSeconds    int64     `json:"last_seconds"`
+}
+
+// data is the on-disk format
+type data struct {
+	Favorites []string            `json:"favorites"`
+	Attempts  []Attempt           `json:"attempts"`
+	Tags      map[string][]string `json:"tags"`
+	// MTUs holds discovered tunnel MTUs keyed by network and node
+	MTUs map[string]MTU `json:"mtus"`
+}
+
+// Store persists favorites, connection history, tags and MTUs
+type Store struct {
+	path string
+
+	mu   sync.Mutex
+	data data
+}
+
+// Open loads the store at path, starting empty if the file does not exist
+func Open(path string) (*Store, error) {
+	s := &Store{path: path, data: data{Favorites: []string{}, Tags: map[string][]string{}, MTUs: map[string]MTU{}}}
+
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return s, nil
+		}
+		return nil, fmt.Errorf("failed to read node store: %w", err)
+	}
+	if err := json.Unmarshal(raw, &s.data); err != nil {
+		return nil, fmt.Errorf("failed to parse node store: %w", err)
+	}
+	if s.data.Favorites == nil {
+		s.data.Favorites = []string{}
+	}
+	if s.data.Tags == nil {
+		s.data.Tags = map[string][]string{}
+	}
+	if s.data.MTUs == nil {
+		s.data.MTUs = map[string]MTU{}
+	}
+	return s, nil
+}
+
+// save writes the store atomically; the caller holds s.mu
+func (s *Store) save() error {
+	raw, err := json.MarshalIndent(s.data, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal node store: %w", err)
+	}
+
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, raw, 0600); err != nil {
+		return fmt.Errorf("failed to write node store: %w", err)
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to write node store: %w", err)
+	}
+	return nil
+}
+
+// Favorites returns the favorite node IDs in the order they were added
+func (s *Store) Favorites() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string{}, s.data.Favorites...)
+}
+
+// IsFavorite reports whether the node is a favorite
+func (s *Store) IsFavorite(nodeID string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return indexOf(s.data.Favorites, nodeID) >= 0
+}
+
+// AddFavorite marks a node as favorite
+func (s *Store) AddFavorite(nodeID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if indexOf(s.data.Favorites, nodeID) >= 0 {
+		return nil
+	}
+	s.data.Favorites = append(s.data.Favorites, nodeID)
+	return s.save()
+}
+
+// RemoveFavorite unmarks a favorite node
+func (s *Store) RemoveFavorite(nodeID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := indexOf(s.data.Favorites, nodeID)
+	if i < 0 {
+		return nil
+	}
+	s.data.Favorites = append(s.data.Favorites[:i], s.data.Favorites[i+1:]...)
+	return s.save()
+}
+
+// Tags returns the tags of every tagged node
+func (s *Store) Tags() map[string][]string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tags := make(map[string][]string, len(s.data.Tags))
+	for id, t := range s.data.Tags {
+		tags[id] = append([]string{}, t...)
+	}
+	return tags
+}
+
+// SetTags replaces the tags of a node; no tags removes the entry
+func (s *Store) SetTags(nodeID string, tags []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(tags) == 0 {
+		delete(s.data.Tags, nodeID)
+	} else {
+		s.data.Tags[nodeID] = append([]string{}, tags...)
+	}
+	return s.save()
+}
+
+// Connected records a successful connection that is now up
+func (s *Store) Connected(nodeID, nodeName, protocol string, at time.Time) error {
+	return s.record(Attempt{NodeID: nodeID, NodeName: nodeName, Protocol: protocol, StartedAt: at, Success: true})
+}
+
+// Failed records a connection attempt that did not come up
+func (s *Store) Failed(nodeID, nodeName, protocol string, at time.Time, cause error) error {
+	attempt := Attempt{NodeID: nodeID, NodeName: nodeName, Protocol: protocol, StartedAt: at, EndedAt: at}
+	if cause != nil {
+		attempt.Error = cause.Error()
+	}
+	return s.record(attempt)
+}
+
+// Disconnected ends every connection that is still up
+func (s *Store) Disconnected(at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	changed := false
+	for i := range s.data.Attempts {
+		if s.data.Attempts[i].Success && s.data.Attempts[i].EndedAt.IsZero() {
+			s.data.Attempts[i].EndedAt = at
+			changed = true
+		}
+	}
+	if !changed {
+		return nil
+	}
+	return s.save()
+}
+
+// Current returns the latest connection that is still up
+func (s *Store) Current() (Attempt, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := len(s.data.Attempts) - 1; i >= 0; i-- {
+		if attempt := s.data.Attempts[i]; attempt.Success && attempt.EndedAt.IsZero() {
+			return attempt, true
+		}
+	}
+	return Attempt{}, false
+}
+
+func (s *Store) record(attempt Attempt) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.data.Attempts = append(s.data.Attempts, attempt)
+	if n := len(s.data.Attempts); n > maxAttempts {
+		s.data.Attempts = append([]Attempt{}, s.data.Attempts[n-maxAttempts:]...)
+	}
+	return s.save()
+}
+
+// Attempts returns up to limit of the most recent attempts, newest first
+func (s *Store) Attempts(limit int) []Attempt {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	attempts := make([]Attempt, 0, len(s.data.Attempts))
+	for i := len(s.data.Attempts) - 1; i >= 0; i-- {
+		if limit > 0 && len(attempts) == limit {
+			break
+		}
+		attempts = append(attempts, s.data.Attempts[i])
+	}
+	return attempts
+}
+
+// Recents summarizes the history per node, most recently used first
+func (s *Store) Recents(limit int) []Recent {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	byNode := map[string]*Recent{}
+	var order []*Recent
+	for _, attempt := range s.data.Attempts {
+		r, ok := byNode[attempt.NodeID]
+		if !ok {
+			r = &Recent{NodeID: attempt.NodeID}
+			byNode[attempt.NodeID] = r
+			order = append(order, r)
+		}
+
+		if attempt.NodeName != "" {
+			r.NodeName = attempt.NodeName
+		}
+		r.Protocol = attempt.Protocol
+		r.LastAttempt = attempt.StartedAt
+		r.LastSuccess = attempt.Success
+		r.LastError = attempt.Error
+		if attempt.Success {
+			r.Successes++
+			seconds := int64(attempt.Duration().Seconds())
+			r.TotalSeconds += seconds
+			r.LastSeconds = seconds
+		} else {
+			r.Failures++
+		}
+	}
+
+	sort.SliceStable(order, func(i, j int) bool {
+		return order[i].LastAttempt.After(order[j].LastAttempt)
+	})
+
+	recents := make([]Recent, 0, len(order))
+	for _, r := range order {
+		if limit > 0 && len(recents) == limit {
+			break
+		}
+		if r.Successes > 0 {
+			r.AverageSeconds = r.TotalSeconds / int64(r.Successes)
+		}
+		recents = append(recents, *r)
+	}
+	return recents
+}
+
+func indexOf(list []string, s string) int {
+	for i, v := range list {
+		if v == s {
+			return i
+		}
+	}
+	return -1
+}
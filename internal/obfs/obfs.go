@@ -0,0 +1,264 @@
+// Package obfs carries WireGuard's UDP datagrams over a TLS or WebSocket
+// stream to a relay on the node, for networks that block UDP. WireGuard is
+// pointed at a local UDP listener; each datagram it sends there is framed
+// onto the stream, and datagrams from the relay are sent back to it.
+package obfs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/nikola43/aureo-vpn-client/internal/logging"
+)
+
+var logger = logging.For(logging.SubsystemVPN)
+
+// Transports
+const (
+	TransportTLS       = "tls"
+	TransportWebSocket = "websocket"
+)
+
+const (
+	dialTimeout = 15 * time.Second
+	// maxPacketSize bounds a single datagram; WireGuard never sends more
+	// than the interface MTU plus its header
+	maxPacketSize = 65535
+
+	redialMinBackoff = time.Second
+	redialMaxBackoff = 30 * time.Second
+)
+
+// Config describes the relay to connect to
+type Config struct {
+	Transport string
+	// Address is host:port for TLS, or a ws:// or wss:// URL for WebSocket
+	Address string
+	// ServerName overrides the name the relay's certificate is checked
+	// against; empty uses the host from Address
+	ServerName string
+}
+
+// Proxy is a local UDP endpoint bridged to a relay stream
+type Proxy struct {
+	cfg    Config
+	remote netip.Addr
+	conn   *net.UDPConn
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	mu     sync.Mutex
+	stream stream
+
+	// peer is the WireGuard socket's address, learned from its first datagram
+	peer atomic.Pointer[net.UDPAddr]
+}
+
+// Start resolves and connects to the relay and opens the local listener.
+// The relay's address is resolved once, so routes can exclude it from the
+// tunnel before WireGuard starts using the proxy.
+func Start(ctx context.Context, cfg Config) (*Proxy, error) {
+	host, err := relayHost(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	remote, err := resolve(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &Proxy{cfg: cfg, remote: remote}
+
+	dialCtx, cancel := context.WithTimeout(ctx, dialTimeout)
+	defer cancel()
+	if p.stream, err = p.dial(dialCtx); err != nil {
+		return nil, fmt.Errorf("failed to connect to %s relay: %w", cfg.Transport, err)
+	}
+
+	p.conn, err = net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		p.stream.Close()
+		return nil, fmt.Errorf("failed to open local relay endpoint: %w", err)
+	}
+
+	p.ctx, p.cancel = context.WithCancel(context.Background())
+	p.wg.Add(2)
+	go p.uplink()
+	go p.downlink()
+
+	logger.Info("obfuscation relay connected", "transport", cfg.Transport, "relay", cfg.Address, "local", p.LocalAddr())
+	return p, nil
+}
+
+// LocalAddr returns the UDP endpoint WireGuard should use
+func (p *Proxy) LocalAddr() string {
+	return p.conn.LocalAddr().String()
+}
+
+// RemoteAddr returns the relay's IP address
+func (p *Proxy) RemoteAddr() netip.Addr {
+	return p.remote
+}
+
+// Close stops the proxy and closes the relay connection
+func (p *Proxy) Close() error {
+	p.cancel()
+	err := p.conn.Close()
+
+	p.mu.Lock()
+	if p.stream != nil {
+		p.stream.Close()
+	}
+	p.mu.Unlock()
+
+	p.wg.Wait()
+	return err
+}
+
+// dial opens a stream to the relay
+func (p *Proxy) dial(ctx context.Context) (stream, error) {
+	switch p.cfg.Transport {
+	case TransportTLS:
+		return dialTLS(ctx, p.cfg, p.remote)
+	case TransportWebSocket:
+		return dialWebSocket(ctx, p.cfg, p.remote)
+	}
+	return nil, fmt.Errorf("unknown transport: %s", p.cfg.Transport)
+}
+
+// current returns the open stream, or nil while reconnecting
+func (p *Proxy) current() stream {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.stream
+}
+
+// uplink forwards datagrams from WireGuard to the relay. Datagrams that
+// arrive while the stream is down are dropped, as UDP would.
+func (p *Proxy) uplink() {
+	defer p.wg.Done()
+
+	buf := make([]byte, maxPacketSize)
+	for {
+		n, addr, err := p.conn.ReadFromUDP(buf)
+		if err != nil {
+			return
+		}
+		p.peer.Store(addr)
+
+		s := p.current()
+		if s == nil {
+			continue
+		}
+		if err := s.WritePacket(buf[:n]); err != nil {
+			// downlink notices the broken stream and reconnects
+			s.Close()
+		}
+	}
+}
+
+// downlink forwards datagrams from the relay to WireGuard and reconnects
+// the stream when it breaks
+func (p *Proxy) downlink() {
+	defer p.wg.Done()
+
+	backoff := redialMinBackoff
+	for {
+		s := p.current()
+		for s != nil {
+			packet, err := s.ReadPacket()
+			if err != nil {
+				logger.Debug("obfuscation relay stream closed", "error", err)
+				break
+			}
+			backoff = redialMinBackoff
+			if peer := p.peer.Load(); peer != nil {
+				p.conn.WriteToUDP(packet, peer)
+			}
+		}
+
+		p.mu.Lock()
+		if p.stream != nil {
+			p.stream.Close()
+			p.stream = nil
+		}
+		p.mu.Unlock()
+
+		select {
+		case <-p.ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+		if backoff > redialMaxBackoff {
+			backoff = redialMaxBackoff
+		}
+
+		dialCtx, cancel := context.WithTimeout(p.ctx, dialTimeout)
+		next, err := p.dial(dialCtx)
+		cancel()
+		if err != nil {
+			logger.Warn("failed to reconnect obfuscation relay", "error", err)
+			continue
+		}
+
+		p.mu.Lock()
+		if p.ctx.Err() != nil {
+			p.mu.Unlock()
+			next.Close()
+			return
+		}
+		p.stream = next
+		p.mu.Unlock()
+	}
+}
+
+// relayHost returns the host part of the relay address
+func relayHost(cfg Config) (string, error) {
+	switch cfg.Transport {
+	case TransportTLS:
+		host, _, err := net.SplitHostPort(cfg.Address)
+		if err != nil {
+			return "", fmt.Errorf("invalid relay address %q: %w", cfg.Address, err)
+		}
+		return host, nil
+	case TransportWebSocket:
+		u, err := url.Parse(cfg.Address)
+		if err != nil || (u.Scheme != "ws" && u.Scheme != "wss") || u.Hostname() == "" {
+			return "", fmt.Errorf("invalid relay URL %q", cfg.Address)
+		}
+		return u.Hostname(), nil
+	}
+	return "", fmt.Errorf("unknown transport: %s", cfg.Transport)
+}
+
+// resolve returns host's address, preferring IPv4
+func resolve(ctx context.Context, host string) (netip.Addr, error) {
+	if addr, err := netip.ParseAddr(host); err == nil {
+		return addr.Unmap(), nil
+	}
+
+	addrs, err := net.DefaultResolver.LookupNetIP(ctx, "ip", host)
+	if err == nil && len(addrs) == 0 {
+		err = errors.New("no addresses found")
+	}
+	if err != nil {
+		return netip.Addr{}, fmt.Errorf("failed to resolve relay %s: %w", host, err)
+	}
+	for _, addr := range addrs {
+		if addr.Unmap().Is4() {
+			return addr.Unmap(), nil
+		}
+	}
+	return addrs[0], nil
+}
@@ -0,0 +1,146 @@
+package obfs
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"sync"
+
+	"github.com/gorilla/websocket"
+)
+
+// stream carries whole datagrams to and from the relay. One goroutine may
+// read while another writes.
+type stream interface {
+	ReadPacket() ([]byte, error)
+	WritePacket(packet []byte) error
+	Close() error
+}
+
+// tlsStream frames each datagram with a 2-byte big-endian length
+type tlsStream struct {
+	conn *tls.Conn
+	wmu  sync.Mutex
+	buf  []byte
+}
+
+// dialTLS connects to a TLS relay at remote, checking its certificate
+// against the configured server name
+func dialTLS(ctx context.Context, cfg Config, remote netip.Addr) (stream, error) {
+	host, port, _ := net.SplitHostPort(cfg.Address)
+	serverName := cfg.ServerName
+	if serverName == "" {
+		serverName = host
+	}
+
+	dialer := tls.Dialer{Config: &tls.Config{ServerName: serverName, MinVersion: tls.VersionTLS12}}
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(remote.String(), port))
+	if err != nil {
+		return nil, err
+	}
+	return &tlsStream{conn: conn.(*tls.Conn), buf: make([]byte, maxPacketSize)}, nil
+}
+
+func (s *tlsStream) ReadPacket() ([]byte, error) {
+	var header [2]byte
+	if _, err := io.ReadFull(s.conn, header[:]); err != nil {
+		return nil, err
+	}
+	n := int(binary.BigEndian.Uint16(header[:]))
+	if _, err := io.ReadFull(s.conn, s.buf[:n]); err != nil {
+		return nil, err
+	}
+	return s.buf[:n], nil
+}
+
+func (s *tlsStream) WritePacket(packet []byte) error {
+	if len(packet) > maxPacketSize {
+		return fmt.Errorf("packet too large: %d bytes", len(packet))
+	}
+
+	frame := make([]byte, 2+len(packet))
+	binary.BigEndian.PutUint16(frame, uint16(len(packet)))
+	copy(frame[2:], packet)
+
+	s.wmu.Lock()
+	defer s.wmu.Unlock()
+	_, err := s.conn.Write(frame)
+	return err
+}
+
+func (s *tlsStream) Close() error {
+	return s.conn.Close()
+}
+
+// wsStream sends each datagram as one binary WebSocket message
+type wsStream struct {
+	conn *websocket.Conn
+	wmu  sync.Mutex
+}
+
+// dialWebSocket connects to a WebSocket relay, dialing remote instead of
+// resolving the URL's host again
+func dialWebSocket(ctx context.Context, cfg Config, remote netip.Addr) (stream, error) {
+	u, _ := url.Parse(cfg.Address)
+	port := u.Port()
+	if port == "" {
+		port = "443"
+		if u.Scheme == "ws" {
+			port = "80"
+		}
+	}
+
+	serverName := cfg.ServerName
+	if serverName == "" {
+		serverName = u.Hostname()
+	}
+
+	var netDialer net.Dialer
+	dialer := websocket.Dialer{
+		NetDialContext: func(ctx context.Context, network, _ string) (net.Conn, error) {
+			return netDialer.DialContext(ctx, network, net.JoinHostPort(remote.String(), port))
+		},
+		TLSClientConfig:  &tls.Config{ServerName: serverName, MinVersion: tls.VersionTLS12},
+		HandshakeTimeout: dialTimeout,
+		ReadBufferSize:   maxPacketSize,
+		WriteBufferSize:  maxPacketSize,
+	}
+
+	conn, resp, err := dialer.DialContext(ctx, cfg.Address, http.Header{})
+	if err != nil {
+		if resp != nil {
+			return nil, fmt.Errorf("%w (status %d)", err, resp.StatusCode)
+		}
+		return nil, err
+	}
+	conn.SetReadLimit(maxPacketSize)
+	return &wsStream{conn: conn}, nil
+}
+
+func (s *wsStream) ReadPacket() ([]byte, error) {
+	for {
+		kind, data, err := s.conn.ReadMessage()
+		if err != nil {
+			return nil, err
+		}
+		if kind == websocket.BinaryMessage {
+			return data, nil
+		}
+	}
+}
+
+func (s *wsStream) WritePacket(packet []byte) error {
+	s.wmu.Lock()
+	defer s.wmu.Unlock()
+	return s.conn.WriteMessage(websocket.BinaryMessage, packet)
+}
+
+func (s *wsStream) Close() error {
+	return s.conn.Close()
+}
@@ -0,0 +1,279 @@
+package pac
+
+import (
+	"context"
+	"math"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// dnsTimeout bounds the lookups of dnsResolve and isResolvable
+const dnsTimeout = 5 * time.Second
+
+// weekdays are the day names weekdayRange accepts
+var weekdays = []string{"SUN", "MON", "TUE", "WED", "THU", "FRI", "SAT"}
+
+// builtins returns the PAC helper functions
+func builtins() map[string]builtin {
+	return map[string]builtin{
+		"isPlainHostName": func(args []value) value {
+			return !strings.Contains(strArg(args, 0), ".")
+		},
+		"dnsDomainIs": func(args []value) value {
+			return strings.HasSuffix(strings.ToLower(strArg(args, 0)), strings.ToLower(strArg(args, 1)))
+		},
+		"localHostOrDomainIs": func(args []value) value {
+			host, hostdom := strings.ToLower(strArg(args, 0)), strings.ToLower(strArg(args, 1))
+			return host == hostdom || (!strings.Contains(host, ".") && strings.HasPrefix(hostdom, host+"."))
+		},
+		"isResolvable": func(args []value) value {
+			return resolve(strArg(args, 0)) != nil
+		},
+		"dnsResolve": func(args []value) value {
+			if ip := resolve(strArg(args, 0)); ip != nil {
+				return ip.String()
+			}
+			return nil
+		},
+		"isInNet": func(args []value) value {
+			ip := resolve(strArg(args, 0))
+			pattern := net.ParseIP(strArg(args, 1)).To4()
+			mask := net.ParseIP(strArg(args, 2)).To4()
+			if ip == nil || pattern == nil || mask == nil {
+				return false
+			}
+			return ip.Mask(net.IPMask(mask)).Equal(pattern.Mask(net.IPMask(mask)))
+		},
+		"myIpAddress": func(args []value) value {
+			return myIPAddress()
+		},
+		"dnsDomainLevels": func(args []value) value {
+			return float64(strings.Count(strArg(args, 0), "."))
+		},
+		"shExpMatch": func(args []value) value {
+			return shExpMatch(strArg(args, 0), strArg(args, 1))
+		},
+		"convert_addr": func(args []value) value {
+			ip := net.ParseIP(strArg(args, 0)).To4()
+			if ip == nil {
+				return float64(0)
+			}
+			return float64(uint32(ip[0])<<24 | uint32(ip[1])<<16 | uint32(ip[2])<<8 | uint32(ip[3]))
+		},
+		"weekdayRange": func(args []value) value {
+			now, args := clock(args)
+			first := dayIndex(strArg(args, 0))
+			last := first
+			if len(args) > 1 {
+				last = dayIndex(strArg(args, 1))
+			}
+			if first < 0 || last < 0 {
+				return false
+			}
+			return inRange(int(now.Weekday()), first, last)
+		},
+		"timeRange": func(args []value) value {
+			now, args := clock(args)
+			switch len(args) {
+			case 1:
+				return now.Hour() == int(toNumber(args[0]))
+			case 2:
+				return inRange(now.Hour(), int(toNumber(args[0])), int(toNumber(args[1]))-1)
+			}
+			throw("timeRange with minutes or seconds is not supported")
+			return nil
+		},
+		"alert": func(args []value) value {
+			return nil
+		},
+		"RegExp": func(args []value) value {
+			if re, ok := valueArg(args, 0).(*regexValue); ok {
+				return re
+			}
+			return newRegex(strArg(args, 0), strArg(args, 1))
+		},
+		"Array": func(args []value) value {
+			if n, ok := valueArg(args, 0).(float64); ok && len(args) == 1 {
+				if n < 0 || n > maxArrayLen || n != math.Trunc(n) {
+					throw("invalid array length")
+				}
+				return &arrayValue{elems: make([]value, int(n))}
+			}
+			return &arrayValue{elems: append([]value{}, args...)}
+		},
+		"String": func(args []value) value {
+			if len(args) == 0 {
+				return ""
+			}
+			return toString(args[0])
+		},
+		"Number": func(args []value) value {
+			if len(args) == 0 {
+				return float64(0)
+			}
+			return toNumber(args[0])
+		},
+		"isNaN": func(args []value) value {
+			return math.IsNaN(toNumber(valueArg(args, 0)))
+		},
+		"parseInt": func(args []value) value {
+			return parseInt(strArg(args, 0), int(toNumber(valueArg(args, 1))))
+		},
+		"parseFloat": func(args []value) value {
+			s := strings.TrimSpace(strArg(args, 0))
+			end := 0
+			for end < len(s) && (isDigit(s[end]) || strings.IndexByte(".eE+-", s[end]) >= 0) {
+				end++
+			}
+			for ; end > 0; end-- {
+				if n, err := strconv.ParseFloat(s[:end], 64); err == nil {
+					return n
+				}
+			}
+			return math.NaN()
+		},
+	}
+}
+
+// valueArg returns argument i, or undefined when it is missing
+func valueArg(args []value, i int) value {
+	if i >= len(args) {
+		return nil
+	}
+	return args[i]
+}
+
+// parseInt parses the leading integer of s in base, or base 10 (16 with a
+// 0x prefix) when base is 0
+func parseInt(s string, base int) value {
+	s = strings.TrimSpace(s)
+	sign := 1.0
+	if s != "" && (s[0] == '-' || s[0] == '+') {
+		if s[0] == '-' {
+			sign = -1
+		}
+		s = s[1:]
+	}
+	if (base == 0 || base == 16) && len(s) > 1 && s[0] == '0' && (s[1] == 'x' || s[1] == 'X') {
+		s, base = s[2:], 16
+	}
+	if base == 0 {
+		base = 10
+	}
+	if base < 2 || base > 36 {
+		return math.NaN()
+	}
+
+	n, digits := 0.0, 0
+	for _, c := range strings.ToLower(s) {
+		d := 36
+		switch {
+		case c >= '0' && c <= '9':
+			d = int(c - '0')
+		case c >= 'a' && c <= 'z':
+			d = int(c-'a') + 10
+		}
+		if d >= base {
+			break
+		}
+		n = n*float64(base) + float64(d)
+		digits++
+	}
+	if digits == 0 {
+		return math.NaN()
+	}
+	return sign * n
+}
+
+// strArg returns argument i as a string, or "" when it is missing
+func strArg(args []value, i int) string {
+	if i >= len(args) || args[i] == nil {
+		return ""
+	}
+	return toString(args[i])
+}
+
+// clock returns the current time, in UTC when the last argument is "GMT",
+// and the remaining arguments
+func clock(args []value) (time.Time, []value) {
+	if n := len(args); n > 0 && strArg(args, n-1) == "GMT" {
+		return time.Now().UTC(), args[:n-1]
+	}
+	return time.Now(), args
+}
+
+func dayIndex(day string) int {
+	for i, name := range weekdays {
+		if strings.EqualFold(day, name) {
+			return i
+		}
+	}
+	return -1
+}
+
+// inRange reports whether v lies in [first, last], wrapping around when
+// last is before first
+func inRange(v, first, last int) bool {
+	if first <= last {
+		return v >= first && v <= last
+	}
+	return v >= first || v <= last
+}
+
+// resolve returns host as an IPv4 address, looking it up if it is a name
+func resolve(host string) net.IP {
+	if ip := net.ParseIP(host); ip != nil {
+		return ip.To4()
+	}
+	if host == "" {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), dnsTimeout)
+	defer cancel()
+	ips, err := net.DefaultResolver.LookupIP(ctx, "ip4", host)
+	if err != nil || len(ips) == 0 {
+		return nil
+	}
+	return ips[0].To4()
+}
+
+// myIPAddress returns the address of the interface with the default route.
+// Connecting a UDP socket picks the route without sending anything.
+func myIPAddress() string {
+	conn, err := net.Dial("udp4", "198.51.100.1:53")
+	if err != nil {
+		return "127.0.0.1"
+	}
+	defer conn.Close()
+	return conn.LocalAddr().(*net.UDPAddr).IP.String()
+}
+
+// shExpMatch matches s against a shell expression, where * matches any
+// run of characters, including slashes, and ? any single character
+func shExpMatch(s, pattern string) bool {
+	// Backtrack to the last star on a mismatch
+	si, pi := 0, 0
+	star, mark := -1, 0
+	for si < len(s) {
+		switch {
+		case pi < len(pattern) && (pattern[pi] == '?' || pattern[pi] == s[si]):
+			si++
+			pi++
+		case pi < len(pattern) && pattern[pi] == '*':
+			star, mark = pi, si
+			pi++
+		case star >= 0:
+			mark++
+			si, pi = mark, star+1
+		default:
+			return false
+		}
+	}
+	for pi < len(pattern) && pattern[pi] == '*' {
+		pi++
+	}
+	return pi == len(pattern)
+}
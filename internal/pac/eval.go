@@ -0,0 +1,1158 @@
+package pac
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// value is a script value: nil (undefined or null), bool, float64, string,
+// *arrayValue, *objectValue, *regexValue, *closure or builtin
+type value interface{}
+
+// builtin is a function provided by the host
+type builtin func(args []value) value
+
+// closure is a function defined by the script
+type closure struct {
+	decl *funcDecl
+	env  *env
+}
+
+// arrayValue is a script array; arrays are shared by reference
+type arrayValue struct {
+	elems []value
+}
+
+// objectValue is a plain script object, keeping its keys in insertion
+// order for for-in loops
+type objectValue struct {
+	props map[string]value
+	keys  []string
+}
+
+func newObject() *objectValue {
+	return &objectValue{props: map[string]value{}}
+}
+
+func (o *objectValue) get(key string) value {
+	return o.props[key]
+}
+
+func (o *objectValue) set(key string, v value) {
+	if _, ok := o.props[key]; !ok {
+		o.keys = append(o.keys, key)
+	}
+	o.props[key] = v
+}
+
+// regexValue is a compiled regular expression; global is the g flag
+type regexValue struct {
+	re     *regexp.Regexp
+	source string
+	global bool
+}
+
+// newRegex compiles a JavaScript regular expression. Go's RE2 syntax covers
+// what PAC files use, except lookaround and backreferences.
+func newRegex(pattern, flags string) *regexValue {
+	prefix := ""
+	global := false
+	for _, flag := range flags {
+		switch flag {
+		case 'i', 'm', 's':
+			if !strings.ContainsRune(prefix, flag) {
+				prefix += string(flag)
+			}
+		case 'g':
+			global = true
+		case 'u', 'y':
+		default:
+			throw("invalid regular expression flag %q", flag)
+		}
+	}
+	source := pattern
+	if prefix != "" {
+		source = "(?" + prefix + ")" + pattern
+	}
+	re, err := regexp.Compile(source)
+	if err != nil {
+		throw("invalid regular expression /%s/: %v", pattern, err)
+	}
+	return &regexValue{re: re, source: pattern, global: global}
+}
+
+const (
+	// maxDepth bounds recursion, so a broken script cannot exhaust the stack
+	maxDepth = 200
+	// maxSteps bounds the loop iterations and function calls of one call,
+	// so a broken script cannot hang requests
+	maxSteps = 1000000
+	// maxRunTime bounds the running time of one call
+	maxRunTime = 2 * time.Second
+	// maxStringLen and maxArrayLen bound a single value, and maxAlloc the
+	// strings and arrays created by one call, so that a script such as
+	// "for (;;) s += s" cannot exhaust memory
+	maxStringLen = 1 << 20
+	maxArrayLen  = 1 << 16
+	maxAlloc     = 64 << 20
+	// elemSize is the cost charged for an array element
+	elemSize = 16
+)
+
+// scriptError carries an error out of the parser or evaluator
+type scriptError struct{ err error }
+
+// catch runs fn, returning the error it panicked with
+func catch(fn func()) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			se, ok := r.(scriptError)
+			if !ok {
+				panic(r)
+			}
+			err = se.err
+		}
+	}()
+	fn()
+	return nil
+}
+
+func throw(format string, args ...interface{}) {
+	panic(scriptError{fmt.Errorf(format, args...)})
+}
+
+// control is how a statement ended
+type control int
+
+const (
+	ctlNone control = iota
+	ctlReturn
+	ctlBreak
+	ctlContinue
+)
+
+// budget is what is left of the limits of the current call
+type budget struct {
+	steps    int
+	bytes    int
+	deadline time.Time
+}
+
+// reset restores the budget for a new call
+func (b *budget) reset() {
+	b.steps = maxSteps
+	b.bytes = maxAlloc
+	b.deadline = time.Now().Add(maxRunTime)
+}
+
+// env is a variable scope
+type env struct {
+	vars   map[string]value
+	parent *env
+	depth  int
+	// budget is shared by all scopes of a script
+	budget *budget
+}
+
+func newEnv(parent *env) *env {
+	e := &env{vars: map[string]value{}, parent: parent}
+	if parent != nil {
+		e.depth = parent.depth + 1
+		e.budget = parent.budget
+	} else {
+		e.budget = &budget{}
+		e.budget.reset()
+	}
+	return e
+}
+
+func (e *env) lookup(name string) (*env, bool) {
+	for s := e; s != nil; s = s.parent {
+		if _, ok := s.vars[name]; ok {
+			return s, true
+		}
+	}
+	return nil, false
+}
+
+func (e *env) get(name string) value {
+	s, ok := e.lookup(name)
+	if !ok {
+		throw("%s is not defined", name)
+	}
+	return s.vars[name]
+}
+
+// set assigns an existing variable, or creates a global as sloppy-mode
+// JavaScript does
+func (e *env) set(name string, v value) {
+	if s, ok := e.lookup(name); ok {
+		s.vars[name] = v
+		return
+	}
+	root := e
+	for root.parent != nil {
+		root = root.parent
+	}
+	root.vars[name] = v
+}
+
+// step uses up one loop iteration or call of the budget
+func (e *env) step() {
+	b := e.budget
+	if b.steps--; b.steps < 0 || (b.steps%1024 == 0 && time.Now().After(b.deadline)) {
+		throw("script ran too long")
+	}
+}
+
+// charge counts a newly created string or array against the budget and
+// returns it
+func (e *env) charge(v value) value {
+	var size int
+	switch x := v.(type) {
+	case string:
+		checkStringLen(len(x))
+		size = len(x)
+	case *arrayValue:
+		checkArrayLen(len(x.elems))
+		size = len(x.elems) * elemSize
+	default:
+		return v
+	}
+	if e.budget.bytes -= size; e.budget.bytes < 0 {
+		throw("script used too much memory")
+	}
+	return v
+}
+
+// checkStringLen throws if a string of length n would be too long
+func checkStringLen(n int) {
+	if n > maxStringLen {
+		throw("string longer than %d bytes", maxStringLen)
+	}
+}
+
+// checkArrayLen throws if an array of length n would be too long
+func checkArrayLen(n int) {
+	if n > maxArrayLen {
+		throw("array longer than %d elements", maxArrayLen)
+	}
+}
+
+// hoist declares the functions and variables of a body before it runs,
+// including those in nested blocks, as var and function declarations are
+// scoped to the enclosing function
+func hoist(body []node, e *env) {
+	for _, stmt := range body {
+		hoistStatement(stmt, e)
+	}
+}
+
+func hoistStatement(stmt node, e *env) {
+	switch s := stmt.(type) {
+	case *funcDecl:
+		e.vars[s.name] = &closure{decl: s, env: e}
+	case *varDecl:
+		for _, name := range s.names {
+			if _, ok := e.vars[name]; !ok {
+				e.vars[name] = nil
+			}
+		}
+	case *ifStmt:
+		hoistStatement(s.then, e)
+		if s.alt != nil {
+			hoistStatement(s.alt, e)
+		}
+	case *forStmt:
+		if s.init != nil {
+			hoistStatement(s.init, e)
+		}
+		hoistStatement(s.body, e)
+	case *forInStmt:
+		if _, ok := e.vars[s.name]; !ok {
+			e.vars[s.name] = nil
+		}
+		hoistStatement(s.body, e)
+	case *switchStmt:
+		for _, clause := range s.cases {
+			hoist(clause.body, e)
+		}
+	case *tryStmt:
+		hoist(s.body, e)
+		hoist(s.handler, e)
+		hoist(s.finalize, e)
+	case *blockStmt:
+		hoist(s.body, e)
+	}
+}
+
+// execBody runs statements until one ends the flow of control
+func execBody(body []node, e *env) (value, control) {
+	for _, stmt := range body {
+		if v, ctl := exec(stmt, e); ctl != ctlNone {
+			return v, ctl
+		}
+	}
+	return nil, ctlNone
+}
+
+// exec runs a statement, reporting whether it returned, broke out of or
+// continued a loop
+func exec(stmt node, e *env) (value, control) {
+	switch s := stmt.(type) {
+	case *funcDecl:
+		// Hoisted
+	case *varDecl:
+		for i, name := range s.names {
+			if s.inits[i] != nil {
+				e.vars[name] = eval(s.inits[i], e)
+			} else if _, ok := e.vars[name]; !ok {
+				e.vars[name] = nil
+			}
+		}
+	case *ifStmt:
+		if truthy(eval(s.cond, e)) {
+			return exec(s.then, e)
+		} else if s.alt != nil {
+			return exec(s.alt, e)
+		}
+	case *forStmt:
+		return execLoop(s, e)
+	case *forInStmt:
+		var keys []string
+		switch o := eval(s.object, e).(type) {
+		case *objectValue:
+			keys = append(keys, o.keys...)
+		case *arrayValue:
+			for i := range o.elems {
+				keys = append(keys, strconv.Itoa(i))
+			}
+		case string:
+			for i := range o {
+				keys = append(keys, strconv.Itoa(i))
+			}
+		}
+		for _, key := range keys {
+			e.step()
+			e.set(s.name, key)
+			v, ctl := exec(s.body, e)
+			if ctl == ctlReturn {
+				return v, ctl
+			}
+			if ctl == ctlBreak {
+				break
+			}
+		}
+	case *switchStmt:
+		disc := eval(s.disc, e)
+		matched := -1
+		for i, clause := range s.cases {
+			if clause.test != nil && strictEqual(disc, eval(clause.test, e)) {
+				matched = i
+				break
+			}
+		}
+		if matched < 0 {
+			for i, clause := range s.cases {
+				if clause.test == nil {
+					matched = i
+				}
+			}
+		}
+		if matched < 0 {
+			return nil, ctlNone
+		}
+		// Fall through the following clauses until a break
+		for _, clause := range s.cases[matched:] {
+			v, ctl := execBody(clause.body, e)
+			if ctl == ctlBreak {
+				return nil, ctlNone
+			}
+			if ctl != ctlNone {
+				return v, ctl
+			}
+		}
+	case *tryStmt:
+		return execTry(s, e)
+	case *returnStmt:
+		if s.value == nil {
+			return nil, ctlReturn
+		}
+		return eval(s.value, e), ctlReturn
+	case *breakStmt:
+		return nil, ctlBreak
+	case *continueStmt:
+		return nil, ctlContinue
+	case *throwStmt:
+		throw("uncaught exception: %s", toString(eval(s.value, e)))
+	case *blockStmt:
+		return execBody(s.body, e)
+	case *exprStmt:
+		eval(s.expr, e)
+	}
+	return nil, ctlNone
+}
+
+// execLoop runs a for, while or do-while loop
+func execLoop(s *forStmt, e *env) (value, control) {
+	if s.init != nil {
+		exec(s.init, e)
+	}
+	for first := true; ; first = false {
+		e.step()
+		if s.cond != nil && !(s.checkAfter && first) && !truthy(eval(s.cond, e)) {
+			return nil, ctlNone
+		}
+		v, ctl := exec(s.body, e)
+		if ctl == ctlReturn {
+			return v, ctl
+		}
+		if ctl == ctlBreak {
+			return nil, ctlNone
+		}
+		if s.post != nil {
+			eval(s.post, e)
+		}
+	}
+}
+
+// execTry runs a try statement. A caught error is passed to the handler as
+// its message.
+func execTry(s *tryStmt, e *env) (v value, ctl control) {
+	err := catch(func() {
+		v, ctl = execBody(s.body, e)
+	})
+	if err != nil && s.handler != nil {
+		if s.param != "" {
+			e.vars[s.param] = err.Error()
+		}
+		err = catch(func() {
+			v, ctl = execBody(s.handler, e)
+		})
+	}
+	if s.finalize != nil {
+		// Leaving the finally block early wins, as in JavaScript
+		if fv, fctl := execBody(s.finalize, e); fctl != ctlNone {
+			return fv, fctl
+		}
+	}
+	if err != nil {
+		panic(scriptError{err})
+	}
+	return v, ctl
+}
+
+func eval(expr node, e *env) value {
+	switch x := expr.(type) {
+	case *literal:
+		return x.value
+	case *regexLit:
+		return x.re
+	case *ident:
+		return e.get(x.name)
+	case *funcLit:
+		return &closure{decl: x.decl, env: e}
+	case *array:
+		elems := make([]value, len(x.elems))
+		for i, elem := range x.elems {
+			elems[i] = eval(elem, e)
+		}
+		return e.charge(&arrayValue{elems: elems})
+	case *object:
+		obj := newObject()
+		for i, key := range x.keys {
+			obj.set(key, eval(x.values[i], e))
+		}
+		return obj
+	case *assign:
+		v := eval(x.expr, e)
+		if x.op != "=" {
+			v = e.charge(binaryOp(strings.TrimSuffix(x.op, "="), eval(x.target, e), v))
+		}
+		assignTo(x.target, v, e)
+		return v
+	case *update:
+		old := toNumber(eval(x.target, e))
+		v := old + 1
+		if x.op == "--" {
+			v = old - 1
+		}
+		assignTo(x.target, v, e)
+		if x.prefix {
+			return v
+		}
+		return old
+	case *unary:
+		if x.op == "typeof" {
+			// typeof does not throw for undeclared variables
+			if id, ok := x.operand.(*ident); ok {
+				if _, declared := e.lookup(id.name); !declared {
+					return "undefined"
+				}
+			}
+			return typeOf(eval(x.operand, e))
+		}
+		v := eval(x.operand, e)
+		switch x.op {
+		case "!":
+			return !truthy(v)
+		case "-":
+			return -toNumber(v)
+		case "+":
+			return toNumber(v)
+		case "~":
+			return float64(^toInt32(v))
+		case "void":
+			return nil
+		}
+	case *logical:
+		left := eval(x.left, e)
+		if truthy(left) == (x.op == "||") {
+			return left
+		}
+		return eval(x.right, e)
+	case *conditional:
+		if truthy(eval(x.cond, e)) {
+			return eval(x.then, e)
+		}
+		return eval(x.alt, e)
+	case *binary:
+		return e.charge(binaryOp(x.op, eval(x.left, e), eval(x.right, e)))
+	case *member:
+		return property(eval(x.object, e), x.name)
+	case *index:
+		return property(eval(x.object, e), propertyKey(eval(x.key, e)))
+	case *call:
+		args := make([]value, len(x.args))
+		for i, arg := range x.args {
+			args[i] = eval(arg, e)
+		}
+		var v value
+		switch callee := x.callee.(type) {
+		case *member:
+			v = callMethod(eval(callee.object, e), callee.name, args, e.depth)
+		case *index:
+			v = callMethod(eval(callee.object, e), propertyKey(eval(callee.key, e)), args, e.depth)
+		default:
+			v = callValue(eval(x.callee, e), args, e.depth)
+		}
+		return e.charge(v)
+	}
+	throw("unsupported expression")
+	return nil
+}
+
+// assignTo stores v in a variable, object property or array element
+func assignTo(target node, v value, e *env) {
+	switch t := target.(type) {
+	case *ident:
+		e.set(t.name, v)
+	case *member:
+		setProperty(eval(t.object, e), t.name, v)
+	case *index:
+		setProperty(eval(t.object, e), propertyKey(eval(t.key, e)), v)
+	}
+}
+
+// propertyKey converts a value used as a property name to a string
+func propertyKey(key value) string {
+	return toString(key)
+}
+
+// arrayIndex parses an array index property name
+func arrayIndex(name string) (int, bool) {
+	i, err := strconv.Atoi(name)
+	return i, err == nil && i >= 0 && strconv.Itoa(i) == name
+}
+
+// setProperty sets a property of an object or an element of an array
+func setProperty(obj value, name string, v value) {
+	switch o := obj.(type) {
+	case *objectValue:
+		o.set(name, v)
+		return
+	case *arrayValue:
+		if i, ok := arrayIndex(name); ok {
+			checkArrayLen(i + 1)
+			for len(o.elems) <= i {
+				o.elems = append(o.elems, nil)
+			}
+			o.elems[i] = v
+			return
+		}
+		if name == "length" {
+			if n := int(toNumber(v)); n >= 0 && n <= len(o.elems) {
+				o.elems = o.elems[:n]
+			}
+			return
+		}
+	case nil:
+		throw("cannot set property %q of undefined", name)
+	}
+	// Properties of other values are silently dropped
+}
+
+// callValue calls a script or host function
+func callValue(fn value, args []value, depth int) value {
+	switch f := fn.(type) {
+	case builtin:
+		return f(args)
+	case *closure:
+		if depth > maxDepth {
+			throw("maximum call depth exceeded")
+		}
+		scope := newEnv(f.env)
+		scope.depth = depth + 1
+		scope.step()
+		if f.decl.name != "" {
+			// A named function expression can call itself
+			scope.vars[f.decl.name] = f
+		}
+		for i, param := range f.decl.params {
+			var v value
+			if i < len(args) {
+				v = args[i]
+			}
+			scope.vars[param] = v
+		}
+		hoist(f.decl.body, scope)
+		v, _ := execBody(f.decl.body, scope)
+		return v
+	}
+	throw("%s is not a function", typeOf(fn))
+	return nil
+}
+
+// property returns a property of a value
+func property(obj value, name string) value {
+	switch o := obj.(type) {
+	case string:
+		if name == "length" {
+			return float64(len(o))
+		}
+		if i, ok := arrayIndex(name); ok && i < len(o) {
+			return o[i : i+1]
+		}
+	case *arrayValue:
+		if name == "length" {
+			return float64(len(o.elems))
+		}
+		if i, ok := arrayIndex(name); ok && i < len(o.elems) {
+			return o.elems[i]
+		}
+	case *objectValue:
+		return o.get(name)
+	case *regexValue:
+		switch name {
+		case "source":
+			return o.source
+		case "global":
+			return o.global
+		}
+	case nil:
+		throw("cannot read property %q of undefined", name)
+	}
+	return nil
+}
+
+// callMethod calls a method of a value: a function stored in an object, or
+// one of the string, array and regular expression methods PAC files
+// commonly use
+func callMethod(obj value, name string, args []value, depth int) value {
+	arg := func(i int) value {
+		if i < len(args) {
+			return args[i]
+		}
+		return nil
+	}
+
+	switch o := obj.(type) {
+	case string:
+		return stringMethod(o, name, args)
+	case *arrayValue:
+		return arrayMethod(o, name, args)
+	case *regexValue:
+		switch name {
+		case "test":
+			return o.re.MatchString(toString(arg(0)))
+		case "exec":
+			return matchResult(o.re.FindStringSubmatch(toString(arg(0))))
+		case "toString":
+			return toString(o)
+		}
+	case *objectValue:
+		if fn, ok := o.props[name]; ok {
+			return callValue(fn, args, depth)
+		}
+		if name == "hasOwnProperty" {
+			_, ok := o.props[toString(arg(0))]
+			return ok
+		}
+	case nil:
+		throw("cannot read property %q of undefined", name)
+	}
+	throw("%s.%s is not a function", typeOf(obj), name)
+	return nil
+}
+
+// matchResult converts regexp submatches to the array exec and match
+// return, or null when there was no match
+func matchResult(groups []string) value {
+	if groups == nil {
+		return nil
+	}
+	out := make([]value, len(groups))
+	for i, group := range groups {
+		out[i] = group
+	}
+	return &arrayValue{elems: out}
+}
+
+func stringMethod(s, name string, args []value) value {
+	arg := func(i int) value {
+		if i < len(args) {
+			return args[i]
+		}
+		return nil
+	}
+	intArg := func(i, def int) int {
+		if i >= len(args) || args[i] == nil {
+			return def
+		}
+		n := toNumber(args[i])
+		if math.IsNaN(n) {
+			return 0
+		}
+		return int(n)
+	}
+	clamp := func(i int) int {
+		return max(0, min(i, len(s)))
+	}
+
+	switch name {
+	case "toLowerCase":
+		return strings.ToLower(s)
+	case "toUpperCase":
+		return strings.ToUpper(s)
+	case "toString", "valueOf":
+		return s
+	case "trim":
+		return strings.TrimSpace(s)
+	case "indexOf":
+		from := clamp(intArg(1, 0))
+		if i := strings.Index(s[from:], toString(arg(0))); i >= 0 {
+			return float64(from + i)
+		}
+		return float64(-1)
+	case "lastIndexOf":
+		return float64(strings.LastIndex(s, toString(arg(0))))
+	case "startsWith":
+		return strings.HasPrefix(s, toString(arg(0)))
+	case "endsWith":
+		return strings.HasSuffix(s, toString(arg(0)))
+	case "includes":
+		return strings.Contains(s, toString(arg(0)))
+	case "charAt":
+		if i := intArg(0, 0); i >= 0 && i < len(s) {
+			return s[i : i+1]
+		}
+		return ""
+	case "charCodeAt":
+		if i := intArg(0, 0); i >= 0 && i < len(s) {
+			return float64(s[i])
+		}
+		return math.NaN()
+	case "concat":
+		var b strings.Builder
+		b.WriteString(s)
+		for _, a := range args {
+			part := toString(a)
+			checkStringLen(b.Len() + len(part))
+			b.WriteString(part)
+		}
+		return b.String()
+	case "substring":
+		start, end := clamp(intArg(0, 0)), clamp(intArg(1, len(s)))
+		if start > end {
+			start, end = end, start
+		}
+		return s[start:end]
+	case "substr":
+		start := intArg(0, 0)
+		if start < 0 {
+			start += len(s)
+		}
+		start = clamp(start)
+		end := clamp(start + intArg(1, len(s)))
+		return s[start:max(start, end)]
+	case "slice":
+		start, end := intArg(0, 0), intArg(1, len(s))
+		if start < 0 {
+			start += len(s)
+		}
+		if end < 0 {
+			end += len(s)
+		}
+		start, end = clamp(start), clamp(end)
+		return s[start:max(start, end)]
+	case "split":
+		var parts []string
+		switch sep := arg(0).(type) {
+		case nil:
+			parts = []string{s}
+		case *regexValue:
+			parts = sep.re.Split(s, -1)
+		default:
+			parts = strings.Split(s, toString(sep))
+		}
+		out := make([]value, len(parts))
+		for i, part := range parts {
+			out[i] = part
+		}
+		return &arrayValue{elems: out}
+	case "replace":
+		re, ok := arg(0).(*regexValue)
+		if !ok {
+			return strings.Replace(s, toString(arg(0)), toString(arg(1)), 1)
+		}
+		replacement := toString(arg(1))
+		n := 1
+		if re.global {
+			n = -1
+		}
+		var out []byte
+		last := 0
+		for _, loc := range re.re.FindAllStringSubmatchIndex(s, n) {
+			out = append(out, s[last:loc[0]]...)
+			out = re.re.ExpandString(out, replacement, s, loc)
+			checkStringLen(len(out))
+			last = loc[1]
+		}
+		checkStringLen(len(out) + len(s) - last)
+		return string(append(out, s[last:]...))
+	case "match":
+		re, ok := arg(0).(*regexValue)
+		if !ok {
+			re = newRegex(regexp.QuoteMeta(toString(arg(0))), "")
+		}
+		if !re.global {
+			return matchResult(re.re.FindStringSubmatch(s))
+		}
+		matches := re.re.FindAllString(s, -1)
+		if matches == nil {
+			return nil
+		}
+		return matchResult(matches)
+	case "search":
+		re, ok := arg(0).(*regexValue)
+		if !ok {
+			re = newRegex(toString(arg(0)), "")
+		}
+		if loc := re.re.FindStringIndex(s); loc != nil {
+			return float64(loc[0])
+		}
+		return float64(-1)
+	}
+	throw("string.%s is not supported", name)
+	return nil
+}
+
+func arrayMethod(a *arrayValue, name string, args []value) value {
+	switch name {
+	case "indexOf":
+		for i, elem := range a.elems {
+			if len(args) > 0 && strictEqual(elem, args[0]) {
+				return float64(i)
+			}
+		}
+		return float64(-1)
+	case "includes":
+		for _, elem := range a.elems {
+			if len(args) > 0 && strictEqual(elem, args[0]) {
+				return true
+			}
+		}
+		return false
+	case "join":
+		sep := ","
+		if len(args) > 0 && args[0] != nil {
+			sep = toString(args[0])
+		}
+		return joinArray(a, sep, 0)
+	case "push":
+		checkArrayLen(len(a.elems) + len(args))
+		a.elems = append(a.elems, args...)
+		return float64(len(a.elems))
+	case "pop":
+		if len(a.elems) == 0 {
+			return nil
+		}
+		last := a.elems[len(a.elems)-1]
+		a.elems = a.elems[:len(a.elems)-1]
+		return last
+	case "shift":
+		if len(a.elems) == 0 {
+			return nil
+		}
+		first := a.elems[0]
+		a.elems = a.elems[1:]
+		return first
+	case "concat":
+		elems := append([]value{}, a.elems...)
+		for _, arg := range args {
+			if other, ok := arg.(*arrayValue); ok {
+				checkArrayLen(len(elems) + len(other.elems))
+				elems = append(elems, other.elems...)
+			} else {
+				checkArrayLen(len(elems) + 1)
+				elems = append(elems, arg)
+			}
+		}
+		return &arrayValue{elems: elems}
+	case "slice":
+		n := len(a.elems)
+		bound := func(i, def int) int {
+			if i >= len(args) || args[i] == nil {
+				return def
+			}
+			v := int(toNumber(args[i]))
+			if v < 0 {
+				v += n
+			}
+			return max(0, min(v, n))
+		}
+		start, end := bound(0, 0), bound(1, n)
+		return &arrayValue{elems: append([]value{}, a.elems[start:max(start, end)]...)}
+	case "toString":
+		return toString(a)
+	}
+	throw("array.%s is not supported", name)
+	return nil
+}
+
+func binaryOp(op string, left, right value) value {
+	switch op {
+	case ",":
+		return right
+	case "+":
+		return add(left, right)
+	case "-":
+		return toNumber(left) - toNumber(right)
+	case "*":
+		return toNumber(left) * toNumber(right)
+	case "/":
+		return toNumber(left) / toNumber(right)
+	case "%":
+		return math.Mod(toNumber(left), toNumber(right))
+	case "&":
+		return float64(toInt32(left) & toInt32(right))
+	case "|":
+		return float64(toInt32(left) | toInt32(right))
+	case "^":
+		return float64(toInt32(left) ^ toInt32(right))
+	case "<<":
+		return float64(toInt32(left) << (uint32(toInt32(right)) & 31))
+	case ">>":
+		return float64(toInt32(left) >> (uint32(toInt32(right)) & 31))
+	case ">>>":
+		return float64(uint32(toInt32(left)) >> (uint32(toInt32(right)) & 31))
+	case "===":
+		return strictEqual(left, right)
+	case "!==":
+		return !strictEqual(left, right)
+	case "==":
+		return looseEqual(left, right)
+	case "!=":
+		return !looseEqual(left, right)
+	case "in":
+		switch o := right.(type) {
+		case *objectValue:
+			_, ok := o.props[toString(left)]
+			return ok
+		case *arrayValue:
+			i, ok := arrayIndex(toString(left))
+			return ok && i < len(o.elems)
+		}
+		throw("cannot use 'in' operator on %s", typeOf(right))
+	case "<", ">", "<=", ">=":
+		ls, lok := left.(string)
+		rs, rok := right.(string)
+		var cmp int
+		if lok && rok {
+			cmp = strings.Compare(ls, rs)
+		} else {
+			l, r := toNumber(left), toNumber(right)
+			if math.IsNaN(l) || math.IsNaN(r) {
+				return false
+			}
+			switch {
+			case l < r:
+				cmp = -1
+			case l > r:
+				cmp = 1
+			}
+		}
+		switch op {
+		case "<":
+			return cmp < 0
+		case ">":
+			return cmp > 0
+		case "<=":
+			return cmp <= 0
+		default:
+			return cmp >= 0
+		}
+	}
+	throw("unsupported operator %s", op)
+	return nil
+}
+
+func add(left, right value) value {
+	_, ls := left.(string)
+	_, rs := right.(string)
+	if ls || rs {
+		l, r := toString(left), toString(right)
+		checkStringLen(len(l) + len(r))
+		return l + r
+	}
+	return toNumber(left) + toNumber(right)
+}
+
+func strictEqual(left, right value) bool {
+	switch left.(type) {
+	case nil, bool, float64, string, *arrayValue, *objectValue, *regexValue, *closure:
+		return left == right
+	}
+	return false
+}
+
+func looseEqual(left, right value) bool {
+	if left == nil || right == nil {
+		return left == nil && right == nil
+	}
+	_, lb := left.(bool)
+	_, rb := right.(bool)
+	_, ln := left.(float64)
+	_, rn := right.(float64)
+	if lb || rb || ln || rn {
+		return toNumber(left) == toNumber(right)
+	}
+	return strictEqual(left, right)
+}
+
+func truthy(v value) bool {
+	switch x := v.(type) {
+	case nil:
+		return false
+	case bool:
+		return x
+	case float64:
+		return x != 0 && !math.IsNaN(x)
+	case string:
+		return x != ""
+	}
+	return true
+}
+
+func toNumber(v value) float64 {
+	switch x := v.(type) {
+	case bool:
+		if x {
+			return 1
+		}
+		return 0
+	case float64:
+		return x
+	case string:
+		x = strings.TrimSpace(x)
+		if x == "" {
+			return 0
+		}
+		if n, err := parseNumber(x); err == nil {
+			return n
+		}
+	}
+	return math.NaN()
+}
+
+// toInt32 converts a value to a 32-bit integer for the bitwise operators
+func toInt32(v value) int32 {
+	n := toNumber(v)
+	if math.IsNaN(n) || math.IsInf(n, 0) {
+		return 0
+	}
+	return int32(uint32(int64(math.Trunc(math.Mod(n, 1<<32)))))
+}
+
+func toString(v value) string {
+	return stringify(v, 0)
+}
+
+// stringify converts v to a string; depth counts the arrays it is nested in
+func stringify(v value, depth int) string {
+	switch x := v.(type) {
+	case nil:
+		return "undefined"
+	case bool:
+		return strconv.FormatBool(x)
+	case float64:
+		if math.IsNaN(x) {
+			return "NaN"
+		}
+		if x == math.Trunc(x) && math.Abs(x) < 1e21 {
+			return strconv.FormatFloat(x, 'f', -1, 64)
+		}
+		return strconv.FormatFloat(x, 'g', -1, 64)
+	case string:
+		return x
+	case *arrayValue:
+		return joinArray(x, ",", depth+1)
+	case *objectValue:
+		return "[object Object]"
+	case *regexValue:
+		return "/" + x.source + "/"
+	}
+	return "function"
+}
+
+// joinArray joins the elements of a with sep, as Array.prototype.join does.
+// It throws rather than build a string that is too long or recurse into an
+// array that contains itself.
+func joinArray(a *arrayValue, sep string, depth int) string {
+	if depth > maxDepth {
+		throw("array nested too deeply")
+	}
+	var b strings.Builder
+	for i, elem := range a.elems {
+		if i > 0 {
+			checkStringLen(b.Len() + len(sep))
+			b.WriteString(sep)
+		}
+		if elem != nil {
+			part := stringify(elem, depth)
+			checkStringLen(b.Len() + len(part))
+			b.WriteString(part)
+		}
+	}
+	return b.String()
+}
+
+func typeOf(v value) string {
+	switch v.(type) {
+	case nil:
+		return "undefined"
+	case bool:
+		return "boolean"
+	case float64:
+		return "number"
+	case string:
+		return "string"
+	case *arrayValue, *objectValue, *regexValue:
+		return "object"
+	}
+	return "function"
+}
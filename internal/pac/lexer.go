@@ -0,0 +1,236 @@
+package pac
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokIdent
+	tokNumber
+	tokString
+	tokPunct
+	// tokRegex is a regular expression literal; text holds the pattern and
+	// flags the flags
+	tokRegex
+)
+
+// token is one lexical token of a script
+type token struct {
+	kind  tokenKind
+	text  string
+	num   float64
+	flags string
+	// newline is set when a line break precedes the token, which ends a
+	// statement that has no semicolon
+	newline bool
+	pos     int
+}
+
+// punctuators are matched longest first
+var punctuators = []string{
+	">>>=",
+	"===", "!==", ">>>", "<<=", ">>=",
+	"==", "!=", "<=", ">=", "&&", "||", "+=", "-=", "*=", "/=", "%=", "&=", "|=", "^=", "++", "--", "<<", ">>",
+	"(", ")", "{", "}", "[", "]", ";", ",", ".", "?", ":", "=", "+", "-", "*", "/", "%", "<", ">", "!", "~", "&", "|", "^",
+}
+
+// regexKeywords are the keywords after which a slash starts a regular
+// expression rather than a division
+var regexKeywords = map[string]bool{
+	"return": true, "typeof": true, "case": true, "in": true, "else": true,
+	"do": true, "void": true, "delete": true, "new": true, "throw": true,
+}
+
+// regexAllowed reports whether a slash after prev starts a regular
+// expression: it does unless prev ends an operand
+func regexAllowed(tokens []token) bool {
+	if len(tokens) == 0 {
+		return true
+	}
+	prev := tokens[len(tokens)-1]
+	switch prev.kind {
+	case tokNumber, tokString, tokRegex:
+		return false
+	case tokIdent:
+		return regexKeywords[prev.text]
+	}
+	return prev.text != ")" && prev.text != "]"
+}
+
+// lex splits src into tokens
+func lex(src string) ([]token, error) {
+	var tokens []token
+	newline := false
+	for i := 0; i < len(src); {
+		c := src[i]
+		switch {
+		case c == '\n':
+			newline = true
+			i++
+		case c == ' ' || c == '\t' || c == '\r' || c == '\f' || c == '\v':
+			i++
+		case strings.HasPrefix(src[i:], "//"):
+			for i < len(src) && src[i] != '\n' {
+				i++
+			}
+		case strings.HasPrefix(src[i:], "/*"):
+			end := strings.Index(src[i+2:], "*/")
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated comment at offset %d", i)
+			}
+			if strings.Contains(src[i:i+2+end], "\n") {
+				newline = true
+			}
+			i += end + 4
+		case isIdentStart(c):
+			start := i
+			for i < len(src) && (isIdentStart(src[i]) || isDigit(src[i])) {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokIdent, text: src[start:i], newline: newline, pos: start})
+			newline = false
+		case isDigit(c) || (c == '.' && i+1 < len(src) && isDigit(src[i+1])):
+			start := i
+			for i < len(src) && (isDigit(src[i]) || src[i] == '.' || src[i] == 'x' || src[i] == 'X' || isHexLetter(src[i])) {
+				i++
+			}
+			num, err := parseNumber(src[start:i])
+			if err != nil {
+				return nil, fmt.Errorf("invalid number %q at offset %d", src[start:i], start)
+			}
+			tokens = append(tokens, token{kind: tokNumber, num: num, newline: newline, pos: start})
+			newline = false
+		case c == '/' && regexAllowed(tokens):
+			pattern, flags, n, err := lexRegex(src[i:])
+			if err != nil {
+				return nil, fmt.Errorf("%w at offset %d", err, i)
+			}
+			tokens = append(tokens, token{kind: tokRegex, text: pattern, flags: flags, newline: newline, pos: i})
+			newline = false
+			i += n
+		case c == '"' || c == '\'':
+			s, n, err := lexString(src[i:])
+			if err != nil {
+				return nil, fmt.Errorf("%w at offset %d", err, i)
+			}
+			tokens = append(tokens, token{kind: tokString, text: s, newline: newline, pos: i})
+			newline = false
+			i += n
+		default:
+			matched := ""
+			for _, p := range punctuators {
+				if strings.HasPrefix(src[i:], p) {
+					matched = p
+					break
+				}
+			}
+			if matched == "" {
+				return nil, fmt.Errorf("unexpected character %q at offset %d", c, i)
+			}
+			tokens = append(tokens, token{kind: tokPunct, text: matched, newline: newline, pos: i})
+			newline = false
+			i += len(matched)
+		}
+	}
+	return append(tokens, token{kind: tokEOF, newline: true, pos: len(src)}), nil
+}
+
+// lexString reads a quoted string literal, returning its value and length
+func lexString(src string) (string, int, error) {
+	quote := src[0]
+	var b strings.Builder
+	for i := 1; i < len(src); i++ {
+		c := src[i]
+		switch {
+		case c == quote:
+			return b.String(), i + 1, nil
+		case c == '\n':
+			return "", 0, fmt.Errorf("unterminated string")
+		case c == '\\' && i+1 < len(src):
+			i++
+			switch src[i] {
+			case 'n':
+				b.WriteByte('\n')
+			case 't':
+				b.WriteByte('\t')
+			case 'r':
+				b.WriteByte('\r')
+			case 'u':
+				if i+4 < len(src) {
+					if v, err := strconv.ParseUint(src[i+1:i+5], 16, 16); err == nil {
+						b.WriteRune(rune(v))
+						i += 4
+						continue
+					}
+				}
+				b.WriteByte('u')
+			case 'x':
+				if i+2 < len(src) {
+					if v, err := strconv.ParseUint(src[i+1:i+3], 16, 8); err == nil {
+						b.WriteByte(byte(v))
+						i += 2
+						continue
+					}
+				}
+				b.WriteByte('x')
+			case '\n':
+			default:
+				b.WriteByte(src[i])
+			}
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return "", 0, fmt.Errorf("unterminated string")
+}
+
+// lexRegex reads a regular expression literal, returning its pattern, flags
+// and length
+func lexRegex(src string) (string, string, int, error) {
+	inClass := false
+	for i := 1; i < len(src); i++ {
+		switch c := src[i]; {
+		case c == '\\' && i+1 < len(src):
+			i++
+		case c == '\n':
+			return "", "", 0, fmt.Errorf("unterminated regular expression")
+		case c == '[':
+			inClass = true
+		case c == ']':
+			inClass = false
+		case c == '/' && !inClass:
+			end := i + 1
+			for end < len(src) && isIdentStart(src[end]) {
+				end++
+			}
+			return src[1:i], src[i+1 : end], end, nil
+		}
+	}
+	return "", "", 0, fmt.Errorf("unterminated regular expression")
+}
+
+// parseNumber parses a decimal or hexadecimal number literal
+func parseNumber(s string) (float64, error) {
+	if strings.HasPrefix(s, "0x") || strings.HasPrefix(s, "0X") {
+		v, err := strconv.ParseUint(s[2:], 16, 64)
+		return float64(v), err
+	}
+	return strconv.ParseFloat(s, 64)
+}
+
+func isIdentStart(c byte) bool {
+	return c == '_' || c == '$' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isHexLetter(c byte) bool {
+	return (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F')
+}
@@ -0,0 +1,158 @@
+// Package pac evaluates proxy auto-config files. It implements the subset
+// of JavaScript that PAC files are written in (functions, variables, loops,
+// switch, try, arrays, objects, regular expressions and string methods, but
+// no prototypes or Date) along with the standard PAC helper functions.
+package pac
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+)
+
+// maxScriptSize bounds a downloaded PAC file
+const maxScriptSize = 1 << 20
+
+// Script is a compiled PAC file
+type Script struct {
+	// mu serializes calls, since a script may change its globals
+	mu      sync.Mutex
+	globals *env
+	find    value
+}
+
+// Compile parses a PAC file and runs its top level
+func Compile(src string) (*Script, error) {
+	program, err := parse(src)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Script{globals: newEnv(nil)}
+	for name, fn := range builtins() {
+		s.globals.vars[name] = fn
+	}
+	hoist(program, s.globals)
+	if err := catch(func() {
+		for _, stmt := range program {
+			exec(stmt, s.globals)
+		}
+	}); err != nil {
+		return nil, err
+	}
+
+	find, ok := s.globals.vars["FindProxyForURL"]
+	if _, isFunc := find.(*closure); !ok || !isFunc {
+		return nil, fmt.Errorf("PAC file does not define FindProxyForURL")
+	}
+	s.find = find
+	return s, nil
+}
+
+// Fetch downloads and compiles the PAC file at rawURL, which may be an
+// http, https or file URL. It is fetched directly, without any proxy.
+func Fetch(ctx context.Context, rawURL string) (*Script, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid PAC URL: %w", err)
+	}
+
+	var src []byte
+	switch u.Scheme {
+	case "file":
+		src, err = os.ReadFile(u.Path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read PAC file: %w", err)
+		}
+	case "http", "https":
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+		if err != nil {
+			return nil, err
+		}
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.Proxy = nil
+		resp, err := (&http.Client{Transport: transport}).Do(req)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch PAC file: %w", err)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("failed to fetch PAC file: %s", resp.Status)
+		}
+		src, err = io.ReadAll(io.LimitReader(resp.Body, maxScriptSize))
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch PAC file: %w", err)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported PAC URL scheme: %q", u.Scheme)
+	}
+
+	script, err := Compile(string(src))
+	if err != nil {
+		return nil, fmt.Errorf("invalid PAC file: %w", err)
+	}
+	return script, nil
+}
+
+// FindProxyForURL calls the script's FindProxyForURL and returns its result,
+// such as "PROXY proxy.example.com:8080; DIRECT"
+func (s *Script) FindProxyForURL(u *url.URL) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Browsers strip the path and query of https URLs, which the proxy
+	// cannot see either
+	target := *u
+	target.User = nil
+	if target.Scheme == "https" {
+		target.Path, target.RawPath, target.RawQuery, target.Fragment = "/", "", "", ""
+	}
+
+	s.globals.budget.reset()
+	var result value
+	err := catch(func() {
+		result = callValue(s.find, []value{target.String(), strings.ToLower(u.Hostname())}, 0)
+	})
+	if err != nil {
+		return "", fmt.Errorf("FindProxyForURL failed: %w", err)
+	}
+	if result == nil {
+		return "", fmt.Errorf("FindProxyForURL returned undefined")
+	}
+	return toString(result), nil
+}
+
+// Proxy returns the first usable proxy of a FindProxyForURL result as a
+// URL, or nil for DIRECT
+func Proxy(result string) (*url.URL, error) {
+	for _, entry := range strings.Split(result, ";") {
+		fields := strings.Fields(entry)
+		if len(fields) == 0 {
+			continue
+		}
+
+		var scheme string
+		switch strings.ToUpper(fields[0]) {
+		case "DIRECT":
+			return nil, nil
+		case "PROXY", "HTTP":
+			scheme = "http"
+		case "HTTPS":
+			scheme = "https"
+		case "SOCKS", "SOCKS5":
+			scheme = "socks5"
+		default:
+			continue
+		}
+		if len(fields) < 2 {
+			continue
+		}
+		return &url.URL{Scheme: scheme, Host: fields[1]}, nil
+	}
+	return nil, fmt.Errorf("no supported proxy in PAC result %q", result)
+}
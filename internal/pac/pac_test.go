@@ -0,0 +1,180 @@
+package pac
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+)
+
+// corporatePAC is a typical enterprise PAC file
+const corporatePAC = `
+function FindProxyForURL(url, host) {
+	// Plain host names and the intranet go direct
+	if (isPlainHostName(host) ||
+		dnsDomainIs(host, ".corp.example.com") ||
+		localHostOrDomainIs(host, "intranet.example.com"))
+		return "DIRECT";
+
+	if (isInNet(host, "10.0.0.0", "255.0.0.0") ||
+		isInNet(host, "192.168.0.0", "255.255.0.0"))
+		return "DIRECT";
+
+	if (shExpMatch(url, "http://*.example.org/downloads/*"))
+		return "PROXY downloads.example.com:3128";
+
+	if (url.substring(0, 6) == "https:")
+		return "PROXY secure.example.com:8443; DIRECT";
+
+	return "PROXY proxy.example.com:8080; SOCKS socks.example.com:1080";
+}
+`
+
+// listPAC picks a proxy from tables using loops, regexes and objects
+const listPAC = `
+var bypass = ["*.local", "*.internal.example.net", "updates.example.com"];
+var regional = { de: "PROXY de.example.com:80", fr: "PROXY fr.example.com:80" };
+var tld = /\.([a-z]+)$/;
+
+function matchesAny(host, patterns) {
+	for (var i = 0; i < patterns.length; i++) {
+		if (shExpMatch(host, patterns[i]))
+			return true;
+	}
+	return false;
+}
+
+function FindProxyForURL(url, host) {
+	if (matchesAny(host, bypass))
+		return "DIRECT";
+
+	var m = tld.exec(host);
+	if (m && regional[m[1]])
+		return regional[m[1]];
+
+	var parts = host.split(".");
+	var n = 0;
+	while (n < parts.length && parts[n] != "cdn") n++;
+	if (n < parts.length)
+		return "PROXY cdn-proxy.example.com:80";
+
+	switch (host.replace(/^www\./, "")) {
+	case "example.com":
+	case "example.net":
+		return "DIRECT";
+	default:
+		return "PROXY proxy.example.com:8080";
+	}
+}
+`
+
+func TestFindProxyForURL(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		url  string
+		want string
+	}{
+		{"plain host", corporatePAC, "http://wiki/", "DIRECT"},
+		{"domain suffix", corporatePAC, "http://build.corp.example.com/", "DIRECT"},
+		{"local host or domain", corporatePAC, "http://intranet/", "DIRECT"},
+		{"private network", corporatePAC, "http://10.1.2.3/", "DIRECT"},
+		{"outside network", corporatePAC, "http://172.16.0.1/", "PROXY proxy.example.com:8080; SOCKS socks.example.com:1080"},
+		{"shell expression", corporatePAC, "http://files.example.org/downloads/a.iso", "PROXY downloads.example.com:3128"},
+		{"https strips path", corporatePAC, "https://files.example.org/downloads/a.iso", "PROXY secure.example.com:8443; DIRECT"},
+		{"bypass list", listPAC, "http://printer.local/", "DIRECT"},
+		{"bypass exact", listPAC, "http://updates.example.com/", "DIRECT"},
+		{"regex and object", listPAC, "http://shop.de/", "PROXY de.example.com:80"},
+		{"while loop", listPAC, "http://img.cdn.example.io/", "PROXY cdn-proxy.example.com:80"},
+		{"switch fallthrough", listPAC, "http://www.example.net/", "DIRECT"},
+		{"switch default", listPAC, "http://example.io/", "PROXY proxy.example.com:8080"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			script, err := Compile(tt.src)
+			if err != nil {
+				t.Fatalf("Compile: %v", err)
+			}
+			u, err := url.Parse(tt.url)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := script.FindProxyForURL(u)
+			if err != nil {
+				t.Fatalf("FindProxyForURL: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("FindProxyForURL(%s) = %q, want %q", tt.url, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFindProxyForURLLimits(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		err  string
+	}{
+		{"infinite loop", `for (;;) {}`, "ran too long"},
+		{"unbounded recursion", `function f() { return f(); } return f();`, "call depth"},
+		{"exponential recursion", `function f(n) { return n == 0 ? 0 : f(n - 1) + f(n - 1); } return f(100);`, "ran too long"},
+		{"doubling string", `var s = "x"; for (;;) s += s;`, "string longer"},
+		{"many strings", `var a = []; for (var i = 0; ; i++) a.push(new Array(60000).join("x") + i);`, "array longer|too much memory"},
+		{"growing array", `var a = []; for (;;) a.push(1);`, "array longer"},
+		{"sparse array", `var a = []; a[100000000] = 1;`, "array longer"},
+		{"join", `var a = []; a[60000] = 1; var s = a.join(new Array(1000).join("x"));`, "string longer"},
+		{"replace", `var s = new Array(60000).join("x"); return s.replace(/x/g, s);`, "string longer"},
+		{"self-containing array", `var a = []; a.push(a); return "" + a;`, "nested too deeply"},
+		{"throw", `throw "blocked";`, "blocked"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			script, err := Compile("function FindProxyForURL(url, host) {" + tt.body + "}")
+			if err != nil {
+				t.Fatalf("Compile: %v", err)
+			}
+			_, err = script.FindProxyForURL(&url.URL{Scheme: "http", Host: "example.com", Path: "/"})
+			if err == nil {
+				t.Fatal("FindProxyForURL succeeded")
+			}
+			matched := false
+			for _, want := range strings.Split(tt.err, "|") {
+				matched = matched || strings.Contains(err.Error(), want)
+			}
+			if !matched {
+				t.Errorf("error %q does not mention %q", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestProxy(t *testing.T) {
+	tests := []struct {
+		result string
+		want   string
+	}{
+		{"DIRECT", ""},
+		{"PROXY proxy.example.com:8080; DIRECT", "http://proxy.example.com:8080"},
+		{"HTTPS secure.example.com:443", "https://secure.example.com:443"},
+		{"SOCKS5 socks.example.com:1080", "socks5://socks.example.com:1080"},
+		{"QUIC q.example.com:443; SOCKS socks.example.com:1080", "socks5://socks.example.com:1080"},
+	}
+	for _, tt := range tests {
+		u, err := Proxy(tt.result)
+		if err != nil {
+			t.Errorf("Proxy(%q): %v", tt.result, err)
+			continue
+		}
+		got := ""
+		if u != nil {
+			got = u.String()
+		}
+		if got != tt.want {
+			t.Errorf("Proxy(%q) = %q, want %q", tt.result, got, tt.want)
+		}
+	}
+
+	if _, err := Proxy("QUIC q.example.com:443"); err == nil {
+		t.Error("Proxy accepted a result without a supported proxy")
+	}
+}
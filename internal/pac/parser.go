@@ -0,0 +1,608 @@
+package pac
+
+import "fmt"
+
+// node is a statement or expression of a parsed script
+type node interface{}
+
+type (
+	funcDecl struct {
+		name   string
+		params []string
+		body   []node
+	}
+	varDecl struct {
+		names []string
+		inits []node
+	}
+	ifStmt struct {
+		cond      node
+		then, alt node
+	}
+	// forStmt is a for or while loop; post runs after each iteration and
+	// a do-while loop checks cond after the body
+	forStmt struct {
+		init, cond, post node
+		body             node
+		checkAfter       bool
+	}
+	forInStmt struct {
+		name   string
+		object node
+		body   node
+	}
+	switchStmt struct {
+		disc  node
+		cases []switchCase
+	}
+	// switchCase is a case clause; test is nil for the default clause
+	switchCase struct {
+		test node
+		body []node
+	}
+	tryStmt struct {
+		body     []node
+		param    string
+		handler  []node
+		finalize []node
+	}
+	returnStmt   struct{ value node }
+	breakStmt    struct{}
+	continueStmt struct{}
+	throwStmt    struct{ value node }
+	blockStmt    struct{ body []node }
+	exprStmt     struct{ expr node }
+
+	literal struct{ value value }
+	// regexLit is compiled while parsing, so a bad pattern fails the script
+	regexLit struct{ re *regexValue }
+	ident    struct{ name string }
+	assign   struct {
+		target node
+		op     string
+		expr   node
+	}
+	// update is ++ or --; prefix returns the new value
+	update struct {
+		op     string
+		target node
+		prefix bool
+	}
+	unary struct {
+		op      string
+		operand node
+	}
+	binary struct {
+		op          string
+		left, right node
+	}
+	logical struct {
+		op          string
+		left, right node
+	}
+	conditional struct{ cond, then, alt node }
+	call        struct {
+		callee node
+		args   []node
+	}
+	member struct {
+		object node
+		name   string
+	}
+	index  struct{ object, key node }
+	array  struct{ elems []node }
+	object struct {
+		keys   []string
+		values []node
+	}
+	funcLit struct{ decl *funcDecl }
+)
+
+// parser builds the syntax tree of a script from its tokens
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+// parse parses a whole script
+func parse(src string) ([]node, error) {
+	tokens, err := lex(src)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens}
+
+	var program []node
+	err = catch(func() {
+		for p.peek().kind != tokEOF {
+			program = append(program, p.statement())
+		}
+	})
+	return program, err
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+// peekAt returns the token n places ahead
+func (p *parser) peekAt(n int) token {
+	if p.pos+n >= len(p.tokens) {
+		return p.tokens[len(p.tokens)-1]
+	}
+	return p.tokens[p.pos+n]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokEOF {
+		p.pos++
+	}
+	return t
+}
+
+// back returns t, the last token read, to the input
+func (p *parser) back(t token) {
+	if t.kind != tokEOF {
+		p.pos--
+	}
+}
+
+// is reports whether the next token is the punctuator or keyword s
+func (p *parser) is(s string) bool {
+	t := p.peek()
+	return (t.kind == tokPunct || t.kind == tokIdent) && t.text == s
+}
+
+// accept consumes the next token if it is s
+func (p *parser) accept(s string) bool {
+	if p.is(s) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *parser) expect(s string) {
+	if !p.accept(s) {
+		p.fail("expected %q", s)
+	}
+}
+
+func (p *parser) identifier() string {
+	t := p.next()
+	if t.kind != tokIdent {
+		p.back(t)
+		p.fail("expected identifier")
+	}
+	return t.text
+}
+
+func (p *parser) fail(format string, args ...interface{}) {
+	t := p.peek()
+	panic(scriptError{fmt.Errorf("syntax error at offset %d: %s", t.pos, fmt.Sprintf(format, args...))})
+}
+
+// endStatement consumes a semicolon, or accepts a line break or closing
+// brace in its place
+func (p *parser) endStatement() {
+	if p.accept(";") {
+		return
+	}
+	if t := p.peek(); t.newline || p.is("}") {
+		return
+	}
+	p.fail("expected \";\"")
+}
+
+// block parses the statements of a braced block
+func (p *parser) block() []node {
+	p.expect("{")
+	var body []node
+	for !p.accept("}") {
+		if p.peek().kind == tokEOF {
+			p.fail("expected \"}\"")
+		}
+		body = append(body, p.statement())
+	}
+	return body
+}
+
+func (p *parser) statement() node {
+	switch {
+	case p.is("function") && p.peekAt(1).kind == tokIdent:
+		p.next()
+		return p.function(true)
+	case p.accept("var"), p.accept("let"), p.accept("const"):
+		decl := p.varDecl()
+		p.endStatement()
+		return decl
+	case p.accept("if"):
+		p.expect("(")
+		stmt := &ifStmt{cond: p.expression()}
+		p.expect(")")
+		stmt.then = p.statement()
+		if p.accept("else") {
+			stmt.alt = p.statement()
+		}
+		return stmt
+	case p.accept("for"):
+		return p.forStatement()
+	case p.accept("while"):
+		p.expect("(")
+		stmt := &forStmt{cond: p.expression()}
+		p.expect(")")
+		stmt.body = p.statement()
+		return stmt
+	case p.accept("do"):
+		stmt := &forStmt{body: p.statement(), checkAfter: true}
+		p.expect("while")
+		p.expect("(")
+		stmt.cond = p.expression()
+		p.expect(")")
+		p.accept(";")
+		return stmt
+	case p.accept("switch"):
+		return p.switchStatement()
+	case p.accept("try"):
+		stmt := &tryStmt{body: p.block()}
+		if p.accept("catch") {
+			if p.accept("(") {
+				stmt.param = p.identifier()
+				p.expect(")")
+			}
+			stmt.handler = p.block()
+			if stmt.handler == nil {
+				stmt.handler = []node{}
+			}
+		}
+		if p.accept("finally") {
+			stmt.finalize = p.block()
+			if stmt.finalize == nil {
+				stmt.finalize = []node{}
+			}
+		}
+		if stmt.handler == nil && stmt.finalize == nil {
+			p.fail("expected \"catch\" or \"finally\"")
+		}
+		return stmt
+	case p.is("return"):
+		p.next()
+		stmt := &returnStmt{}
+		if t := p.peek(); !t.newline && !p.is(";") && !p.is("}") {
+			stmt.value = p.expression()
+		}
+		p.endStatement()
+		return stmt
+	case p.accept("break"):
+		p.endStatement()
+		return &breakStmt{}
+	case p.accept("continue"):
+		p.endStatement()
+		return &continueStmt{}
+	case p.accept("throw"):
+		stmt := &throwStmt{value: p.expression()}
+		p.endStatement()
+		return stmt
+	case p.is("{"):
+		return &blockStmt{body: p.block()}
+	case p.accept(";"):
+		return &blockStmt{}
+	}
+	stmt := &exprStmt{expr: p.expression()}
+	p.endStatement()
+	return stmt
+}
+
+// varDecl parses the declarations after var, let or const
+func (p *parser) varDecl() *varDecl {
+	decl := &varDecl{}
+	for {
+		decl.names = append(decl.names, p.identifier())
+		var init node
+		if p.accept("=") {
+			init = p.assignment()
+		}
+		decl.inits = append(decl.inits, init)
+		if !p.accept(",") {
+			return decl
+		}
+	}
+}
+
+// forStatement parses a for or for-in loop after the for keyword
+func (p *parser) forStatement() node {
+	p.expect("(")
+
+	var init node
+	switch {
+	case p.accept("var"), p.accept("let"), p.accept("const"):
+		if p.peekAt(1).kind == tokIdent && p.peekAt(1).text == "in" {
+			return p.forIn()
+		}
+		init = p.varDecl()
+	case p.peek().kind == tokIdent && p.peekAt(1).kind == tokIdent && p.peekAt(1).text == "in":
+		return p.forIn()
+	case !p.is(";"):
+		init = &exprStmt{expr: p.expression()}
+	}
+
+	stmt := &forStmt{init: init}
+	p.expect(";")
+	if !p.is(";") {
+		stmt.cond = p.expression()
+	}
+	p.expect(";")
+	if !p.is(")") {
+		stmt.post = p.expression()
+	}
+	p.expect(")")
+	stmt.body = p.statement()
+	return stmt
+}
+
+// forIn parses the rest of a for-in loop from its variable name
+func (p *parser) forIn() node {
+	stmt := &forInStmt{name: p.identifier()}
+	p.expect("in")
+	stmt.object = p.expression()
+	p.expect(")")
+	stmt.body = p.statement()
+	return stmt
+}
+
+func (p *parser) switchStatement() node {
+	p.expect("(")
+	stmt := &switchStmt{disc: p.expression()}
+	p.expect(")")
+	p.expect("{")
+	for !p.accept("}") {
+		var clause switchCase
+		switch {
+		case p.accept("case"):
+			clause.test = p.expression()
+		case p.accept("default"):
+		default:
+			p.fail("expected \"case\" or \"default\"")
+		}
+		p.expect(":")
+		for !p.is("case") && !p.is("default") && !p.is("}") {
+			if p.peek().kind == tokEOF {
+				p.fail("expected \"}\"")
+			}
+			clause.body = append(clause.body, p.statement())
+		}
+		stmt.cases = append(stmt.cases, clause)
+	}
+	return stmt
+}
+
+// function parses a function after the function keyword; a declaration
+// requires a name
+func (p *parser) function(named bool) *funcDecl {
+	fn := &funcDecl{}
+	if named || p.peek().kind == tokIdent {
+		fn.name = p.identifier()
+	}
+	p.expect("(")
+	for !p.accept(")") {
+		if len(fn.params) > 0 {
+			p.expect(",")
+		}
+		fn.params = append(fn.params, p.identifier())
+	}
+	fn.body = p.block()
+	return fn
+}
+
+func (p *parser) expression() node {
+	expr := p.assignment()
+	for p.accept(",") {
+		expr = &binary{op: ",", left: expr, right: p.assignment()}
+	}
+	return expr
+}
+
+// assignOps are the assignment operators
+var assignOps = []string{"=", "+=", "-=", "*=", "/=", "%=", "&=", "|=", "^=", "<<=", ">>=", ">>>="}
+
+func (p *parser) assignment() node {
+	expr := p.conditional()
+	for _, op := range assignOps {
+		if p.is(op) {
+			p.checkTarget(expr)
+			p.next()
+			return &assign{target: expr, op: op, expr: p.assignment()}
+		}
+	}
+	return expr
+}
+
+// checkTarget fails unless expr can be assigned to
+func (p *parser) checkTarget(expr node) {
+	switch expr.(type) {
+	case *ident, *member, *index:
+	default:
+		p.fail("invalid assignment target")
+	}
+}
+
+func (p *parser) conditional() node {
+	cond := p.logical("||")
+	if !p.accept("?") {
+		return cond
+	}
+	then := p.assignment()
+	p.expect(":")
+	return &conditional{cond: cond, then: then, alt: p.assignment()}
+}
+
+func (p *parser) logical(op string) node {
+	operand := func() node {
+		if op == "||" {
+			return p.logical("&&")
+		}
+		return p.binary(0)
+	}
+	expr := operand()
+	for p.accept(op) {
+		expr = &logical{op: op, left: expr, right: operand()}
+	}
+	return expr
+}
+
+// precedence lists binary operators from the loosest binding
+var precedence = [][]string{
+	{"|"},
+	{"^"},
+	{"&"},
+	{"===", "!==", "==", "!="},
+	{"<=", ">=", "<", ">", "in"},
+	{"<<", ">>>", ">>"},
+	{"+", "-"},
+	{"*", "/", "%"},
+}
+
+func (p *parser) binary(level int) node {
+	if level == len(precedence) {
+		return p.unary()
+	}
+	expr := p.binary(level + 1)
+	for {
+		op := ""
+		for _, candidate := range precedence[level] {
+			if p.is(candidate) {
+				op = candidate
+				break
+			}
+		}
+		if op == "" {
+			return expr
+		}
+		p.next()
+		expr = &binary{op: op, left: expr, right: p.binary(level + 1)}
+	}
+}
+
+func (p *parser) unary() node {
+	for _, op := range []string{"++", "--"} {
+		if p.accept(op) {
+			target := p.unary()
+			p.checkTarget(target)
+			return &update{op: op, target: target, prefix: true}
+		}
+	}
+	for _, op := range []string{"!", "-", "+", "~", "typeof", "void"} {
+		if p.accept(op) {
+			return &unary{op: op, operand: p.unary()}
+		}
+	}
+	// Constructors are called as plain functions
+	p.accept("new")
+
+	expr := p.postfix()
+	if t := p.peek(); !t.newline && (p.is("++") || p.is("--")) {
+		p.checkTarget(expr)
+		p.next()
+		return &update{op: t.text, target: expr}
+	}
+	return expr
+}
+
+func (p *parser) postfix() node {
+	expr := p.primary()
+	for {
+		switch {
+		case p.accept("("):
+			c := &call{callee: expr}
+			for !p.accept(")") {
+				if len(c.args) > 0 {
+					p.expect(",")
+				}
+				c.args = append(c.args, p.assignment())
+			}
+			expr = c
+		case p.accept("."):
+			expr = &member{object: expr, name: p.identifier()}
+		case p.accept("["):
+			expr = &index{object: expr, key: p.expression()}
+			p.expect("]")
+		default:
+			return expr
+		}
+	}
+}
+
+func (p *parser) primary() node {
+	t := p.next()
+	switch t.kind {
+	case tokNumber:
+		return &literal{value: t.num}
+	case tokString:
+		return &literal{value: t.text}
+	case tokRegex:
+		return &regexLit{re: newRegex(t.text, t.flags)}
+	case tokIdent:
+		switch t.text {
+		case "true":
+			return &literal{value: true}
+		case "false":
+			return &literal{value: false}
+		case "null", "undefined":
+			return &literal{value: nil}
+		case "function":
+			return &funcLit{decl: p.function(false)}
+		}
+		return &ident{name: t.text}
+	case tokPunct:
+		switch t.text {
+		case "(":
+			expr := p.expression()
+			p.expect(")")
+			return expr
+		case "[":
+			arr := &array{}
+			for !p.accept("]") {
+				if len(arr.elems) > 0 {
+					p.expect(",")
+					if p.accept("]") {
+						break
+					}
+				}
+				arr.elems = append(arr.elems, p.assignment())
+			}
+			return arr
+		case "{":
+			return p.objectLiteral()
+		}
+	}
+	p.back(t)
+	p.fail("unexpected token")
+	return nil
+}
+
+// objectLiteral parses an object literal after its opening brace
+func (p *parser) objectLiteral() node {
+	obj := &object{}
+	for !p.accept("}") {
+		if len(obj.keys) > 0 {
+			p.expect(",")
+			if p.accept("}") {
+				break
+			}
+		}
+		key := p.next()
+		switch key.kind {
+		case tokIdent, tokString:
+			obj.keys = append(obj.keys, key.text)
+		case tokNumber:
+			obj.keys = append(obj.keys, toString(key.num))
+		default:
+			p.back(key)
+			p.fail("expected property name")
+		}
+		p.expect(":")
+		obj.values = append(obj.values, p.assignment())
+	}
+	return obj
+}
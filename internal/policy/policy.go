@@ -0,0 +1,176 @@
+// Package policy decides when to connect or disconnect automatically based
+// on the auto-connect rules and the network the host is on
+package policy
+
+import (
+	"context"
+	"sync"
+
+	"github.com/nikola43/aureo-vpn-client/internal/config"
+	"github.com/nikola43/aureo-vpn-client/internal/logging"
+	"github.com/nikola43/aureo-vpn-client/internal/netmon"
+)
+
+var logger = logging.For(logging.SubsystemApp)
+
+// Actions the engine can take
+const (
+	ActionNone       = "none"
+	ActionConnect    = "connect"
+	ActionDisconnect = "disconnect"
+)
+
+// Decision is the outcome of evaluating the rules
+type Decision struct {
+	Action string `json:"action"`
+	Reason string `json:"reason"`
+}
+
+// Actions connects the engine to the app
+type Actions struct {
+	Connect     func(rules config.AutoConnect) error
+	Disconnect  func() error
+	IsConnected func() bool
+	// Hold returns a non-empty reason while automatic actions must wait,
+	// e.g. before the user is signed in
+	Hold func() string
+}
+
+// Decide evaluates the rules for a network. startup is true for the first
+// evaluation after the app starts.
+func Decide(rules config.AutoConnect, network netmon.Network, startup, connected bool) Decision {
+	if network.Type == netmon.TypeNone {
+		return Decision{Action: ActionNone, Reason: "no network"}
+	}
+
+	trusted := rules.IsTrusted(network.ID)
+
+	if trusted && rules.DisconnectOnTrusted {
+		if connected {
+			return Decision{Action: ActionDisconnect, Reason: "trusted network " + network.ID}
+		}
+		return Decision{Action: ActionNone, Reason: "trusted network " + network.ID}
+	}
+
+	if connected {
+		return Decision{Action: ActionNone, Reason: "already connected"}
+	}
+
+	switch {
+	case !trusted && rules.ConnectOnUntrustedNetworks:
+		return Decision{Action: ActionConnect, Reason: "untrusted network " + network.ID}
+	case !trusted && rules.ConnectOnUntrustedWiFi && network.Type == netmon.TypeWiFi:
+		return Decision{Action: ActionConnect, Reason: "untrusted Wi-Fi " + network.ID}
+	case startup && rules.ConnectOnStartup:
+		return Decision{Action: ActionConnect, Reason: "startup"}
+	}
+
+	return Decision{Action: ActionNone, Reason: "no matching rule"}
+}
+
+// Engine applies the rules whenever the network changes, at startup and
+// when a hold is released
+type Engine struct {
+	actions Actions
+
+	// OnDecision is called after each connect or disconnect attempt
+	OnDecision func(Decision, netmon.Network, error)
+
+	mu          sync.Mutex
+	rules       config.AutoConnect
+	network     netmon.Network
+	haveNetwork bool
+	startup     bool
+	pending     bool
+
+	// run serializes evaluations
+	run sync.Mutex
+}
+
+// New creates an engine with the given rules
+func New(rules config.AutoConnect, actions Actions) *Engine {
+	return &Engine{actions: actions, rules: rules, startup: true}
+}
+
+// Run watches the network until ctx is done
+func (e *Engine) Run(ctx context.Context) {
+	for network := range netmon.Watch(ctx) {
+		logger.Info("network changed", "network", network.ID, "type", network.Type)
+
+		e.mu.Lock()
+		e.network = network
+		e.haveNetwork = true
+		e.pending = true
+		e.mu.Unlock()
+
+		e.evaluate()
+	}
+}
+
+// SetRules replaces the rules. They take effect on the next network change
+// or Reevaluate.
+func (e *Engine) SetRules(rules config.AutoConnect) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.rules = rules
+}
+
+// Network returns the last observed network
+func (e *Engine) Network() (netmon.Network, bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.network, e.haveNetwork
+}
+
+// Reevaluate applies the rules to the current network if an earlier change
+// or the startup rule was held back
+func (e *Engine) Reevaluate() {
+	e.evaluate()
+}
+
+// evaluate applies the rules once, unless a hold is active
+func (e *Engine) evaluate() {
+	e.run.Lock()
+	defer e.run.Unlock()
+
+	e.mu.Lock()
+	rules, network, haveNetwork := e.rules, e.network, e.haveNetwork
+	startup, pending := e.startup, e.pending
+	e.mu.Unlock()
+
+	if !haveNetwork || (!startup && !pending) {
+		return
+	}
+
+	if reason := e.actions.Hold(); reason != "" {
+		logger.Debug("auto-connect on hold", "reason", reason)
+		return
+	}
+
+	e.mu.Lock()
+	e.startup = false
+	e.pending = false
+	e.mu.Unlock()
+
+	decision := Decide(rules, network, startup, e.actions.IsConnected())
+
+	var err error
+	switch decision.Action {
+	case ActionConnect:
+		logger.Info("auto-connecting", "reason", decision.Reason)
+		err = e.actions.Connect(rules)
+	case ActionDisconnect:
+		logger.Info("auto-disconnecting", "reason", decision.Reason)
+		err = e.actions.Disconnect()
+	default:
+		logger.Debug("no auto-connect action", "reason", decision.Reason)
+		return
+	}
+
+	if err != nil {
+		logger.Warn("auto-connect action failed", "action", decision.Action, "error", err)
+	}
+	if e.OnDecision != nil {
+		e.OnDecision(decision, network, err)
+	}
+}
@@ -0,0 +1,58 @@
+package proxy
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+// InterfaceDialer returns a DialFunc whose sockets are bound to the named
+// network interface, so connections leave through the tunnel regardless of
+// the system routing table. Host names are resolved through the interface
+// too, using dnsServers or, if there are none, the system's servers.
+func InterfaceDialer(iface string, dnsServers []string) (DialFunc, error) {
+	ifi, err := net.InterfaceByName(iface)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find interface %s: %w", iface, err)
+	}
+
+	control := func(network, address string, c syscall.RawConn) error {
+		var bindErr error
+		err := c.Control(func(fd uintptr) {
+			bindErr = bindToInterface(fd, network, ifi)
+		})
+		if err != nil {
+			return err
+		}
+		return bindErr
+	}
+
+	dnsDialer := &net.Dialer{Timeout: 5 * time.Second, Control: control}
+	var next atomic.Uint32
+	resolver := &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, address string) (net.Conn, error) {
+			// Spread queries over the tunnel's servers; the resolver retries
+			// a failed query, which then goes to the next one
+			if len(dnsServers) > 0 {
+				server := dnsServers[int(next.Add(1)-1)%len(dnsServers)]
+				address = net.JoinHostPort(server, "53")
+			}
+			return dnsDialer.DialContext(ctx, network, address)
+		},
+	}
+
+	dialer := &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+		Control:   control,
+		Resolver:  resolver,
+	}
+
+	return func(ctx context.Context, network, address string) (net.Conn, error) {
+		return dialer.DialContext(ctx, network, address)
+	}, nil
+}
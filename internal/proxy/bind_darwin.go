@@ -0,0 +1,23 @@
+package proxy
+
+import (
+	"fmt"
+	"net"
+
+	"golang.org/x/sys/unix"
+)
+
+// bindToInterface sets IP_BOUND_IF (or IPV6_BOUND_IF) on the socket
+func bindToInterface(fd uintptr, network string, ifi *net.Interface) error {
+	var err error
+	switch network {
+	case "tcp6", "udp6":
+		err = unix.SetsockoptInt(int(fd), unix.IPPROTO_IPV6, unix.IPV6_BOUND_IF, ifi.Index)
+	default:
+		err = unix.SetsockoptInt(int(fd), unix.IPPROTO_IP, unix.IP_BOUND_IF, ifi.Index)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to bind to %s: %w", ifi.Name, err)
+	}
+	return nil
+}
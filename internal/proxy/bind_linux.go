@@ -0,0 +1,16 @@
+package proxy
+
+import (
+	"fmt"
+	"net"
+
+	"golang.org/x/sys/unix"
+)
+
+// bindToInterface sets SO_BINDTODEVICE on the socket
+func bindToInterface(fd uintptr, network string, ifi *net.Interface) error {
+	if err := unix.BindToDevice(int(fd), ifi.Name); err != nil {
+		return fmt.Errorf("failed to bind to %s: %w", ifi.Name, err)
+	}
+	return nil
+}
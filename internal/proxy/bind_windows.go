@@ -0,0 +1,33 @@
+package proxy
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net"
+
+	"golang.org/x/sys/windows"
+)
+
+// IP_UNICAST_IF and IPV6_UNICAST_IF from ws2ipdef.h
+const (
+	ipUnicastIf   = 31
+	ipv6UnicastIf = 31
+)
+
+// bindToInterface sets IP_UNICAST_IF (or IPV6_UNICAST_IF) on the socket
+func bindToInterface(fd uintptr, network string, ifi *net.Interface) error {
+	var err error
+	switch network {
+	case "tcp6", "udp6":
+		err = windows.SetsockoptInt(windows.Handle(fd), windows.IPPROTO_IPV6, ipv6UnicastIf, ifi.Index)
+	default:
+		// The IPv4 option takes the index in network byte order
+		var index [4]byte
+		binary.BigEndian.PutUint32(index[:], uint32(ifi.Index))
+		err = windows.SetsockoptInt(windows.Handle(fd), windows.IPPROTO_IP, ipUnicastIf, int(binary.LittleEndian.Uint32(index[:])))
+	}
+	if err != nil {
+		return fmt.Errorf("failed to bind to %s: %w", ifi.Name, err)
+	}
+	return nil
+}
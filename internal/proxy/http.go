@@ -0,0 +1,160 @@
+package proxy
+
+import (
+	"bufio"
+	"encoding/base64"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// hopHeaders are connection-specific headers that must not be forwarded
+var hopHeaders = []string{
+	"Connection",
+	"Keep-Alive",
+	"Proxy-Authenticate",
+	"Proxy-Authorization",
+	"Proxy-Connection",
+	"Te",
+	"Trailer",
+	"Transfer-Encoding",
+	"Upgrade",
+}
+
+// handleHTTP serves an HTTP proxy client. CONNECT requests are tunneled;
+// plain requests with absolute URLs are forwarded one at a time.
+func (s *Server) handleHTTP(client *countingConn) {
+	reader := bufio.NewReader(client)
+	transport := &http.Transport{
+		DialContext:       s.cfg.Dial,
+		DisableKeepAlives: true,
+	}
+	defer transport.CloseIdleConnections()
+
+	for {
+		req, err := http.ReadRequest(reader)
+		if err != nil {
+			return
+		}
+
+		if !s.authorized(req) {
+			writeAuthRequired(client)
+			return
+		}
+
+		if req.Method == http.MethodConnect {
+			s.handleConnect(client, reader, req)
+			return
+		}
+
+		if !s.forwardHTTP(client, transport, req) {
+			return
+		}
+	}
+}
+
+// handleConnect tunnels a CONNECT request to its target
+func (s *Server) handleConnect(client *countingConn, reader *bufio.Reader, req *http.Request) {
+	target := req.Host
+	if _, _, err := net.SplitHostPort(target); err != nil {
+		target = net.JoinHostPort(target, "443")
+	}
+	client.setTarget(target)
+
+	upstream, err := s.cfg.Dial(s.ctx, "tcp", target)
+	if err != nil {
+		writeHTTPError(client, http.StatusBadGateway)
+		return
+	}
+	defer upstream.Close()
+
+	if _, err := client.Write([]byte("HTTP/1.1 200 Connection Established\r\n\r\n")); err != nil {
+		return
+	}
+
+	// Forward anything the client sent ahead of the response
+	if buffered := reader.Buffered(); buffered > 0 {
+		data, _ := reader.Peek(buffered)
+		if _, err := upstream.Write(data); err != nil {
+			return
+		}
+	}
+
+	pipe(client, upstream)
+}
+
+// forwardHTTP forwards a plain HTTP request and writes the response back.
+// It returns false when the client connection should be closed.
+func (s *Server) forwardHTTP(client *countingConn, transport *http.Transport, req *http.Request) bool {
+	if req.URL.Scheme == "" || req.URL.Host == "" {
+		writeHTTPError(client, http.StatusBadRequest)
+		return false
+	}
+	client.setTarget(req.URL.Host)
+
+	keepAlive := !req.Close && !strings.EqualFold(req.Header.Get("Proxy-Connection"), "close")
+
+	req = req.WithContext(s.ctx)
+	req.RequestURI = ""
+	for _, h := range hopHeaders {
+		req.Header.Del(h)
+	}
+
+	resp, err := transport.RoundTrip(req)
+	if err != nil {
+		writeHTTPError(client, http.StatusBadGateway)
+		return false
+	}
+	defer resp.Body.Close()
+
+	for _, h := range hopHeaders {
+		resp.Header.Del(h)
+	}
+	resp.Close = !keepAlive
+
+	if err := resp.Write(client); err != nil {
+		return false
+	}
+	return keepAlive
+}
+
+// authorized checks the request's Basic Proxy-Authorization header
+func (s *Server) authorized(req *http.Request) bool {
+	if !s.authRequired() {
+		return true
+	}
+
+	scheme, encoded, ok := strings.Cut(req.Header.Get("Proxy-Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Basic") {
+		return false
+	}
+	decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+	if err != nil {
+		return false
+	}
+	username, password, ok := strings.Cut(string(decoded), ":")
+	return ok && s.checkCredentials(username, password)
+}
+
+// writeAuthRequired asks the client for proxy credentials
+func writeAuthRequired(client net.Conn) {
+	resp := &http.Response{
+		StatusCode: http.StatusProxyAuthRequired,
+		ProtoMajor: 1,
+		ProtoMinor: 1,
+		Header:     http.Header{"Proxy-Authenticate": {`Basic realm="Aureo VPN"`}},
+		Close:      true,
+	}
+	resp.Write(client)
+}
+
+// writeHTTPError writes a minimal error response
+func writeHTTPError(client net.Conn, status int) {
+	resp := &http.Response{
+		StatusCode: status,
+		ProtoMajor: 1,
+		ProtoMinor: 1,
+		Close:      true,
+	}
+	resp.Write(client)
+}
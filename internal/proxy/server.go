@@ -0,0 +1,313 @@
+package proxy
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"io"
+	"net"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Proxy kinds
+const (
+	KindSOCKS5 = "socks5"
+	KindHTTP   = "http"
+)
+
+// recentConnections is how many closed connections are kept for reporting
+const recentConnections = 50
+
+// DialFunc opens an outbound connection, e.g. through a VPN tunnel
+type DialFunc func(ctx context.Context, network, address string) (net.Conn, error)
+
+// Config describes a local proxy
+type Config struct {
+	Kind string
+	Addr string
+	Dial DialFunc
+
+	// Username and Password enable authentication when both are set
+	Username string
+	Password string
+}
+
+// ConnStats holds the byte counters for a single client connection.
+// Up counts bytes sent by the client, Down bytes sent back to it.
+type ConnStats struct {
+	ID        uint64    `json:"id"`
+	Client    string    `json:"client"`
+	Target    string    `json:"target"`
+	StartedAt time.Time `json:"started_at"`
+	ClosedAt  time.Time `json:"closed_at"`
+	BytesUp   int64     `json:"bytes_up"`
+	BytesDown int64     `json:"bytes_down"`
+}
+
+// Status reports a proxy's listen address and traffic accounting
+type Status struct {
+	Kind              string      `json:"kind"`
+	Addr              string      `json:"addr"`
+	AuthRequired      bool        `json:"auth_required"`
+	ActiveConnections int         `json:"active_connections"`
+	TotalConnections  uint64      `json:"total_connections"`
+	BytesUp           int64       `json:"bytes_up"`
+	BytesDown         int64       `json:"bytes_down"`
+	Connections       []ConnStats `json:"connections"`
+}
+
+// Server is a local proxy that forwards client connections through a DialFunc
+type Server struct {
+	cfg      Config
+	listener net.Listener
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	mu     sync.Mutex
+	nextID uint64
+	active map[uint64]*countingConn
+	recent []ConnStats
+
+	// Totals for connections that have already closed
+	closedUp   int64
+	closedDown int64
+}
+
+// Listen starts a proxy described by cfg
+func Listen(cfg Config) (*Server, error) {
+	if cfg.Kind != KindSOCKS5 && cfg.Kind != KindHTTP {
+		return nil, fmt.Errorf("unknown proxy kind: %s", cfg.Kind)
+	}
+	if cfg.Dial == nil {
+		return nil, fmt.Errorf("proxy dialer not set")
+	}
+	if !isLoopback(cfg.Addr) && (cfg.Username == "" || cfg.Password == "") {
+		return nil, fmt.Errorf("a proxy on %s is reachable from other hosts and requires a username and password", cfg.Addr)
+	}
+
+	listener, err := net.Listen("tcp", cfg.Addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on %s: %w", cfg.Addr, err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s := &Server{
+		cfg:      cfg,
+		listener: listener,
+		ctx:      ctx,
+		cancel:   cancel,
+		active:   make(map[uint64]*countingConn),
+	}
+
+	s.wg.Add(1)
+	go s.serve()
+
+	return s, nil
+}
+
+// isLoopback reports whether addr only accepts connections from this host.
+// An empty host listens on every interface.
+func isLoopback(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return false
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// Kind returns the proxy kind
+func (s *Server) Kind() string {
+	return s.cfg.Kind
+}
+
+// Addr returns the address the proxy listens on
+func (s *Server) Addr() string {
+	return s.listener.Addr().String()
+}
+
+// Close stops accepting connections and waits for active ones to finish
+func (s *Server) Close() error {
+	s.cancel()
+	err := s.listener.Close()
+	s.wg.Wait()
+	return err
+}
+
+// Status returns the proxy's current accounting
+func (s *Server) Status() Status {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	status := Status{
+		Kind:              s.cfg.Kind,
+		Addr:              s.Addr(),
+		AuthRequired:      s.authRequired(),
+		ActiveConnections: len(s.active),
+		TotalConnections:  s.nextID,
+		BytesUp:           s.closedUp,
+		BytesDown:         s.closedDown,
+	}
+
+	for _, c := range s.active {
+		stats := c.stats()
+		status.BytesUp += stats.BytesUp
+		status.BytesDown += stats.BytesDown
+		status.Connections = append(status.Connections, stats)
+	}
+	sort.Slice(status.Connections, func(i, j int) bool {
+		return status.Connections[i].ID > status.Connections[j].ID
+	})
+	status.Connections = append(status.Connections, s.recent...)
+
+	return status
+}
+
+// authRequired reports whether clients must authenticate
+func (s *Server) authRequired() bool {
+	return s.cfg.Username != "" && s.cfg.Password != ""
+}
+
+// checkCredentials validates a client's username and password
+func (s *Server) checkCredentials(username, password string) bool {
+	userOK := subtle.ConstantTimeCompare([]byte(username), []byte(s.cfg.Username)) == 1
+	passOK := subtle.ConstantTimeCompare([]byte(password), []byte(s.cfg.Password)) == 1
+	return userOK && passOK
+}
+
+// serve accepts client connections until the listener is closed
+func (s *Server) serve() {
+	defer s.wg.Done()
+
+	for {
+		client, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			s.handle(client)
+		}()
+	}
+}
+
+// handle serves a single client connection
+func (s *Server) handle(conn net.Conn) {
+	client := s.track(conn)
+	defer s.untrack(client)
+
+	// Closing the client unblocks the handler when the server shuts down
+	stop := context.AfterFunc(s.ctx, func() { client.Close() })
+	defer stop()
+	defer client.Close()
+
+	switch s.cfg.Kind {
+	case KindSOCKS5:
+		s.handleSOCKS5(client)
+	case KindHTTP:
+		s.handleHTTP(client)
+	}
+}
+
+// track registers a new client connection for accounting
+func (s *Server) track(conn net.Conn) *countingConn {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.nextID++
+	c := &countingConn{
+		Conn:      conn,
+		id:        s.nextID,
+		startedAt: time.Now(),
+	}
+	s.active[c.id] = c
+	return c
+}
+
+// untrack moves a finished connection's counters into the totals
+func (s *Server) untrack(c *countingConn) {
+	stats := c.stats()
+	stats.ClosedAt = time.Now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.active, c.id)
+	s.closedUp += stats.BytesUp
+	s.closedDown += stats.BytesDown
+
+	s.recent = append([]ConnStats{stats}, s.recent...)
+	if len(s.recent) > recentConnections {
+		s.recent = s.recent[:recentConnections]
+	}
+}
+
+// countingConn counts bytes read from and written to a client connection
+type countingConn struct {
+	net.Conn
+
+	id        uint64
+	startedAt time.Time
+	target    atomic.Value
+	up        atomic.Int64
+	down      atomic.Int64
+}
+
+func (c *countingConn) Read(b []byte) (int, error) {
+	n, err := c.Conn.Read(b)
+	c.up.Add(int64(n))
+	return n, err
+}
+
+func (c *countingConn) Write(b []byte) (int, error) {
+	n, err := c.Conn.Write(b)
+	c.down.Add(int64(n))
+	return n, err
+}
+
+// setTarget records the upstream address the client asked for
+func (c *countingConn) setTarget(target string) {
+	c.target.Store(target)
+}
+
+// stats returns a snapshot of the connection's counters
+func (c *countingConn) stats() ConnStats {
+	target, _ := c.target.Load().(string)
+	return ConnStats{
+		ID:        c.id,
+		Client:    c.RemoteAddr().String(),
+		Target:    target,
+		StartedAt: c.startedAt,
+		BytesUp:   c.up.Load(),
+		BytesDown: c.down.Load(),
+	}
+}
+
+// pipe copies data in both directions until either side closes
+func pipe(client, upstream net.Conn) {
+	done := make(chan struct{}, 2)
+
+	go func() {
+		io.Copy(upstream, client)
+		upstream.Close()
+		done <- struct{}{}
+	}()
+	go func() {
+		io.Copy(client, upstream)
+		client.Close()
+		done <- struct{}{}
+	}()
+
+	<-done
+	<-done
+}
@@ -0,0 +1,214 @@
+package proxy
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+)
+
+// SOCKS5 protocol constants (RFC 1928)
+const (
+	socksVersion = 0x05
+
+	socksMethodNoAuth       = 0x00
+	socksMethodUserPass     = 0x02
+	socksMethodNoAcceptable = 0xff
+
+	// Username/password sub-negotiation (RFC 1929)
+	socksAuthVersion = 0x01
+	socksAuthSuccess = 0x00
+	socksAuthFailure = 0x01
+
+	socksCmdConnect = 0x01
+
+	socksAtypIPv4   = 0x01
+	socksAtypDomain = 0x03
+	socksAtypIPv6   = 0x04
+
+	socksReplySucceeded           = 0x00
+	socksReplyGeneralFailure      = 0x01
+	socksReplyHostUnreachable     = 0x04
+	socksReplyCommandNotSupported = 0x07
+	socksReplyAddrNotSupported    = 0x08
+)
+
+// handleSOCKS5 serves a SOCKS5 client. Only the CONNECT command is supported.
+func (s *Server) handleSOCKS5(client *countingConn) {
+	reader := bufio.NewReader(client)
+
+	if err := s.socksNegotiate(reader, client); err != nil {
+		return
+	}
+
+	// Request: VER CMD RSV ATYP DST.ADDR DST.PORT
+	header := make([]byte, 4)
+	if _, err := io.ReadFull(reader, header); err != nil {
+		return
+	}
+	if header[0] != socksVersion {
+		return
+	}
+
+	target, err := readSOCKSAddr(reader, header[3])
+	if err != nil {
+		writeSOCKSReply(client, socksReplyAddrNotSupported)
+		return
+	}
+
+	if header[1] != socksCmdConnect {
+		writeSOCKSReply(client, socksReplyCommandNotSupported)
+		return
+	}
+
+	client.setTarget(target)
+
+	upstream, err := s.cfg.Dial(s.ctx, "tcp", target)
+	if err != nil {
+		writeSOCKSReply(client, socksReplyHostUnreachable)
+		return
+	}
+	defer upstream.Close()
+
+	if err := writeSOCKSReply(client, socksReplySucceeded); err != nil {
+		return
+	}
+
+	// Forward anything the client sent ahead of the reply
+	if buffered := reader.Buffered(); buffered > 0 {
+		data, _ := reader.Peek(buffered)
+		if _, err := upstream.Write(data); err != nil {
+			return
+		}
+	}
+
+	pipe(client, upstream)
+}
+
+// socksNegotiate performs method selection, requiring username/password
+// authentication when the server has credentials configured
+func (s *Server) socksNegotiate(reader *bufio.Reader, client net.Conn) error {
+	// Greeting: VER NMETHODS METHODS...
+	greeting := make([]byte, 2)
+	if _, err := io.ReadFull(reader, greeting); err != nil {
+		return err
+	}
+	if greeting[0] != socksVersion {
+		return fmt.Errorf("unsupported SOCKS version %d", greeting[0])
+	}
+
+	methods := make([]byte, greeting[1])
+	if _, err := io.ReadFull(reader, methods); err != nil {
+		return err
+	}
+
+	want := byte(socksMethodNoAuth)
+	if s.authRequired() {
+		want = socksMethodUserPass
+	}
+
+	for _, method := range methods {
+		if method != want {
+			continue
+		}
+		if _, err := client.Write([]byte{socksVersion, want}); err != nil {
+			return err
+		}
+		if want == socksMethodUserPass {
+			return s.socksAuthenticate(reader, client)
+		}
+		return nil
+	}
+
+	client.Write([]byte{socksVersion, socksMethodNoAcceptable})
+	return fmt.Errorf("no acceptable authentication method")
+}
+
+// socksAuthenticate performs the username/password sub-negotiation
+func (s *Server) socksAuthenticate(reader *bufio.Reader, client net.Conn) error {
+	// Request: VER ULEN UNAME PLEN PASSWD
+	version, err := reader.ReadByte()
+	if err != nil {
+		return err
+	}
+	if version != socksAuthVersion {
+		return fmt.Errorf("unsupported auth version %d", version)
+	}
+
+	username, err := readSOCKSString(reader)
+	if err != nil {
+		return err
+	}
+	password, err := readSOCKSString(reader)
+	if err != nil {
+		return err
+	}
+
+	if !s.checkCredentials(username, password) {
+		client.Write([]byte{socksAuthVersion, socksAuthFailure})
+		return fmt.Errorf("invalid credentials")
+	}
+
+	_, err = client.Write([]byte{socksAuthVersion, socksAuthSuccess})
+	return err
+}
+
+// readSOCKSString reads a length-prefixed string
+func readSOCKSString(reader *bufio.Reader) (string, error) {
+	length, err := reader.ReadByte()
+	if err != nil {
+		return "", err
+	}
+	buf := make([]byte, length)
+	if _, err := io.ReadFull(reader, buf); err != nil {
+		return "", err
+	}
+	return string(buf), nil
+}
+
+// readSOCKSAddr reads DST.ADDR and DST.PORT for the given address type
+func readSOCKSAddr(reader *bufio.Reader, atyp byte) (string, error) {
+	var host string
+
+	switch atyp {
+	case socksAtypIPv4:
+		addr := make([]byte, net.IPv4len)
+		if _, err := io.ReadFull(reader, addr); err != nil {
+			return "", err
+		}
+		host = net.IP(addr).String()
+	case socksAtypIPv6:
+		addr := make([]byte, net.IPv6len)
+		if _, err := io.ReadFull(reader, addr); err != nil {
+			return "", err
+		}
+		host = net.IP(addr).String()
+	case socksAtypDomain:
+		length, err := reader.ReadByte()
+		if err != nil {
+			return "", err
+		}
+		domain := make([]byte, length)
+		if _, err := io.ReadFull(reader, domain); err != nil {
+			return "", err
+		}
+		host = string(domain)
+	default:
+		return "", fmt.Errorf("unsupported address type %d", atyp)
+	}
+
+	port := make([]byte, 2)
+	if _, err := io.ReadFull(reader, port); err != nil {
+		return "", err
+	}
+
+	return net.JoinHostPort(host, strconv.Itoa(int(binary.BigEndian.Uint16(port)))), nil
+}
+
+// writeSOCKSReply sends a reply with an unspecified bound address
+func writeSOCKSReply(client net.Conn, code byte) error {
+	_, err := client.Write([]byte{socksVersion, code, 0x00, socksAtypIPv4, 0, 0, 0, 0, 0, 0})
+	return err
+}
@@ -0,0 +1,78 @@
+package secrets
+
+import (
+	"bytes"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+)
+
+// Buffer holds a secret, such as a base64 WireGuard key, in memory that can
+// be wiped once the secret has been used. Go strings are immutable and stay
+// in memory until collected, so secrets should not be converted to them.
+type Buffer []byte
+
+// Wipe overwrites the secret with zeros
+func (b Buffer) Wipe() {
+	clear(b)
+}
+
+// Clone returns a copy that can be wiped independently
+func (b Buffer) Clone() Buffer {
+	if b == nil {
+		return nil
+	}
+	return append(Buffer{}, b...)
+}
+
+// Equal reports whether two secrets are the same, in constant time
+func (b Buffer) Equal(other Buffer) bool {
+	return subtle.ConstantTimeCompare(b, other) == 1
+}
+
+// String keeps the secret out of logs and formatted output
+func (b Buffer) String() string {
+	return "[REDACTED]"
+}
+
+// GoString keeps the secret out of %#v output
+func (b Buffer) GoString() string {
+	return b.String()
+}
+
+// MarshalJSON encodes the secret as a JSON string without going through a
+// Go string, unless it holds characters that need escaping
+func (b Buffer) MarshalJSON() ([]byte, error) {
+	for _, c := range b {
+		if c < 0x20 || c >= 0x7f || c == '"' || c == '\\' {
+			return json.Marshal(string(b))
+		}
+	}
+
+	out := make([]byte, 0, len(b)+2)
+	out = append(out, '"')
+	out = append(out, b...)
+	return append(out, '"'), nil
+}
+
+// UnmarshalJSON decodes a JSON string into the buffer
+func (b *Buffer) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		return nil
+	}
+	if len(data) < 2 || data[0] != '"' || data[len(data)-1] != '"' {
+		return fmt.Errorf("secret is not a JSON string")
+	}
+
+	raw := data[1 : len(data)-1]
+	if bytes.IndexByte(raw, '\\') >= 0 {
+		var s string
+		if err := json.Unmarshal(data, &s); err != nil {
+			return err
+		}
+		*b = Buffer(s)
+		return nil
+	}
+	*b = append(Buffer{}, raw...)
+	return nil
+}
@@ -0,0 +1,100 @@
+// Package secrets keeps small secrets, such as WireGuard private keys, in
+// the operating system's credential store: the login keychain on macOS,
+// the Secret Service (GNOME Keyring, KWallet) on Linux and DPAPI-encrypted
+// files on Windows. On Linux hosts without a Secret Service, secrets fall
+// back to files readable only by the user.
+package secrets
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+)
+
+// ErrNotFound is returned by Get when no secret has the name
+var ErrNotFound = errors.New("secret not found")
+
+// validName restricts names to characters that are safe in file names and
+// credential store attributes
+var validName = regexp.MustCompile(`^[A-Za-z0-9._-]{1,128}$`)
+
+// Store reads and writes the secrets of one application
+type Store struct {
+	// service labels the app's entries in the credential store
+	service string
+	// dir holds file-backed secrets
+	dir string
+}
+
+// New returns a store for service, keeping any file-backed secrets in dir
+func New(service, dir string) *Store {
+	return &Store{service: service, dir: filepath.Join(dir, "secrets")}
+}
+
+// Get returns the named secret, or ErrNotFound
+func (s *Store) Get(name string) ([]byte, error) {
+	if !validName.MatchString(name) {
+		return nil, fmt.Errorf("invalid secret name %q", name)
+	}
+	return s.get(name)
+}
+
+// Set stores a secret, replacing any previous value
+func (s *Store) Set(name string, value []byte) error {
+	if !validName.MatchString(name) {
+		return fmt.Errorf("invalid secret name %q", name)
+	}
+	return s.set(name, value)
+}
+
+// Delete removes a secret; deleting a missing secret is not an error
+func (s *Store) Delete(name string) error {
+	if !validName.MatchString(name) {
+		return fmt.Errorf("invalid secret name %q", name)
+	}
+	return s.delete(name)
+}
+
+// filePath returns the path of a file-backed secret
+func (s *Store) filePath(name, ext string) string {
+	return filepath.Join(s.dir, name+ext)
+}
+
+// writeFile writes a file-backed secret atomically with owner-only access
+func (s *Store) writeFile(path string, data []byte) error {
+	if err := os.MkdirAll(s.dir, 0700); err != nil {
+		return fmt.Errorf("failed to create secrets directory: %w", err)
+	}
+
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("failed to write secret: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to write secret: %w", err)
+	}
+	return nil
+}
+
+// readFile reads a file-backed secret
+func readFile(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read secret: %w", err)
+	}
+	return data, nil
+}
+
+// removeFile overwrites and deletes a file-backed secret
+func removeFile(path string) error {
+	if err := Shred(path); err != nil {
+		return fmt.Errorf("failed to delete secret: %w", err)
+	}
+	return nil
+}
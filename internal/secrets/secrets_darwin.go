@@ -0,0 +1,64 @@
+package secrets
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// errItemNotFound is the exit status of security(1) for a missing item
+const errItemNotFound = 44
+
+// get reads a generic password from the login keychain
+func (s *Store) get(name string) ([]byte, error) {
+	output, err := exec.Command("security", "find-generic-password", "-s", s.service, "-a", name, "-w").Output()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && exitErr.ExitCode() == errItemNotFound {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to read keychain item: %w", err)
+	}
+
+	value, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(output)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode keychain item: %w", err)
+	}
+	return value, nil
+}
+
+// set adds or updates a generic password. The command is passed on stdin
+// in interactive mode, so the secret never appears in the process list.
+// Values are base64 encoded to keep them free of quotes and newlines.
+func (s *Store) set(name string, value []byte) error {
+	command := fmt.Sprintf("add-generic-password -U -s %q -a %q -w %q\n",
+		s.service, name, base64.StdEncoding.EncodeToString(value))
+
+	cmd := exec.Command("security", "-i")
+	cmd.Stdin = strings.NewReader(command)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("failed to write keychain item: %w", err)
+	}
+	if stderr.Len() > 0 {
+		return fmt.Errorf("failed to write keychain item: %s", strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
+
+// delete removes a generic password
+func (s *Store) delete(name string) error {
+	err := exec.Command("security", "delete-generic-password", "-s", s.service, "-a", name).Run()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && exitErr.ExitCode() == errItemNotFound {
+			return nil
+		}
+		return fmt.Errorf("failed to delete keychain item: %w", err)
+	}
+	return nil
+}
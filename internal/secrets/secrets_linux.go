@@ -0,0 +1,226 @@
+package secrets
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+)
+
+const (
+	ssDest       = "org.freedesktop.secrets"
+	ssPath       = "/org/freedesktop/secrets"
+	ssService    = "org.freedesktop.Secret.Service"
+	ssCollection = "org.freedesktop.Secret.Collection"
+	ssItem       = "org.freedesktop.Secret.Item"
+	ssPrompt     = "org.freedesktop.Secret.Prompt"
+
+	ssDefaultAlias  = "default"
+	ssNoPrompt      = dbus.ObjectPath("/")
+	ssPromptTimeout = 2 * time.Minute
+	ssContentType   = "application/octet-stream"
+
+	// fileExt is the extension of secrets kept in files
+	fileExt = ".secret"
+)
+
+// errNoSecretService means no Secret Service with a default collection is
+// running, so secrets are kept in files
+var errNoSecretService = errors.New("secret service not available")
+
+// ssSecret is the Secret struct of the Secret Service API
+type ssSecret struct {
+	Session     dbus.ObjectPath
+	Parameters  []byte
+	Value       []byte
+	ContentType string
+}
+
+// secretService is an open session with the Secret Service
+type secretService struct {
+	conn       *dbus.Conn
+	session    dbus.ObjectPath
+	collection dbus.ObjectPath
+}
+
+// get reads a secret from the Secret Service, or from a file if none is running
+func (s *Store) get(name string) ([]byte, error) {
+	ss, err := openSecretService()
+	if errors.Is(err, errNoSecretService) {
+		return readFile(s.filePath(name, fileExt))
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer ss.close()
+
+	item, err := ss.find(s.attributes(name))
+	if err != nil {
+		return nil, err
+	}
+	if item == "" {
+		return nil, ErrNotFound
+	}
+	if err := ss.unlock(item); err != nil {
+		return nil, err
+	}
+
+	var secret ssSecret
+	if err := ss.conn.Object(ssDest, item).Call(ssItem+".GetSecret", 0, ss.session).Store(&secret); err != nil {
+		return nil, fmt.Errorf("failed to read secret: %w", err)
+	}
+	return secret.Value, nil
+}
+
+// set stores a secret in the Secret Service, or in a file if none is running
+func (s *Store) set(name string, value []byte) error {
+	ss, err := openSecretService()
+	if errors.Is(err, errNoSecretService) {
+		return s.writeFile(s.filePath(name, fileExt), value)
+	}
+	if err != nil {
+		return err
+	}
+	defer ss.close()
+
+	if err := ss.unlock(ss.collection); err != nil {
+		return err
+	}
+
+	properties := map[string]dbus.Variant{
+		ssItem + ".Label":      dbus.MakeVariant(s.service + " " + name),
+		ssItem + ".Attributes": dbus.MakeVariant(s.attributes(name)),
+	}
+	secret := ssSecret{Session: ss.session, Value: value, ContentType: ssContentType}
+
+	var item, prompt dbus.ObjectPath
+	call := ss.conn.Object(ssDest, ss.collection).Call(ssCollection+".CreateItem", 0, properties, secret, true)
+	if err := call.Store(&item, &prompt); err != nil {
+		return fmt.Errorf("failed to write secret: %w", err)
+	}
+	return ss.prompt(prompt)
+}
+
+// delete removes a secret from the Secret Service and any file fallback
+func (s *Store) delete(name string) error {
+	if err := removeFile(s.filePath(name, fileExt)); err != nil {
+		return err
+	}
+
+	ss, err := openSecretService()
+	if errors.Is(err, errNoSecretService) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer ss.close()
+
+	item, err := ss.find(s.attributes(name))
+	if err != nil || item == "" {
+		return err
+	}
+
+	var prompt dbus.ObjectPath
+	if err := ss.conn.Object(ssDest, item).Call(ssItem+".Delete", 0).Store(&prompt); err != nil {
+		return fmt.Errorf("failed to delete secret: %w", err)
+	}
+	return ss.prompt(prompt)
+}
+
+// attributes identify a secret in the Secret Service
+func (s *Store) attributes(name string) map[string]string {
+	return map[string]string{"service": s.service, "name": name}
+}
+
+// openSecretService connects to the session bus and opens a plain session.
+// Secrets travel unencrypted over the bus, which only the user can access.
+func openSecretService() (*secretService, error) {
+	conn, err := dbus.ConnectSessionBus()
+	if err != nil {
+		return nil, errNoSecretService
+	}
+
+	ss := &secretService{conn: conn}
+	service := conn.Object(ssDest, ssPath)
+
+	var output dbus.Variant
+	if err := service.Call(ssService+".OpenSession", 0, "plain", dbus.MakeVariant("")).Store(&output, &ss.session); err != nil {
+		conn.Close()
+		return nil, errNoSecretService
+	}
+
+	if err := service.Call(ssService+".ReadAlias", 0, ssDefaultAlias).Store(&ss.collection); err != nil || ss.collection == ssNoPrompt {
+		ss.close()
+		return nil, errNoSecretService
+	}
+	return ss, nil
+}
+
+// close ends the session and disconnects
+func (ss *secretService) close() {
+	ss.conn.Object(ssDest, ss.session).Call("org.freedesktop.Secret.Session.Close", 0)
+	ss.conn.Close()
+}
+
+// find returns the first item matching attributes, or "" if there is none
+func (ss *secretService) find(attributes map[string]string) (dbus.ObjectPath, error) {
+	var unlocked, locked []dbus.ObjectPath
+	call := ss.conn.Object(ssDest, ssPath).Call(ssService+".SearchItems", 0, attributes)
+	if err := call.Store(&unlocked, &locked); err != nil {
+		return "", fmt.Errorf("failed to search secrets: %w", err)
+	}
+	if len(unlocked) > 0 {
+		return unlocked[0], nil
+	}
+	if len(locked) > 0 {
+		return locked[0], nil
+	}
+	return "", nil
+}
+
+// unlock unlocks an item or collection, prompting the user if needed
+func (ss *secretService) unlock(path dbus.ObjectPath) error {
+	var unlocked []dbus.ObjectPath
+	var prompt dbus.ObjectPath
+	call := ss.conn.Object(ssDest, ssPath).Call(ssService+".Unlock", 0, []dbus.ObjectPath{path})
+	if err := call.Store(&unlocked, &prompt); err != nil {
+		return fmt.Errorf("failed to unlock secrets: %w", err)
+	}
+	return ss.prompt(prompt)
+}
+
+// prompt shows a Secret Service prompt and waits for the user to answer it
+func (ss *secretService) prompt(path dbus.ObjectPath) error {
+	if path == "" || path == ssNoPrompt {
+		return nil
+	}
+
+	if err := ss.conn.AddMatchSignal(dbus.WithMatchObjectPath(path), dbus.WithMatchInterface(ssPrompt), dbus.WithMatchMember("Completed")); err != nil {
+		return fmt.Errorf("failed to watch secret prompt: %w", err)
+	}
+	signals := make(chan *dbus.Signal, 1)
+	ss.conn.Signal(signals)
+	defer ss.conn.RemoveSignal(signals)
+
+	if err := ss.conn.Object(ssDest, path).Call(ssPrompt+".Prompt", 0, "").Err; err != nil {
+		return fmt.Errorf("failed to show secret prompt: %w", err)
+	}
+
+	timeout := time.After(ssPromptTimeout)
+	for {
+		select {
+		case signal := <-signals:
+			if signal.Path != path || len(signal.Body) == 0 {
+				continue
+			}
+			if dismissed, _ := signal.Body[0].(bool); dismissed {
+				return fmt.Errorf("secret prompt dismissed")
+			}
+			return nil
+		case <-timeout:
+			return fmt.Errorf("secret prompt timed out")
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package secrets
+
+import (
+	"fmt"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+// dpapiExt is the extension of DPAPI-encrypted secret files
+const dpapiExt = ".dpapi"
+
+// get decrypts a secret file with the user's DPAPI key
+func (s *Store) get(name string) ([]byte, error) {
+	data, err := readFile(s.filePath(name, dpapiExt))
+	if err != nil {
+		return nil, err
+	}
+
+	value, err := s.crypt(data, false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt secret: %w", err)
+	}
+	return value, nil
+}
+
+// set encrypts a secret with the user's DPAPI key and writes it to a file
+func (s *Store) set(name string, value []byte) error {
+	data, err := s.crypt(value, true)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt secret: %w", err)
+	}
+	return s.writeFile(s.filePath(name, dpapiExt), data)
+}
+
+// delete removes a secret file
+func (s *Store) delete(name string) error {
+	return removeFile(s.filePath(name, dpapiExt))
+}
+
+// crypt protects or unprotects data, using the service name as entropy
+func (s *Store) crypt(data []byte, protect bool) ([]byte, error) {
+	in := blob(data)
+	entropy := blob([]byte(s.service))
+	var out windows.DataBlob
+
+	var err error
+	if protect {
+		err = windows.CryptProtectData(in, nil, entropy, 0, nil, windows.CRYPTPROTECT_UI_FORBIDDEN, &out)
+	} else {
+		err = windows.CryptUnprotectData(in, nil, entropy, 0, nil, windows.CRYPTPROTECT_UI_FORBIDDEN, &out)
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer windows.LocalFree(windows.Handle(unsafe.Pointer(out.Data)))
+
+	return append([]byte{}, unsafe.Slice(out.Data, out.Size)...), nil
+}
+
+// blob wraps data for the DPAPI calls
+func blob(data []byte) *windows.DataBlob {
+	if len(data) == 0 {
+		return &windows.DataBlob{}
+	}
+	return &windows.DataBlob{Size: uint32(len(data)), Data: &data[0]}
+}
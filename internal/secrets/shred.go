@@ -0,0 +1,54 @@
+package secrets
+
+import (
+	"fmt"
+	"os"
+)
+
+// shredChunk is the size of the zero blocks written over a file
+const shredChunk = 32 * 1024
+
+// Shred overwrites a file with zeros before removing it, so its contents
+// are not left behind in free disk blocks. Copy-on-write filesystems and
+// SSD wear levelling may still keep old copies, so this is best effort.
+// Shredding a missing file is not an error.
+func Shred(path string) error {
+	f, err := os.OpenFile(path, os.O_WRONLY, 0)
+	if os.IsNotExist(err) {
+		return nil
+	}
+
+	// Remove the file even if it cannot be overwritten
+	if err == nil {
+		err = overwrite(f)
+		f.Close()
+	}
+	if removeErr := os.Remove(path); removeErr != nil && !os.IsNotExist(removeErr) {
+		return fmt.Errorf("failed to remove %s: %w", path, removeErr)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to overwrite %s: %w", path, err)
+	}
+	return nil
+}
+
+// overwrite writes zeros over the whole file and flushes them to disk
+func overwrite(f *os.File) error {
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+
+	zeros := make([]byte, shredChunk)
+	for remaining := info.Size(); remaining > 0; {
+		n := int64(len(zeros))
+		if remaining < n {
+			n = remaining
+		}
+		if _, err := f.Write(zeros[:n]); err != nil {
+			return err
+		}
+		remaining -= n
+	}
+	return f.Sync()
+}
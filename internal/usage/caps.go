@@ -0,0 +1,96 @@
+package usage
+
+import (
+	"fmt"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// Cap levels reported by CheckCaps
+const (
+	CapOK   = "ok"
+	CapSoft = "soft"
+	CapHard = "hard"
+)
+
+// Caps limits traffic per period. Reaching the soft cap warns the user;
+// reaching the hard cap disconnects. Zero disables a cap.
+type Caps struct {
+	Period    string `json:"period"`
+	SoftBytes int64  `json:"soft_bytes"`
+	HardBytes int64  `json:"hard_bytes"`
+}
+
+// CapStatus is the usage of the current period measured against the caps
+type CapStatus struct {
+	Caps
+	Used        int64     `json:"used"`
+	Level       string    `json:"level"`
+	PeriodStart time.Time `json:"period_start"`
+	PeriodEnd   time.Time `json:"period_end"`
+}
+
+// SetCaps stores the caps
+func (l *Ledger) SetCaps(caps Caps) error {
+	if _, _, err := PeriodBounds(caps.Period, time.Now()); err != nil {
+		return err
+	}
+	if caps.SoftBytes < 0 || caps.HardBytes < 0 {
+		return fmt.Errorf("caps must not be negative")
+	}
+	if caps.SoftBytes > 0 && caps.HardBytes > 0 && caps.SoftBytes > caps.HardBytes {
+		return fmt.Errorf("soft cap must not exceed hard cap")
+	}
+
+	err := l.db.Update(func(tx *bolt.Tx) error {
+		return putJSON(tx.Bucket(metaBucket), capsKey, caps)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save caps: %w", err)
+	}
+	return nil
+}
+
+// Caps returns the stored caps, defaulting to none on a monthly period
+func (l *Ledger) Caps() (Caps, error) {
+	caps := Caps{Period: PeriodMonth}
+	err := l.db.View(func(tx *bolt.Tx) error {
+		return getJSON(tx.Bucket(metaBucket), capsKey, &caps)
+	})
+	if err != nil {
+		return Caps{}, fmt.Errorf("failed to read caps: %w", err)
+	}
+	return caps, nil
+}
+
+// CheckCaps measures the period containing now against the caps
+func (l *Ledger) CheckCaps(now time.Time) (CapStatus, error) {
+	caps, err := l.Caps()
+	if err != nil {
+		return CapStatus{}, err
+	}
+
+	rollups, err := l.Rollups(caps.Period, 1, now)
+	if err != nil {
+		return CapStatus{}, err
+	}
+	current := rollups[0]
+
+	status := CapStatus{
+		Caps:        caps,
+		Used:        current.Total(),
+		Level:       CapOK,
+		PeriodStart: current.Start,
+		PeriodEnd:   current.End,
+	}
+
+	switch {
+	case caps.HardBytes > 0 && status.Used >= caps.HardBytes:
+		status.Level = CapHard
+	case caps.SoftBytes > 0 && status.Used >= caps.SoftBytes:
+		status.Level = CapSoft
+	}
+
+	return status, nil
+}
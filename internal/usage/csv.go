@@ -0,0 +1,45 @@
+package usage
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+)
+
+// ExportCSV writes the sessions that started in [from, to) as CSV, oldest first
+func (l *Ledger) ExportCSV(w io.Writer, from, to time.Time) error {
+	sessions, err := l.Sessions(from, to, 0)
+	if err != nil {
+		return err
+	}
+
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"session_id", "node_id", "node_name", "protocol", "started_at", "ended_at", "bytes_sent", "bytes_received", "bytes_total"})
+
+	for i := len(sessions) - 1; i >= 0; i-- {
+		s := sessions[i]
+		ended := ""
+		if !s.EndedAt.IsZero() {
+			ended = s.EndedAt.Format(time.RFC3339)
+		}
+		cw.Write([]string{
+			strconv.FormatUint(s.ID, 10),
+			s.NodeID,
+			s.NodeName,
+			s.Protocol,
+			s.StartedAt.Format(time.RFC3339),
+			ended,
+			strconv.FormatInt(s.BytesSent, 10),
+			strconv.FormatInt(s.BytesReceived, 10),
+			strconv.FormatInt(s.Total(), 10),
+		})
+	}
+
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		return fmt.Errorf("failed to write CSV: %w", err)
+	}
+	return nil
+}
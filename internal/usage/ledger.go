@@ -0,0 +1,341 @@
+// Package usage keeps a local ledger of VPN traffic in a BoltDB file. Byte
+// counts are recorded per session and per day and node, from which daily,
+// weekly and monthly rollups and data caps are computed.
+package usage
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+var (
+	sessionsBucket = []byte("sessions")
+	daysBucket     = []byte("days")
+	metaBucket     = []byte("meta")
+
+	capsKey = []byte("caps")
+)
+
+const (
+	dayFormat = "2006-01-02"
+
+	// flushInterval is how often pending byte counts are written to disk
+	flushInterval = 30 * time.Second
+)
+
+// Totals is a pair of byte counters
+type Totals struct {
+	BytesSent     int64 `json:"bytes_sent"`
+	BytesReceived int64 `json:"bytes_received"`
+}
+
+// Total returns the sum of sent and received bytes
+func (t Totals) Total() int64 {
+	return t.BytesSent + t.BytesReceived
+}
+
+func (t *Totals) add(o Totals) {
+	t.BytesSent += o.BytesSent
+	t.BytesReceived += o.BytesReceived
+}
+
+// Session is the traffic of a single VPN connection
+type Session struct {
+	ID        uint64    `json:"id"`
+	NodeID    string    `json:"node_id"`
+	NodeName  string    `json:"node_name"`
+	Protocol  string    `json:"protocol"`
+	StartedAt time.Time `json:"started_at"`
+	EndedAt   time.Time `json:"ended_at"`
+	Totals
+}
+
+// Ledger records traffic for the active session and answers usage queries
+type Ledger struct {
+	db *bolt.DB
+
+	mu        sync.Mutex
+	active    *Session
+	last      Totals
+	haveLast  bool
+	pending   map[string]map[string]Totals // day -> node -> bytes
+	lastFlush time.Time
+}
+
+// Open opens or creates the ledger at path
+func Open(path string) (*Ledger, error) {
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open usage ledger: %w", err)
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, name := range [][]byte{sessionsBucket, daysBucket, metaBucket} {
+			if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to initialize usage ledger: %w", err)
+	}
+
+	return &Ledger{db: db, pending: make(map[string]map[string]Totals)}, nil
+}
+
+// Close ends the active session and closes the database
+func (l *Ledger) Close() error {
+	if err := l.EndSession(time.Now()); err != nil {
+		l.db.Close()
+		return err
+	}
+	return l.db.Close()
+}
+
+// StartSession begins recording a new session, ending any active one
+func (l *Ledger) StartSession(nodeID, nodeName, protocol string, at time.Time) error {
+	if err := l.EndSession(at); err != nil {
+		return err
+	}
+
+	session := &Session{
+		NodeID:    nodeID,
+		NodeName:  nodeName,
+		Protocol:  protocol,
+		StartedAt: at,
+	}
+
+	err := l.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(sessionsBucket)
+		id, err := b.NextSequence()
+		if err != nil {
+			return err
+		}
+		session.ID = id
+		return putJSON(b, sessionKey(id), session)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to start usage session: %w", err)
+	}
+
+	l.mu.Lock()
+	l.active = session
+	l.haveLast = false
+	l.lastFlush = at
+	l.mu.Unlock()
+	return nil
+}
+
+// Record adds the traffic since the previous reading of the tunnel's
+// cumulative counters. The first reading of a session is only the baseline,
+// since an adopted tunnel's counters include traffic of earlier sessions.
+// Counters that went backwards are treated as having restarted from zero.
+func (l *Ledger) Record(sent, received int64, at time.Time) error {
+	l.mu.Lock()
+
+	if l.active == nil {
+		l.mu.Unlock()
+		return nil
+	}
+
+	current := Totals{BytesSent: sent, BytesReceived: received}
+	var delta Totals
+	if l.haveLast {
+		delta = Totals{
+			BytesSent:     counterDelta(l.last.BytesSent, sent),
+			BytesReceived: counterDelta(l.last.BytesReceived, received),
+		}
+	}
+	l.last = current
+	l.haveLast = true
+
+	l.active.add(delta)
+	l.addPending(at, l.active.NodeID, delta)
+
+	flush := at.Sub(l.lastFlush) >= flushInterval
+	l.mu.Unlock()
+
+	if flush {
+		return l.Flush(at)
+	}
+	return nil
+}
+
+// EndSession flushes and closes the active session
+func (l *Ledger) EndSession(at time.Time) error {
+	l.mu.Lock()
+	active := l.active
+	if active != nil {
+		active.EndedAt = at
+	}
+	l.mu.Unlock()
+
+	if active == nil {
+		return nil
+	}
+
+	err := l.Flush(at)
+
+	l.mu.Lock()
+	l.active = nil
+	l.mu.Unlock()
+	return err
+}
+
+// ActiveSession returns a copy of the session being recorded, if any
+func (l *Ledger) ActiveSession() (Session, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.active == nil {
+		return Session{}, false
+	}
+	return *l.active, true
+}
+
+// Flush writes pending per-day counts and the active session to disk
+func (l *Ledger) Flush(at time.Time) error {
+	l.mu.Lock()
+	pending := l.pending
+	l.pending = make(map[string]map[string]Totals)
+	l.lastFlush = at
+	var session *Session
+	if l.active != nil {
+		copied := *l.active
+		session = &copied
+	}
+	l.mu.Unlock()
+
+	err := l.db.Update(func(tx *bolt.Tx) error {
+		days := tx.Bucket(daysBucket)
+		for day, nodes := range pending {
+			b, err := days.CreateBucketIfNotExists([]byte(day))
+			if err != nil {
+				return err
+			}
+			for node, delta := range nodes {
+				var totals Totals
+				if err := getJSON(b, []byte(node), &totals); err != nil {
+					return err
+				}
+				totals.add(delta)
+				if err := putJSON(b, []byte(node), totals); err != nil {
+					return err
+				}
+			}
+		}
+
+		if session != nil {
+			return putJSON(tx.Bucket(sessionsBucket), sessionKey(session.ID), session)
+		}
+		return nil
+	})
+	if err != nil {
+		// Keep the counts so the next flush retries them
+		l.mu.Lock()
+		for day, nodes := range pending {
+			for node, delta := range nodes {
+				l.addPendingDay(day, node, delta)
+			}
+		}
+		l.mu.Unlock()
+		return fmt.Errorf("failed to write usage ledger: %w", err)
+	}
+	return nil
+}
+
+// Sessions returns sessions that started in [from, to), newest first. A
+// limit of zero returns all of them.
+func (l *Ledger) Sessions(from, to time.Time, limit int) ([]Session, error) {
+	var sessions []Session
+
+	err := l.db.View(func(tx *bolt.Tx) error {
+		c := tx.Bucket(sessionsBucket).Cursor()
+		for k, v := c.Last(); k != nil; k, v = c.Prev() {
+			var session Session
+			if err := json.Unmarshal(v, &session); err != nil {
+				return err
+			}
+			if !session.StartedAt.Before(to) || session.StartedAt.Before(from) {
+				continue
+			}
+			sessions = append(sessions, session)
+			if limit > 0 && len(sessions) >= limit {
+				break
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read sessions: %w", err)
+	}
+
+	// The active session has unflushed bytes
+	if active, ok := l.ActiveSession(); ok {
+		for i := range sessions {
+			if sessions[i].ID == active.ID {
+				sessions[i] = active
+			}
+		}
+	}
+
+	return sessions, nil
+}
+
+// addPending adds bytes to the in-memory counts for at's local day; l.mu
+// must be held
+func (l *Ledger) addPending(at time.Time, node string, delta Totals) {
+	l.addPendingDay(at.Format(dayFormat), node, delta)
+}
+
+// addPendingDay adds bytes to the in-memory counts; l.mu must be held
+func (l *Ledger) addPendingDay(day, node string, delta Totals) {
+	nodes, ok := l.pending[day]
+	if !ok {
+		nodes = make(map[string]Totals)
+		l.pending[day] = nodes
+	}
+	totals := nodes[node]
+	totals.add(delta)
+	nodes[node] = totals
+}
+
+// counterDelta returns the growth of a cumulative counter
+func counterDelta(prev, cur int64) int64 {
+	if cur < prev {
+		return cur
+	}
+	return cur - prev
+}
+
+// sessionKey encodes a session ID so keys sort chronologically
+func sessionKey(id uint64) []byte {
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, id)
+	return key
+}
+
+// putJSON stores v under key
+func putJSON(b *bolt.Bucket, key []byte, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return b.Put(key, data)
+}
+
+// getJSON loads the value under key into v, leaving v unchanged if missing
+func getJSON(b *bolt.Bucket, key []byte, v interface{}) error {
+	data := b.Get(key)
+	if data == nil {
+		return nil
+	}
+	return json.Unmarshal(data, v)
+}
@@ -0,0 +1,147 @@
+package usage
+
+import (
+	"fmt"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// Rollup periods
+const (
+	PeriodDay   = "day"
+	PeriodWeek  = "week"
+	PeriodMonth = "month"
+)
+
+// Rollup is the traffic within one period, in total and per node
+type Rollup struct {
+	Period string            `json:"period"`
+	Start  time.Time         `json:"start"`
+	End    time.Time         `json:"end"`
+	Nodes  map[string]Totals `json:"nodes"`
+	Totals
+}
+
+// PeriodBounds returns the local-time period containing t. Weeks start on
+// Monday.
+func PeriodBounds(period string, t time.Time) (start, end time.Time, err error) {
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+
+	switch period {
+	case PeriodDay:
+		return day, day.AddDate(0, 0, 1), nil
+	case PeriodWeek:
+		offset := (int(day.Weekday()) + 6) % 7
+		start = day.AddDate(0, 0, -offset)
+		return start, start.AddDate(0, 0, 7), nil
+	case PeriodMonth:
+		start = time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+		return start, start.AddDate(0, 1, 0), nil
+	default:
+		return time.Time{}, time.Time{}, fmt.Errorf("unknown usage period: %s", period)
+	}
+}
+
+// Rollups returns the last count periods ending with the one containing
+// now, oldest first
+func (l *Ledger) Rollups(period string, count int, now time.Time) ([]Rollup, error) {
+	if count < 1 {
+		count = 1
+	}
+
+	start, end, err := PeriodBounds(period, now)
+	if err != nil {
+		return nil, err
+	}
+
+	rollups := make([]Rollup, count)
+	for i := count - 1; i >= 0; i-- {
+		rollups[i] = Rollup{Period: period, Start: start, End: end, Nodes: make(map[string]Totals)}
+		end = start
+		start, _, _ = PeriodBounds(period, start.Add(-time.Nanosecond))
+	}
+
+	days, err := l.days(rollups[0].Start, rollups[count-1].End)
+	if err != nil {
+		return nil, err
+	}
+
+	for day, nodes := range days {
+		t, err := time.ParseInLocation(dayFormat, day, now.Location())
+		if err != nil {
+			continue
+		}
+		for i := range rollups {
+			if t.Before(rollups[i].Start) || !t.Before(rollups[i].End) {
+				continue
+			}
+			for node, totals := range nodes {
+				rollups[i].Totals.add(totals)
+				nodeTotals := rollups[i].Nodes[node]
+				nodeTotals.add(totals)
+				rollups[i].Nodes[node] = nodeTotals
+			}
+		}
+	}
+
+	return rollups, nil
+}
+
+// days returns per-node counts for each day in [from, to), including bytes
+// that have not been flushed yet
+func (l *Ledger) days(from, to time.Time) (map[string]map[string]Totals, error) {
+	result := make(map[string]map[string]Totals)
+	first := []byte(from.Format(dayFormat))
+	last := to.Format(dayFormat)
+
+	add := func(day, node string, totals Totals) {
+		nodes, ok := result[day]
+		if !ok {
+			nodes = make(map[string]Totals)
+			result[day] = nodes
+		}
+		sum := nodes[node]
+		sum.add(totals)
+		nodes[node] = sum
+	}
+
+	err := l.db.View(func(tx *bolt.Tx) error {
+		days := tx.Bucket(daysBucket)
+		c := days.Cursor()
+		for k, _ := c.Seek(first); k != nil && string(k) < last; k, _ = c.Next() {
+			b := days.Bucket(k)
+			if b == nil {
+				continue
+			}
+			err := b.ForEach(func(node, _ []byte) error {
+				var totals Totals
+				if err := getJSON(b, node, &totals); err != nil {
+					return err
+				}
+				add(string(k), string(node), totals)
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read daily usage: %w", err)
+	}
+
+	l.mu.Lock()
+	for day, nodes := range l.pending {
+		if day < string(first) || day >= last {
+			continue
+		}
+		for node, totals := range nodes {
+			add(day, node, totals)
+		}
+	}
+	l.mu.Unlock()
+
+	return result, nil
+}
@@ -0,0 +1,111 @@
+package vpn
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// Backend names
+const (
+	BackendWgQuick       = "wg-quick"
+	BackendTunnelService = "tunnel-service"
+	BackendNetlink       = "netlink"
+	BackendUserspace     = "userspace"
+	BackendFake          = "fake"
+)
+
+// Backend describes a WireGuard tunnel implementation that can be selected at startup
+type Backend struct {
+	Name string
+	// Priority orders automatic selection; lower values are preferred
+	Priority int
+	// ExplicitOnly backends are only selected when requested by name
+	ExplicitOnly bool
+	// Probe returns nil if the backend can run on this host
+	Probe func() error
+	// New creates a tunnel for the named WireGuard interface
+	New func(configDir, iface string) (WireGuardTunnel, error)
+}
+
+// BackendInfo reports a backend's availability on this host
+type BackendInfo struct {
+	Name      string `json:"name"`
+	Available bool   `json:"available"`
+	Reason    string `json:"reason,omitempty"`
+}
+
+var (
+	backendsMu sync.Mutex
+	backends   = map[string]Backend{}
+)
+
+// RegisterBackend makes a backend available for selection
+func RegisterBackend(b Backend) {
+	backendsMu.Lock()
+	defer backendsMu.Unlock()
+	backends[b.Name] = b
+}
+
+// Backends returns all registered backends ordered by priority
+func Backends() []Backend {
+	backendsMu.Lock()
+	defer backendsMu.Unlock()
+
+	list := make([]Backend, 0, len(backends))
+	for _, b := range backends {
+		list = append(list, b)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Priority < list[j].Priority
+	})
+	return list
+}
+
+// ProbeBackends reports the availability of every registered backend
+func ProbeBackends() []BackendInfo {
+	var infos []BackendInfo
+	for _, b := range Backends() {
+		info := BackendInfo{Name: b.Name, Available: true}
+		if err := b.Probe(); err != nil {
+			info.Available = false
+			info.Reason = err.Error()
+		}
+		infos = append(infos, info)
+	}
+	return infos
+}
+
+// SelectBackend returns the preferred backend if it is available, otherwise
+// the highest priority backend that passes its capability probe
+func SelectBackend(preferred string) (Backend, error) {
+	if preferred != "" {
+		backendsMu.Lock()
+		b, ok := backends[preferred]
+		backendsMu.Unlock()
+		if !ok {
+			return Backend{}, fmt.Errorf("unknown tunnel backend: %s", preferred)
+		}
+		err := b.Probe()
+		if err == nil {
+			return b, nil
+		}
+		logger.Warn("preferred tunnel backend unavailable", "backend", preferred, "error", err)
+	}
+
+	var reasons []string
+	for _, b := range Backends() {
+		if b.ExplicitOnly {
+			continue
+		}
+		err := b.Probe()
+		if err == nil {
+			logger.Info("selected tunnel backend", "backend", b.Name)
+			return b, nil
+		}
+		logger.Debug("tunnel backend unavailable", "backend", b.Name, "error", err)
+		reasons = append(reasons, fmt.Sprintf("%s: %v", b.Name, err))
+	}
+
+	return Backend{}, fmt.Errorf("no tunnel backend available (%v)", reasons)
+}
@@ -0,0 +1,105 @@
+package vpn
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// FakeTunnel is an in-memory WireGuardTunnel for tests and UI development.
+// It never touches the network; counters advance only when set by the caller.
+type FakeTunnel struct {
+	iface string
+
+	mu       sync.Mutex
+	config   TunnelConfig
+	up       bool
+	counters Stats
+
+	// UpErr and DownErr are returned by Up and Down when set
+	UpErr   error
+	DownErr error
+}
+
+// NewFakeTunnel creates a fake tunnel for the named interface
+func NewFakeTunnel(iface string) *FakeTunnel {
+	return &FakeTunnel{iface: iface}
+}
+
+// Name returns the backend name
+func (t *FakeTunnel) Name() string {
+	return BackendFake
+}
+
+// Protocol returns the protocol name for WireGuard tunnels
+func (t *FakeTunnel) Protocol() string {
+	return ProtocolWireGuard
+}
+
+// Interface returns the interface name
+func (t *FakeTunnel) Interface() string {
+	return t.iface
+}
+
+// Configure records the config used by the next Up
+func (t *FakeTunnel) Configure(cfg TunnelConfig) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.config = cfg.cloneKeys()
+	return nil
+}
+
+// Config returns the last applied config
+func (t *FakeTunnel) Config() TunnelConfig {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.config
+}
+
+// Up marks the tunnel as connected and records a handshake
+func (t *FakeTunnel) Up(ctx context.Context) error {
+	if t.UpErr != nil {
+		return t.UpErr
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.up = true
+	t.counters = Stats{LastHandshake: time.Now(), Endpoint: t.config.Endpoint}
+	return nil
+}
+
+// Down marks the tunnel as disconnected
+func (t *FakeTunnel) Down(ctx context.Context) error {
+	if t.DownErr != nil {
+		return t.DownErr
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.up = false
+	return nil
+}
+
+// IsConnected reports whether Up has been called without a matching Down
+func (t *FakeTunnel) IsConnected() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.up
+}
+
+// Stats returns the counters set with SetCounters
+func (t *FakeTunnel) Stats(ctx context.Context) (Stats, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	stats := t.counters
+	stats.Connected = t.up
+	return stats, nil
+}
+
+// SetCounters replaces the counters reported by Stats
+func (t *FakeTunnel) SetCounters(stats Stats) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.counters = stats
+}
@@ -0,0 +1,15 @@
+//go:build fakebackend
+
+package vpn
+
+func init() {
+	RegisterBackend(Backend{
+		Name:         BackendFake,
+		Priority:     100,
+		ExplicitOnly: true,
+		Probe:        func() error { return nil },
+		New: func(configDir, iface string) (WireGuardTunnel, error) {
+			return NewFakeTunnel(iface), nil
+		},
+	})
+}
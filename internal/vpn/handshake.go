@@ -0,0 +1,83 @@
+package vpn
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"time"
+)
+
+// handshakePollInterval is how often WaitForHandshake checks the counters
+const handshakePollInterval = 250 * time.Millisecond
+
+// CommonWireGuardPorts are tried after the ports a node advertises, as
+// networks that block other UDP traffic usually leave DNS and QUIC open
+var CommonWireGuardPorts = []int{443, 53, 51820}
+
+// ErrNoHandshake is returned when a tunnel came up but the peer never
+// completed a handshake, typically because UDP to its port is blocked
+var ErrNoHandshake = errors.New("no handshake with VPN server")
+
+// EndpointCandidates returns the endpoints to try for a node, in order: the
+// endpoint as given, the same host on each advertised port, then on each
+// common port. Duplicates are skipped.
+func EndpointCandidates(endpoint string, advertised []int) ([]string, error) {
+	host, port, err := net.SplitHostPort(endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid endpoint %q: %w", endpoint, err)
+	}
+
+	candidates := []string{endpoint}
+	seen := map[string]bool{port: true}
+	for _, p := range append(append([]int{}, advertised...), CommonWireGuardPorts...) {
+		s := strconv.Itoa(p)
+		if p <= 0 || p > 65535 || seen[s] {
+			continue
+		}
+		seen[s] = true
+		candidates = append(candidates, net.JoinHostPort(host, s))
+	}
+	return candidates, nil
+}
+
+// WaitForHandshake waits until the tunnel reports a handshake with its peer.
+// It returns ErrNoHandshake if the stats could be read but showed no
+// handshake within timeout. If they could never be read, e.g. because wg
+// needs a password for sudo, the handshake is unknown and it returns nil,
+// keeping the tunnel.
+func WaitForHandshake(ctx context.Context, tunnel Tunnel, timeout time.Duration) error {
+	waitCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	ticker := time.NewTicker(handshakePollInterval)
+	defer ticker.Stop()
+
+	var readErr error
+	read := false
+	for {
+		stats, err := tunnel.Stats(waitCtx)
+		if err == nil {
+			read = true
+			if !stats.LastHandshake.IsZero() {
+				return nil
+			}
+		} else {
+			readErr = err
+		}
+
+		select {
+		case <-waitCtx.Done():
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			if !read {
+				logger.Warn("could not read tunnel stats, assuming the handshake succeeded", "error", readErr)
+				return nil
+			}
+			return ErrNoHandshake
+		case <-ticker.C:
+		}
+	}
+}
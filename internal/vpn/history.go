@@ -0,0 +1,119 @@
+package vpn
+
+import (
+	"sync"
+	"time"
+)
+
+// Sample is a point in a tunnel's throughput history
+type Sample struct {
+	Time          time.Time `json:"time"`
+	BytesSent     int64     `json:"bytes_sent"`
+	BytesReceived int64     `json:"bytes_received"`
+	RxRate        float64   `json:"rx_rate"`
+	TxRate        float64   `json:"tx_rate"`
+}
+
+// StatsHistory keeps the most recent samples in a fixed-size ring buffer and
+// computes throughput from consecutive counter readings
+type StatsHistory struct {
+	mu      sync.Mutex
+	samples []Sample
+	next    int
+	full    bool
+}
+
+// NewStatsHistory creates a history holding up to capacity samples
+func NewStatsHistory(capacity int) *StatsHistory {
+	if capacity < 1 {
+		capacity = 1
+	}
+	return &StatsHistory{samples: make([]Sample, capacity)}
+}
+
+// Add records counters read at the given time and returns the stats with
+// rates filled in. Counters that went backwards (e.g. after a reconnect)
+// yield a zero rate for that interval.
+func (h *StatsHistory) Add(stats Stats, at time.Time) Stats {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	sample := Sample{
+		Time:          at,
+		BytesSent:     stats.BytesSent,
+		BytesReceived: stats.BytesReceived,
+	}
+
+	if prev, ok := h.latest(); ok {
+		elapsed := at.Sub(prev.Time).Seconds()
+		if elapsed > 0 {
+			sample.TxRate = rate(prev.BytesSent, stats.BytesSent, elapsed)
+			sample.RxRate = rate(prev.BytesReceived, stats.BytesReceived, elapsed)
+		}
+	}
+
+	h.samples[h.next] = sample
+	h.next = (h.next + 1) % len(h.samples)
+	if h.next == 0 {
+		h.full = true
+	}
+
+	stats.TxRate = sample.TxRate
+	stats.RxRate = sample.RxRate
+	return stats
+}
+
+// Latest returns the most recent sample
+func (h *StatsHistory) Latest() (Sample, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.latest()
+}
+
+// Since returns the samples taken after t, oldest first
+func (h *StatsHistory) Since(t time.Time) []Sample {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	var result []Sample
+	for _, sample := range h.ordered() {
+		if sample.Time.After(t) {
+			result = append(result, sample)
+		}
+	}
+	return result
+}
+
+// Reset discards all samples
+func (h *StatsHistory) Reset() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.samples = make([]Sample, len(h.samples))
+	h.next = 0
+	h.full = false
+}
+
+// latest returns the most recent sample; h.mu must be held
+func (h *StatsHistory) latest() (Sample, bool) {
+	if h.next == 0 && !h.full {
+		return Sample{}, false
+	}
+	return h.samples[(h.next-1+len(h.samples))%len(h.samples)], true
+}
+
+// ordered returns the stored samples oldest first; h.mu must be held
+func (h *StatsHistory) ordered() []Sample {
+	if !h.full {
+		return append([]Sample(nil), h.samples[:h.next]...)
+	}
+	return append(append([]Sample(nil), h.samples[h.next:]...), h.samples[:h.next]...)
+}
+
+// rate returns bytes per second between two counter readings
+func rate(prev, cur int64, seconds float64) float64 {
+	if cur < prev {
+		return 0
+	}
+	return float64(cur-prev) / seconds
+}
@@ -0,0 +1,26 @@
+package vpn
+
+// Tunnel MTU bounds. IPv6 inside the tunnel needs at least 1280.
+const (
+	MinTunnelMTU = 1280
+	MaxTunnelMTU = linkMTU - wireGuardOverheadIPv4
+)
+
+// TunnelMTU derives the WireGuard interface MTU from the path MTU to the
+// endpoint, less the encapsulation overhead of the outer IP version. The
+// result is clamped to [MinTunnelMTU, MaxTunnelMTU].
+func TunnelMTU(pathMTU int, ipv6 bool) int {
+	overhead := wireGuardOverheadIPv4
+	if ipv6 {
+		overhead = wireGuardOverheadIPv6
+	}
+
+	mtu := pathMTU - overhead
+	if mtu < MinTunnelMTU {
+		return MinTunnelMTU
+	}
+	if mtu > MaxTunnelMTU {
+		return MaxTunnelMTU
+	}
+	return mtu
+}
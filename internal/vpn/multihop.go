@@ -0,0 +1,309 @@
+package vpn
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/nikola43/aureo-vpn-client/internal/secrets"
+)
+
+const (
+	entryInterface = "wgentry"
+	exitInterface  = "wgexit"
+
+	// multiHopFwMark marks the entry tunnel's packets. The exit tunnel
+	// carries the same mark, which makes wg-quick use it as the number of
+	// its routing table, so marked packets skip the table's policy rule.
+	multiHopFwMark = "51820"
+
+	// linkMTU is the assumed MTU of the physical link
+	linkMTU = 1500
+
+	// WireGuard adds a 32 byte header and 8 byte UDP header on top of the
+	// outer IP header (20 bytes for IPv4, 40 bytes for IPv6)
+	wireGuardOverheadIPv4 = 60
+	wireGuardOverheadIPv6 = 80
+)
+
+// Hop describes one layer of a multi-hop chain as returned by peer registration
+type Hop struct {
+	PrivateKey      secrets.Buffer
+	ClientIP        string
+	ServerPublicKey string
+	ServerEndpoint  string
+	DNS             string
+	PresharedKey    secrets.Buffer
+}
+
+// WipeKeys clears the hop's private and pre-shared keys
+func (h *Hop) WipeKeys() {
+	h.PrivateKey.Wipe()
+	h.PresharedKey.Wipe()
+}
+
+// MultiHopPlan holds the computed interface configs for a multi-hop chain
+type MultiHopPlan struct {
+	Entry TunnelConfig
+	Exit  TunnelConfig
+}
+
+// PlanMultiHop computes routes and MTUs for an entry/exit chain. The entry
+// tunnel only routes the exit endpoint, and the exit tunnel carries all
+// traffic with an MTU reduced by the entry tunnel's encapsulation.
+func PlanMultiHop(entry, exit Hop) (*MultiHopPlan, error) {
+	entryIP, err := resolveEndpoint(entry.ServerEndpoint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid entry endpoint: %w", err)
+	}
+
+	exitIP, err := resolveEndpoint(exit.ServerEndpoint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid exit endpoint: %w", err)
+	}
+
+	_, entryPort, _ := net.SplitHostPort(entry.ServerEndpoint)
+	_, exitPort, _ := net.SplitHostPort(exit.ServerEndpoint)
+
+	entryMTU := linkMTU - transportOverhead(entryIP)
+	exitMTU := entryMTU - transportOverhead(exitIP)
+
+	exitRoute := exitIP.String() + "/32"
+	if exitIP.To4() == nil {
+		exitRoute = exitIP.String() + "/128"
+	}
+
+	plan := &MultiHopPlan{
+		Entry: TunnelConfig{
+			PrivateKey:    entry.PrivateKey,
+			Address:       entry.ClientIP,
+			MTU:           entryMTU,
+			PeerPublicKey: entry.ServerPublicKey,
+			PresharedKey:  entry.PresharedKey,
+			Endpoint:      net.JoinHostPort(entryIP.String(), entryPort),
+			AllowedIPs:    []string{exitRoute},
+			Keepalive:     25,
+		},
+		Exit: TunnelConfig{
+			PrivateKey:    exit.PrivateKey,
+			Address:       exit.ClientIP,
+			DNS:           exit.DNS,
+			MTU:           exitMTU,
+			PeerPublicKey: exit.ServerPublicKey,
+			PresharedKey:  exit.PresharedKey,
+			Endpoint:      net.JoinHostPort(exitIP.String(), exitPort),
+			AllowedIPs:    []string{"0.0.0.0/0"},
+			Keepalive:     25,
+		},
+	}
+
+	return plan, nil
+}
+
+// MultiHopTunnel chains two WireGuard interfaces so that the exit tunnel's
+// endpoint is reached through the entry tunnel
+type MultiHopTunnel struct {
+	entry WireGuardTunnel
+	exit  WireGuardTunnel
+}
+
+// NewMultiHop plans the chain and configures both interfaces on the given backend
+func NewMultiHop(backend Backend, configDir string, entry, exit Hop) (*MultiHopTunnel, error) {
+	// A userspace exit tunnel would send its packets over the host network,
+	// not through the entry tunnel's netstack
+	if backend.Name == BackendUserspace {
+		return nil, fmt.Errorf("multi-hop requires a kernel tunnel backend")
+	}
+
+	plan, err := PlanMultiHop(entry, exit)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := prepareMultiHop(entryInterface, &plan.Entry, &plan.Exit); err != nil {
+		return nil, err
+	}
+
+	t, err := newMultiHopTunnel(backend, configDir)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := t.entry.Configure(plan.Entry); err != nil {
+		t.Down(context.Background())
+		return nil, err
+	}
+	if err := t.exit.Configure(plan.Exit); err != nil {
+		// Shred the entry config, which holds its keys
+		t.Down(context.Background())
+		return nil, err
+	}
+
+	return t, nil
+}
+
+// newMultiHopTunnel creates the entry and exit interfaces without configuring them
+func newMultiHopTunnel(backend Backend, configDir string) (*MultiHopTunnel, error) {
+	entryTunnel, err := backend.New(configDir, entryInterface)
+	if err != nil {
+		return nil, err
+	}
+	exitTunnel, err := backend.New(configDir, exitInterface)
+	if err != nil {
+		return nil, err
+	}
+	return &MultiHopTunnel{entry: entryTunnel, exit: exitTunnel}, nil
+}
+
+// Name returns the backend name of the underlying interfaces
+func (t *MultiHopTunnel) Name() string {
+	return t.exit.Name()
+}
+
+// Protocol returns the protocol name for WireGuard tunnels
+func (t *MultiHopTunnel) Protocol() string {
+	return ProtocolWireGuard
+}
+
+// SystemInterface returns the exit tunnel's interface, which carries user traffic
+func (t *MultiHopTunnel) SystemInterface() (string, error) {
+	bound, ok := t.exit.(BoundTunnel)
+	if !ok {
+		return "", fmt.Errorf("backend %s has no system interface", t.exit.Name())
+	}
+	return bound.SystemInterface()
+}
+
+// DNSServers returns the exit tunnel's DNS servers
+func (t *MultiHopTunnel) DNSServers() []string {
+	if dns, ok := t.exit.(DNSTunnel); ok {
+		return dns.DNSServers()
+	}
+	return nil
+}
+
+// Up brings up the entry tunnel and then the exit tunnel nested inside it
+func (t *MultiHopTunnel) Up(ctx context.Context) error {
+	if err := t.entry.Up(ctx); err != nil {
+		return fmt.Errorf("entry tunnel: %w", err)
+	}
+
+	if err := t.exit.Up(ctx); err != nil {
+		t.entry.Down(ctx)
+		return fmt.Errorf("exit tunnel: %w", err)
+	}
+
+	return nil
+}
+
+// Down tears down the exit tunnel and then the entry tunnel
+func (t *MultiHopTunnel) Down(ctx context.Context) error {
+	exitErr := t.exit.Down(ctx)
+	entryErr := t.entry.Down(ctx)
+
+	if exitErr != nil {
+		return exitErr
+	}
+	return entryErr
+}
+
+// IsConnected reports whether the exit tunnel is up
+func (t *MultiHopTunnel) IsConnected() bool {
+	return t.exit.IsConnected()
+}
+
+// Stats returns the exit tunnel's counters, which carry user traffic. The
+// chain only counts as connected while both layers are up.
+func (t *MultiHopTunnel) Stats(ctx context.Context) (Stats, error) {
+	entry, exit, err := t.HopStats(ctx)
+	if err != nil {
+		return Stats{}, err
+	}
+
+	exit.Connected = entry.Connected && exit.Connected
+	return exit, nil
+}
+
+// HopStats returns the counters of each layer of the chain
+func (t *MultiHopTunnel) HopStats(ctx context.Context) (entry, exit Stats, err error) {
+	entry, err = t.entry.Stats(ctx)
+	if err != nil {
+		return Stats{}, Stats{}, err
+	}
+	exit, err = t.exit.Stats(ctx)
+	if err != nil {
+		return Stats{}, Stats{}, err
+	}
+	return entry, exit, nil
+}
+
+// DumpState returns the state of both layers of the chain
+func (t *MultiHopTunnel) DumpState(ctx context.Context) (string, error) {
+	var b strings.Builder
+	for _, layer := range []WireGuardTunnel{t.entry, t.exit} {
+		dumper, ok := layer.(StateDumper)
+		if !ok {
+			continue
+		}
+		state, err := dumper.DumpState(ctx)
+		if err != nil {
+			fmt.Fprintf(&b, "%s: %v\n", layer.Interface(), err)
+			continue
+		}
+		b.WriteString(state)
+		b.WriteString("\n")
+	}
+	return b.String(), nil
+}
+
+// RestoreTunnel returns the tunnel left up by a previous run, or nil
+func RestoreTunnel(backend Backend, configDir string) Tunnel {
+	if multiHop, err := newMultiHopTunnel(backend, configDir); err == nil && multiHop.IsConnected() {
+		return multiHop
+	}
+
+	if t, err := backend.New(configDir, DefaultInterface); err == nil && t.IsConnected() {
+		return t
+	}
+
+	return nil
+}
+
+// resolveEndpoint resolves the host part of a host:port endpoint to an IP
+func resolveEndpoint(endpoint string) (net.IP, error) {
+	host, port, err := net.SplitHostPort(endpoint)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := strconv.Atoi(port); err != nil {
+		return nil, fmt.Errorf("invalid port %q", port)
+	}
+
+	if ip := net.ParseIP(host); ip != nil {
+		return ip, nil
+	}
+
+	ips, err := net.LookupIP(host)
+	if err != nil {
+		return nil, err
+	}
+	for _, ip := range ips {
+		if ip.To4() != nil {
+			return ip, nil
+		}
+	}
+	if len(ips) == 0 {
+		return nil, fmt.Errorf("no addresses found for %s", host)
+	}
+	return ips[0], nil
+}
+
+// transportOverhead returns the WireGuard encapsulation overhead for an outer IP
+func transportOverhead(ip net.IP) int {
+	if ip.To4() != nil {
+		return wireGuardOverheadIPv4
+	}
+	return wireGuardOverheadIPv6
+}
@@ -0,0 +1,487 @@
+//go:build linux
+
+package vpn
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/vishvananda/netlink"
+	"golang.org/x/sys/unix"
+	"golang.zx2c4.com/wireguard/wgctrl"
+	"golang.zx2c4.com/wireguard/wgctrl/wgtypes"
+)
+
+const (
+	// fullTunnelTable is the routing table used for default routes, matching wg-quick
+	fullTunnelTable = 51820
+
+	// capNetAdmin is the CAP_NET_ADMIN bit in the capability sets
+	capNetAdmin = 12
+)
+
+// netlinkTunnel configures a kernel WireGuard interface directly over
+// netlink, without wg-quick or a config file on disk
+type netlinkTunnel struct {
+	iface string
+
+	mu     sync.Mutex
+	config *TunnelConfig
+}
+
+func init() {
+	RegisterBackend(Backend{
+		Name:     BackendNetlink,
+		Priority: 10,
+		Probe:    probeNetlink,
+		New: func(configDir, iface string) (WireGuardTunnel, error) {
+			return &netlinkTunnel{iface: iface}, nil
+		},
+	})
+}
+
+// probeNetlink checks for CAP_NET_ADMIN and kernel WireGuard support
+func probeNetlink() error {
+	if !hasCapability(capNetAdmin) {
+		return fmt.Errorf("CAP_NET_ADMIN required")
+	}
+
+	if _, err := os.Stat("/sys/module/wireguard"); err == nil {
+		return nil
+	}
+	if _, err := netlink.GenlFamilyGet("wireguard"); err != nil {
+		return fmt.Errorf("kernel WireGuard module not available")
+	}
+	return nil
+}
+
+// hasCapability reports whether the process has a capability in its effective set
+func hasCapability(bit uint) bool {
+	f, err := os.Open("/proc/self/status")
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, "CapEff:") {
+			continue
+		}
+		caps, err := strconv.ParseUint(strings.TrimSpace(strings.TrimPrefix(line, "CapEff:")), 16, 64)
+		if err != nil {
+			return false
+		}
+		return caps&(1<<bit) != 0
+	}
+	return false
+}
+
+// Name returns the backend name
+func (t *netlinkTunnel) Name() string {
+	return BackendNetlink
+}
+
+// Protocol returns the protocol name for WireGuard tunnels
+func (t *netlinkTunnel) Protocol() string {
+	return ProtocolWireGuard
+}
+
+// Interface returns the WireGuard interface name
+func (t *netlinkTunnel) Interface() string {
+	return t.iface
+}
+
+// SystemInterface returns the kernel interface name
+func (t *netlinkTunnel) SystemInterface() (string, error) {
+	return t.iface, nil
+}
+
+// DNSServers returns the DNS servers of the applied config
+func (t *netlinkTunnel) DNSServers() []string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.config == nil {
+		return nil
+	}
+	return t.config.nameservers()
+}
+
+// Configure stores a copy of the config in memory for the next Up; the keys
+// are passed to the kernel over netlink and never written to disk
+func (t *netlinkTunnel) Configure(cfg TunnelConfig) error {
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.config != nil {
+		t.config.WipeKeys()
+	}
+	cfg = cfg.cloneKeys()
+	t.config = &cfg
+	return nil
+}
+
+// Up creates the interface, configures the peer, and installs addresses and routes
+func (t *netlinkTunnel) Up(ctx context.Context) error {
+	t.mu.Lock()
+	cfg := t.config
+	t.mu.Unlock()
+	if cfg == nil {
+		return fmt.Errorf("tunnel %s is not configured", t.iface)
+	}
+
+	deviceConfig, fullTunnel, err := t.deviceConfig(cfg)
+	if err != nil {
+		return err
+	}
+	defer wipeDeviceKeys(&deviceConfig)
+
+	// Remove any stale interface left over from a previous run
+	if link, err := netlink.LinkByName(t.iface); err == nil {
+		netlink.LinkDel(link)
+	}
+
+	mtu := cfg.MTU
+	if mtu == 0 {
+		mtu = linkMTU - wireGuardOverheadIPv6
+	}
+
+	link := &netlink.Wireguard{LinkAttrs: netlink.LinkAttrs{Name: t.iface, MTU: mtu}}
+	if err := netlink.LinkAdd(link); err != nil {
+		return fmt.Errorf("failed to create interface %s: %w", t.iface, err)
+	}
+
+	if err := t.setup(ctx, link, cfg, deviceConfig, fullTunnel); err != nil {
+		t.teardown()
+		return err
+	}
+
+	return nil
+}
+
+// setup applies the device config, address, routes and DNS to a new link
+func (t *netlinkTunnel) setup(ctx context.Context, link netlink.Link, cfg *TunnelConfig, deviceConfig wgtypes.Config, fullTunnel bool) error {
+	client, err := wgctrl.New()
+	if err != nil {
+		return fmt.Errorf("failed to open wgctrl: %w", err)
+	}
+	defer client.Close()
+
+	if err := client.ConfigureDevice(t.iface, deviceConfig); err != nil {
+		return fmt.Errorf("failed to configure %s: %w", t.iface, err)
+	}
+
+	addr, err := netlink.ParseAddr(cfg.Address + "/32")
+	if err != nil {
+		return fmt.Errorf("invalid address %q: %w", cfg.Address, err)
+	}
+	if err := netlink.AddrAdd(link, addr); err != nil {
+		return fmt.Errorf("failed to add address: %w", err)
+	}
+
+	if err := netlink.LinkSetUp(link); err != nil {
+		return fmt.Errorf("failed to bring up %s: %w", t.iface, err)
+	}
+
+	for _, peer := range deviceConfig.Peers {
+		for _, allowed := range peer.AllowedIPs {
+			ones, _ := allowed.Mask.Size()
+			if ones == 0 {
+				continue
+			}
+			dst := allowed
+			route := &netlink.Route{LinkIndex: link.Attrs().Index, Dst: &dst, Scope: netlink.SCOPE_LINK}
+			if err := netlink.RouteReplace(route); err != nil {
+				return fmt.Errorf("failed to add route %s: %w", dst.String(), err)
+			}
+		}
+	}
+
+	if fullTunnel {
+		if err := t.addDefaultRoute(link); err != nil {
+			return err
+		}
+	}
+
+	if cfg.DNS != "" {
+		setLinkDNS(ctx, t.iface, cfg.DNS)
+	}
+
+	return nil
+}
+
+// addDefaultRoute routes all traffic through the link using a dedicated table
+// and policy rules, like wg-quick: packets carrying the tunnel's fwmark (the
+// encrypted WireGuard traffic itself) keep using the main table
+func (t *netlinkTunnel) addDefaultRoute(link netlink.Link) error {
+	_, defaultDst, _ := net.ParseCIDR("0.0.0.0/0")
+	route := &netlink.Route{LinkIndex: link.Attrs().Index, Dst: defaultDst, Table: fullTunnelTable, Scope: netlink.SCOPE_LINK}
+	if err := netlink.RouteReplace(route); err != nil {
+		return fmt.Errorf("failed to add default route: %w", err)
+	}
+
+	notMarked := netlink.NewRule()
+	notMarked.Family = netlink.FAMILY_V4
+	notMarked.Mark = fullTunnelTable
+	notMarked.Invert = true
+	notMarked.Table = fullTunnelTable
+	if err := netlink.RuleAdd(notMarked); err != nil {
+		return fmt.Errorf("failed to add routing rule: %w", err)
+	}
+
+	suppress := netlink.NewRule()
+	suppress.Family = netlink.FAMILY_V4
+	suppress.Table = unix.RT_TABLE_MAIN
+	suppress.SuppressPrefixlen = 0
+	if err := netlink.RuleAdd(suppress); err != nil {
+		return fmt.Errorf("failed to add routing rule: %w", err)
+	}
+
+	return nil
+}
+
+// deviceConfig converts a TunnelConfig into a wgctrl device config
+func (t *netlinkTunnel) deviceConfig(cfg *TunnelConfig) (wgtypes.Config, bool, error) {
+	peerKey, err := wgtypes.ParseKey(cfg.PeerPublicKey)
+	if err != nil {
+		return wgtypes.Config{}, false, fmt.Errorf("invalid peer public key: %w", err)
+	}
+
+	endpoint, err := net.ResolveUDPAddr("udp", cfg.Endpoint)
+	if err != nil {
+		return wgtypes.Config{}, false, fmt.Errorf("invalid endpoint %q: %w", cfg.Endpoint, err)
+	}
+
+	fullTunnel := false
+	var allowedIPs []net.IPNet
+	for _, cidr := range cfg.AllowedIPs {
+		_, ipNet, err := net.ParseCIDR(strings.TrimSpace(cidr))
+		if err != nil {
+			return wgtypes.Config{}, false, fmt.Errorf("invalid allowed IP %q: %w", cidr, err)
+		}
+		if ones, _ := ipNet.Mask.Size(); ones == 0 {
+			fullTunnel = true
+		}
+		allowedIPs = append(allowedIPs, *ipNet)
+	}
+
+	var fwMark *int
+	if cfg.FwMark != "" {
+		mark, err := strconv.ParseInt(cfg.FwMark, 0, 32)
+		if err != nil {
+			return wgtypes.Config{}, false, fmt.Errorf("invalid fwmark %q: %w", cfg.FwMark, err)
+		}
+		m := int(mark)
+		fwMark = &m
+	} else if fullTunnel {
+		m := fullTunnelTable
+		fwMark = &m
+	}
+
+	keepalive := time.Duration(cfg.Keepalive) * time.Second
+
+	// Decode the keys last so no error path leaves them behind
+	privateKey, err := decodeKey(cfg.PrivateKey)
+	if err != nil {
+		return wgtypes.Config{}, false, fmt.Errorf("invalid private key: %w", err)
+	}
+
+	var psk *wgtypes.Key
+	if len(cfg.PresharedKey) > 0 {
+		key, err := decodeKey(cfg.PresharedKey)
+		if err != nil {
+			clear(privateKey[:])
+			return wgtypes.Config{}, false, fmt.Errorf("invalid pre-shared key: %w", err)
+		}
+		psk = &key
+	}
+
+	return wgtypes.Config{
+		PrivateKey:   &privateKey,
+		FirewallMark: fwMark,
+		ReplacePeers: true,
+		Peers: []wgtypes.PeerConfig{{
+			PublicKey:                   peerKey,
+			PresharedKey:                psk,
+			Endpoint:                    endpoint,
+			AllowedIPs:                  allowedIPs,
+			ReplaceAllowedIPs:           true,
+			PersistentKeepaliveInterval: &keepalive,
+		}},
+	}, fullTunnel, nil
+}
+
+// wipeDeviceKeys clears the keys of a device config once it has been applied
+func wipeDeviceKeys(cfg *wgtypes.Config) {
+	if cfg.PrivateKey != nil {
+		clear(cfg.PrivateKey[:])
+	}
+	for _, peer := range cfg.Peers {
+		if peer.PresharedKey != nil {
+			clear(peer.PresharedKey[:])
+		}
+	}
+}
+
+// Down removes the interface and its policy routing rules, and wipes the
+// stored keys; the next Up needs a new Configure
+func (t *netlinkTunnel) Down(ctx context.Context) error {
+	t.mu.Lock()
+	if t.config != nil {
+		t.config.WipeKeys()
+	}
+	t.mu.Unlock()
+	return t.teardown()
+}
+
+// teardown deletes the link, which also removes its addresses and routes
+func (t *netlinkTunnel) teardown() error {
+	// Only the full-tunnel interface installs policy rules
+	if t.usesDefaultRoute() {
+		rules, _ := netlink.RuleList(netlink.FAMILY_V4)
+		for i := range rules {
+			rule := rules[i]
+			if (rule.Table == fullTunnelTable && rule.Invert) ||
+				(rule.Table == unix.RT_TABLE_MAIN && rule.SuppressPrefixlen == 0) {
+				netlink.RuleDel(&rule)
+			}
+		}
+	}
+
+	link, err := netlink.LinkByName(t.iface)
+	if err != nil {
+		return nil // Already gone
+	}
+	if err := netlink.LinkDel(link); err != nil {
+		return fmt.Errorf("failed to delete interface %s: %w", t.iface, err)
+	}
+	return nil
+}
+
+// usesDefaultRoute reports whether the tunnel owns the full-tunnel routing table
+func (t *netlinkTunnel) usesDefaultRoute() bool {
+	routes, err := netlink.RouteListFiltered(netlink.FAMILY_V4, &netlink.Route{Table: fullTunnelTable}, netlink.RT_FILTER_TABLE)
+	if err != nil {
+		return false
+	}
+	link, err := netlink.LinkByName(t.iface)
+	if err != nil {
+		return false
+	}
+	for _, route := range routes {
+		if route.LinkIndex == link.Attrs().Index {
+			return true
+		}
+	}
+	return false
+}
+
+// IsConnected checks if the WireGuard link exists
+func (t *netlinkTunnel) IsConnected() bool {
+	link, err := netlink.LinkByName(t.iface)
+	if err != nil {
+		return false
+	}
+	return link.Type() == "wireguard"
+}
+
+// Stats returns exact counters from the kernel
+func (t *netlinkTunnel) Stats(ctx context.Context) (Stats, error) {
+	if !t.IsConnected() {
+		return Stats{}, nil
+	}
+
+	client, err := wgctrl.New()
+	if err != nil {
+		return Stats{}, fmt.Errorf("failed to open wgctrl: %w", err)
+	}
+	defer client.Close()
+
+	device, err := client.Device(t.iface)
+	if err != nil {
+		return Stats{}, fmt.Errorf("failed to read %s: %w", t.iface, err)
+	}
+
+	stats := Stats{Connected: true}
+	for _, peer := range device.Peers {
+		stats.BytesSent += peer.TransmitBytes
+		stats.BytesReceived += peer.ReceiveBytes
+		if peer.LastHandshakeTime.After(stats.LastHandshake) {
+			stats.LastHandshake = peer.LastHandshakeTime
+		}
+		if peer.Endpoint != nil {
+			stats.Endpoint = peer.Endpoint.String()
+		}
+	}
+
+	return stats, nil
+}
+
+// DumpState describes the device and its peers in wg show style
+func (t *netlinkTunnel) DumpState(ctx context.Context) (string, error) {
+	client, err := wgctrl.New()
+	if err != nil {
+		return "", fmt.Errorf("failed to open wgctrl: %w", err)
+	}
+	defer client.Close()
+
+	device, err := client.Device(t.iface)
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", t.iface, err)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "interface: %s\n", device.Name)
+	fmt.Fprintf(&b, "  public key: %s\n", device.PublicKey)
+	fmt.Fprintf(&b, "  listening port: %d\n", device.ListenPort)
+	if device.FirewallMark != 0 {
+		fmt.Fprintf(&b, "  fwmark: 0x%x\n", device.FirewallMark)
+	}
+	for _, peer := range device.Peers {
+		fmt.Fprintf(&b, "\npeer: %s\n", peer.PublicKey)
+		if peer.PresharedKey != (wgtypes.Key{}) {
+			fmt.Fprintf(&b, "  preshared key: (hidden)\n")
+		}
+		if peer.Endpoint != nil {
+			fmt.Fprintf(&b, "  endpoint: %s\n", peer.Endpoint)
+		}
+		allowed := make([]string, 0, len(peer.AllowedIPs))
+		for _, ipNet := range peer.AllowedIPs {
+			allowed = append(allowed, ipNet.String())
+		}
+		fmt.Fprintf(&b, "  allowed ips: %s\n", strings.Join(allowed, ", "))
+		if !peer.LastHandshakeTime.IsZero() {
+			fmt.Fprintf(&b, "  latest handshake: %s\n", peer.LastHandshakeTime.Format(time.RFC3339))
+		}
+		fmt.Fprintf(&b, "  transfer: %d B received, %d B sent\n", peer.ReceiveBytes, peer.TransmitBytes)
+	}
+
+	return b.String(), nil
+}
+
+// setLinkDNS points systemd-resolved at the tunnel's DNS servers for all
+// domains. Hosts without resolvectl keep their existing resolver.
+func setLinkDNS(ctx context.Context, iface, dns string) {
+	if _, err := exec.LookPath("resolvectl"); err != nil {
+		return
+	}
+
+	servers := strings.Split(dns, ",")
+	for i := range servers {
+		servers[i] = strings.TrimSpace(servers[i])
+	}
+
+	exec.CommandContext(ctx, "resolvectl", append([]string{"dns", iface}, servers...)...).Run()
+	exec.CommandContext(ctx, "resolvectl", "domain", iface, "~.").Run()
+}
@@ -0,0 +1,506 @@
+package vpn
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nikola43/aureo-vpn-client/internal/secrets"
+)
+
+const (
+	openVPNConfigFile   = "openvpn.conf"
+	openVPNPasswordFile = "openvpn-mgmt.pw"
+	// openVPNPortFile records the management port, so a later run of the
+	// app can take over a running openvpn
+	openVPNPortFile = "openvpn-mgmt.port"
+
+	// openVPNConnectTimeout bounds how long Up waits for CONNECTED
+	openVPNConnectTimeout = 45 * time.Second
+)
+
+// OpenVPNManager manages OpenVPN connections by driving the openvpn binary
+// through its management interface
+type OpenVPNManager struct {
+	configDir string
+
+	mu            sync.Mutex
+	conn          net.Conn
+	state         string
+	localIP       string
+	remoteIP      string
+	bytesSent     int64
+	bytesReceived int64
+	lastError     string
+	stateChanged  chan struct{}
+	closed        chan struct{}
+}
+
+// NewOpenVPNManager creates a new OpenVPN manager storing its files in configDir
+func NewOpenVPNManager(configDir string) (*OpenVPNManager, error) {
+	if err := os.MkdirAll(configDir, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create config directory: %w", err)
+	}
+
+	return &OpenVPNManager{
+		configDir: configDir,
+	}, nil
+}
+
+// Name returns the backend name
+func (m *OpenVPNManager) Name() string {
+	return ProtocolOpenVPN
+}
+
+// Protocol returns the protocol name for OpenVPN tunnels
+func (m *OpenVPNManager) Protocol() string {
+	return ProtocolOpenVPN
+}
+
+// openVPNForbiddenDirectives run programs, load code, read further config
+// or write files. openvpn runs as root, so a config from the server must not
+// contain them.
+var openVPNForbiddenDirectives = map[string]bool{
+	"up":                    true,
+	"down":                  true,
+	"down-pre":              true,
+	"up-restart":            true,
+	"route-up":              true,
+	"route-pre-down":        true,
+	"ipchange":              true,
+	"tls-verify":            true,
+	"auth-user-pass-verify": true,
+	"client-connect":        true,
+	"client-disconnect":     true,
+	"learn-address":         true,
+	"plugin":                true,
+	"script-security":       true,
+	"iproute":               true,
+	"management":            true,
+	"config":                true,
+	"cd":                    true,
+	"chroot":                true,
+	"daemon":                true,
+	"log":                   true,
+	"log-append":            true,
+	"status":                true,
+	"writepid":              true,
+	"replay-persist":        true,
+	"tls-export-cert":       true,
+	"tmp-dir":               true,
+}
+
+// openVPNInlineBlocks are the inline <…> blocks a config may contain
+var openVPNInlineBlocks = map[string]bool{
+	"ca":        true,
+	"cert":      true,
+	"key":       true,
+	"tls-auth":  true,
+	"tls-crypt": true,
+}
+
+// validateOpenVPNConfig rejects directives that would let the config run
+// code or touch files as root, and inline blocks other than keys and
+// certificates
+func validateOpenVPNConfig(content string) error {
+	block := ""
+	for i, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if block != "" {
+			if line == "</"+block+">" {
+				block = ""
+			}
+			continue
+		}
+		if line == "" || line[0] == '#' || line[0] == ';' {
+			continue
+		}
+
+		if strings.HasPrefix(line, "<") {
+			name := strings.TrimSuffix(strings.TrimPrefix(line, "<"), ">")
+			if !openVPNInlineBlocks[strings.ToLower(name)] {
+				return fmt.Errorf("OpenVPN config line %d: inline block %q is not allowed", i+1, line)
+			}
+			block = name
+			continue
+		}
+
+		directive := strings.Trim(strings.Fields(line)[0], `"'`)
+		directive = strings.Trim(strings.TrimPrefix(directive, "--"), `"'`)
+		directive = strings.ToLower(directive)
+		if openVPNForbiddenDirectives[directive] || strings.HasPrefix(directive, "management-") {
+			return fmt.Errorf("OpenVPN config line %d: directive %q is not allowed", i+1, directive)
+		}
+	}
+	if block != "" {
+		return fmt.Errorf("OpenVPN config: inline block <%s> is not closed", block)
+	}
+	return nil
+}
+
+// WriteConfig checks and writes the OpenVPN configuration returned by the API
+func (m *OpenVPNManager) WriteConfig(content string) error {
+	if err := validateOpenVPNConfig(content); err != nil {
+		return err
+	}
+
+	configPath := filepath.Join(m.configDir, openVPNConfigFile)
+	if err := os.WriteFile(configPath, []byte(content), 0600); err != nil {
+		return fmt.Errorf("failed to write config file: %w", err)
+	}
+	return nil
+}
+
+// Up starts openvpn with a management interface and waits until the
+// tunnel reports CONNECTED
+func (m *OpenVPNManager) Up(ctx context.Context) error {
+	openvpnExe, err := findOpenVPN()
+	if err != nil {
+		return err
+	}
+
+	port, err := freeLocalPort()
+	if err != nil {
+		return fmt.Errorf("failed to allocate management port: %w", err)
+	}
+
+	password, err := m.writeManagementPassword()
+	if err != nil {
+		return err
+	}
+
+	configPath := filepath.Join(m.configDir, openVPNConfigFile)
+	args := []string{
+		"--config", configPath,
+		// Scripts are never needed; this wins over anything in the file
+		"--script-security", "1",
+		"--management", "127.0.0.1", strconv.Itoa(port), filepath.Join(m.configDir, openVPNPasswordFile),
+		"--management-hold",
+	}
+
+	portPath := filepath.Join(m.configDir, openVPNPortFile)
+	if err := os.WriteFile(portPath, []byte(strconv.Itoa(port)), 0600); err != nil {
+		return fmt.Errorf("failed to write management port: %w", err)
+	}
+
+	if err := startOpenVPN(ctx, openvpnExe, args); err != nil {
+		return fmt.Errorf("failed to start VPN: %w", err)
+	}
+
+	if err := m.attach(port, password); err != nil {
+		return err
+	}
+
+	deadline := time.After(openVPNConnectTimeout)
+	for {
+		m.mu.Lock()
+		state, lastError, changed, closed := m.state, m.lastError, m.stateChanged, m.closed
+		m.mu.Unlock()
+
+		switch state {
+		case "CONNECTED":
+			return nil
+		case "EXITING":
+			m.Down(ctx)
+			if lastError != "" {
+				return fmt.Errorf("OpenVPN exited: %s", lastError)
+			}
+			return fmt.Errorf("OpenVPN exited before connecting")
+		}
+
+		select {
+		case <-changed:
+		case <-closed:
+			// openvpn is gone; remove the config and password it left
+			m.Down(ctx)
+			return fmt.Errorf("OpenVPN management interface closed before connecting")
+		case <-deadline:
+			m.Down(ctx)
+			return fmt.Errorf("OpenVPN did not connect in time (last state: %s)", state)
+		case <-ctx.Done():
+			m.Down(context.Background())
+			return ctx.Err()
+		}
+	}
+}
+
+// Down asks openvpn to exit through the management interface
+func (m *OpenVPNManager) Down(ctx context.Context) error {
+	m.mu.Lock()
+	conn, closed := m.conn, m.closed
+	m.mu.Unlock()
+
+	if conn != nil {
+		fmt.Fprintf(conn, "signal SIGTERM\n")
+
+		// Wait for openvpn to close the management connection
+		select {
+		case <-closed:
+		case <-ctx.Done():
+		case <-time.After(5 * time.Second):
+		}
+		conn.Close()
+	}
+
+	m.mu.Lock()
+	m.conn = nil
+	m.state = ""
+	m.mu.Unlock()
+
+	// The config may embed a client key, so overwrite the files
+	for _, name := range []string{openVPNConfigFile, openVPNPasswordFile, openVPNPortFile} {
+		if err := secrets.Shred(filepath.Join(m.configDir, name)); err != nil {
+			logger.Warn("failed to shred OpenVPN file", "file", name, "error", err)
+		}
+	}
+
+	return nil
+}
+
+// IsConnected checks if the VPN is currently connected
+func (m *OpenVPNManager) IsConnected() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.conn != nil && m.state == "CONNECTED"
+}
+
+// IsRunning reports whether openvpn is running, including while it waits,
+// authenticates or reconnects. The management connection closes when the
+// process exits.
+func (m *OpenVPNManager) IsRunning() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.conn != nil
+}
+
+// Restore takes over an openvpn left running by a previous run of the app,
+// reporting whether there was one
+func (m *OpenVPNManager) Restore() bool {
+	port, err := os.ReadFile(filepath.Join(m.configDir, openVPNPortFile))
+	if err != nil {
+		return false
+	}
+	password, err := os.ReadFile(filepath.Join(m.configDir, openVPNPasswordFile))
+	if err != nil {
+		return false
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(string(port)))
+	if err != nil {
+		return false
+	}
+
+	conn, err := net.DialTimeout("tcp", net.JoinHostPort("127.0.0.1", strconv.Itoa(n)), time.Second)
+	if err != nil {
+		// openvpn is gone; clean up after it
+		m.Down(context.Background())
+		return false
+	}
+	m.start(conn, strings.TrimSpace(string(password)))
+	logger.Info("restored running OpenVPN tunnel")
+	return true
+}
+
+// Stats returns counters parsed from management events
+func (m *OpenVPNManager) Stats(ctx context.Context) (Stats, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stats := Stats{
+		Connected:     m.conn != nil && m.state == "CONNECTED",
+		BytesSent:     m.bytesSent,
+		BytesReceived: m.bytesReceived,
+	}
+	if m.remoteIP != "" {
+		stats.Endpoint = m.remoteIP
+	}
+	return stats, nil
+}
+
+// LocalIP returns the tunnel address reported by the server
+func (m *OpenVPNManager) LocalIP() string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.localIP
+}
+
+// attach connects to the management interface, authenticates and enables
+// state and byte count notifications before releasing the hold
+func (m *OpenVPNManager) attach(port int, password string) error {
+	addr := net.JoinHostPort("127.0.0.1", strconv.Itoa(port))
+
+	// openvpn may take a moment to open the management socket
+	var conn net.Conn
+	var err error
+	for i := 0; i < 20; i++ {
+		conn, err = net.DialTimeout("tcp", addr, time.Second)
+		if err == nil {
+			break
+		}
+		time.Sleep(250 * time.Millisecond)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to reach OpenVPN management interface: %w", err)
+	}
+
+	m.start(conn, password)
+	return nil
+}
+
+// start takes over the management connection, asking for the current state
+// and notifications of later changes
+func (m *OpenVPNManager) start(conn net.Conn, password string) {
+	m.mu.Lock()
+	m.conn = conn
+	m.state = "CONNECTING"
+	m.localIP = ""
+	m.remoteIP = ""
+	m.bytesSent = 0
+	m.bytesReceived = 0
+	m.lastError = ""
+	changed, closed := make(chan struct{}), make(chan struct{})
+	m.stateChanged = changed
+	m.closed = closed
+	m.mu.Unlock()
+
+	fmt.Fprintf(conn, "%s\n", password)
+	fmt.Fprintf(conn, "state on\n")
+	fmt.Fprintf(conn, "state\n")
+	fmt.Fprintf(conn, "bytecount 2\n")
+	fmt.Fprintf(conn, "hold release\n")
+
+	go m.readEvents(conn, changed, closed)
+}
+
+// readEvents consumes management interface output until the connection
+// closes. It owns the connection's channels: once start has taken over a
+// new connection, the manager's channels belong to that one.
+func (m *OpenVPNManager) readEvents(conn net.Conn, changed, closed chan struct{}) {
+	scanner := bufio.NewScanner(conn)
+	for scanner.Scan() {
+		if !m.handleLine(conn, strings.TrimSpace(scanner.Text())) {
+			continue
+		}
+		m.mu.Lock()
+		close(changed)
+		changed = make(chan struct{})
+		if m.conn == conn {
+			m.stateChanged = changed
+		}
+		m.mu.Unlock()
+	}
+
+	m.mu.Lock()
+	if m.conn == conn {
+		m.conn = nil
+		m.state = "EXITING"
+	}
+	close(closed)
+	m.mu.Unlock()
+}
+
+// handleLine applies a single management interface line to the manager
+// state, reporting whether the state changed. Lines from a connection that
+// has been replaced are ignored.
+func (m *OpenVPNManager) handleLine(conn net.Conn, line string) bool {
+	m.mu.Lock()
+	current := m.conn == conn
+	m.mu.Unlock()
+	if !current {
+		return false
+	}
+
+	switch {
+	case strings.HasPrefix(line, ">STATE:") || isStateReply(line):
+		// >STATE:<time>,<state>,<desc>,<local ip>,<remote ip>,..., or the
+		// same without the prefix in reply to the state command
+		fields := strings.Split(strings.TrimPrefix(line, ">STATE:"), ",")
+		if len(fields) < 2 {
+			return false
+		}
+		m.mu.Lock()
+		m.state = fields[1]
+		if len(fields) > 3 && fields[3] != "" {
+			m.localIP = fields[3]
+		}
+		if len(fields) > 4 && fields[4] != "" {
+			m.remoteIP = fields[4]
+		}
+		m.mu.Unlock()
+		return true
+
+	case strings.HasPrefix(line, ">BYTECOUNT:"):
+		// >BYTECOUNT:<bytes in>,<bytes out>
+		fields := strings.Split(strings.TrimPrefix(line, ">BYTECOUNT:"), ",")
+		if len(fields) != 2 {
+			return false
+		}
+		in, _ := strconv.ParseInt(fields[0], 10, 64)
+		out, _ := strconv.ParseInt(fields[1], 10, 64)
+		m.mu.Lock()
+		m.bytesReceived = in
+		m.bytesSent = out
+		m.mu.Unlock()
+
+	case strings.HasPrefix(line, ">HOLD:"):
+		fmt.Fprintf(conn, "hold release\n")
+
+	case strings.HasPrefix(line, ">FATAL:"):
+		message := strings.TrimPrefix(line, ">FATAL:")
+		logger.Error("OpenVPN fatal error", "message", message)
+		m.mu.Lock()
+		m.lastError = message
+		m.mu.Unlock()
+
+	case strings.HasPrefix(line, ">PASSWORD:Verification Failed"):
+		m.mu.Lock()
+		m.lastError = "authentication failed"
+		m.mu.Unlock()
+	}
+	return false
+}
+
+// isStateReply reports whether line is the reply to the state command,
+// which starts with a Unix time
+func isStateReply(line string) bool {
+	timestamp, _, ok := strings.Cut(line, ",")
+	if !ok || timestamp == "" {
+		return false
+	}
+	_, err := strconv.ParseUint(timestamp, 10, 64)
+	return err == nil
+}
+
+// writeManagementPassword writes a random password protecting the management port
+func (m *OpenVPNManager) writeManagementPassword() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate management password: %w", err)
+	}
+	password := hex.EncodeToString(buf)
+
+	passwordPath := filepath.Join(m.configDir, openVPNPasswordFile)
+	if err := os.WriteFile(passwordPath, []byte(password+"\n"), 0600); err != nil {
+		return "", fmt.Errorf("failed to write management password: %w", err)
+	}
+
+	return password, nil
+}
+
+// freeLocalPort asks the kernel for an unused loopback TCP port
+func freeLocalPort() (int, error) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return 0, err
+	}
+	defer l.Close()
+	return l.Addr().(*net.TCPAddr).Port, nil
+}
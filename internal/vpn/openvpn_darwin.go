@@ -0,0 +1,50 @@
+//go:build darwin
+
+package vpn
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// findOpenVPN locates the openvpn binary, including Homebrew's sbin directories
+func findOpenVPN() (string, error) {
+	if p, err := exec.LookPath("openvpn"); err == nil {
+		return p, nil
+	}
+
+	for _, p := range []string{"/opt/homebrew/sbin/openvpn", "/usr/local/sbin/openvpn"} {
+		if _, err := os.Stat(p); err == nil {
+			return p, nil
+		}
+	}
+
+	return "", fmt.Errorf("OpenVPN not installed. Install with: brew install openvpn")
+}
+
+// startOpenVPN launches openvpn as a root daemon through osascript
+func startOpenVPN(ctx context.Context, openvpnExe string, args []string) error {
+	quoted := []string{shellQuote(openvpnExe)}
+	for _, arg := range args {
+		quoted = append(quoted, shellQuote(arg))
+	}
+	quoted = append(quoted, "--daemon", "aureo-openvpn")
+
+	command := strings.ReplaceAll(strings.Join(quoted, " "), `"`, `\"`)
+	script := fmt.Sprintf(`do shell script "%s" with administrator privileges`, command)
+
+	cmd := exec.CommandContext(ctx, "osascript", "-e", script)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%w\nOutput: %s", err, string(output))
+	}
+	return nil
+}
+
+// shellQuote wraps a value in single quotes for /bin/sh
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,31 @@
+//go:build linux
+
+package vpn
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+)
+
+// findOpenVPN locates the openvpn binary
+func findOpenVPN() (string, error) {
+	p, err := exec.LookPath("openvpn")
+	if err != nil {
+		return "", fmt.Errorf("OpenVPN not installed. Install with: sudo apt install openvpn")
+	}
+	return p, nil
+}
+
+// startOpenVPN launches openvpn as a root daemon
+func startOpenVPN(ctx context.Context, openvpnExe string, args []string) error {
+	cmdArgs := append([]string{openvpnExe}, args...)
+	cmdArgs = append(cmdArgs, "--daemon", "aureo-openvpn")
+
+	cmd := exec.CommandContext(ctx, "sudo", cmdArgs...)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%w\nOutput: %s", err, string(output))
+	}
+	return nil
+}
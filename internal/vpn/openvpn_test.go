@@ -0,0 +1,30 @@
+package vpn
+
+import "testing"
+
+func TestValidateOpenVPNConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  string
+		wantErr bool
+	}{
+		{"plain client", "client\ndev tun\nproto udp\nremote 203.0.113.1 1194\n# up /tmp/x\n; down /tmp/x\n", false},
+		{"inline keys", "client\n<ca>\nup not a directive here\n</ca>\n<tls-crypt>\nkey\n</tls-crypt>\n", false},
+		{"up script", "client\nup /tmp/evil.sh\n", true},
+		{"dashed and quoted", "client\n--\"route-up\" /tmp/evil.sh\n", true},
+		{"upper case", "client\nScript-Security 2\n", true},
+		{"plugin", "plugin /tmp/evil.so\n", true},
+		{"management option", "management-client-user root\n", true},
+		{"log file", "log-append /etc/passwd\n", true},
+		{"connection block", "<connection>\nremote 203.0.113.1\n</connection>\n", true},
+		{"unclosed block", "<key>\nsecret\n", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateOpenVPNConfig(tt.config)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("validateOpenVPNConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,44 @@
+//go:build windows
+
+package vpn
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+)
+
+// findOpenVPN locates openvpn.exe in PATH or the default install location
+func findOpenVPN() (string, error) {
+	if p, err := exec.LookPath("openvpn.exe"); err == nil {
+		return p, nil
+	}
+
+	for _, envVar := range []string{"ProgramFiles", "ProgramFiles(x86)"} {
+		dir := os.Getenv(envVar)
+		if dir != "" {
+			p := filepath.Join(dir, "OpenVPN", "bin", "openvpn.exe")
+			if _, err := os.Stat(p); err == nil {
+				return p, nil
+			}
+		}
+	}
+
+	return "", fmt.Errorf("OpenVPN not installed. Download it from https://openvpn.net/community-downloads/")
+}
+
+// startOpenVPN launches openvpn.exe in the background. The app already runs
+// as Administrator, so no elevation is needed.
+func startOpenVPN(ctx context.Context, openvpnExe string, args []string) error {
+	cmd := hiddenCmd(exec.Command(openvpnExe, args...))
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+
+	// Reap the process when it exits; lifetime is controlled via management
+	go cmd.Wait()
+
+	return nil
+}
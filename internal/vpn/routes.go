@@ -0,0 +1,162 @@
+package vpn
+
+import (
+	"fmt"
+	"net/netip"
+	"sort"
+	"strings"
+)
+
+// FullTunnel is the AllowedIPs of a tunnel carrying all IPv4 traffic
+var FullTunnel = []string{"0.0.0.0/0"}
+
+// SplitRoutes returns the IPv4 prefixes to send through the tunnel: the
+// include list (or everything if it is empty) minus the exclude list
+func SplitRoutes(include, exclude []string) ([]string, error) {
+	if len(include) == 0 {
+		include = FullTunnel
+	}
+
+	prefixes, err := parsePrefixes(include)
+	if err != nil {
+		return nil, err
+	}
+	excluded, err := parsePrefixes(exclude)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, ex := range excluded {
+		var remaining []netip.Prefix
+		for _, p := range prefixes {
+			remaining = append(remaining, subtractPrefix(p, ex)...)
+		}
+		prefixes = remaining
+	}
+
+	sort.Slice(prefixes, func(i, j int) bool {
+		return prefixes[i].Addr().Less(prefixes[j].Addr())
+	})
+
+	routes := make([]string, len(prefixes))
+	for i, p := range prefixes {
+		routes[i] = p.String()
+	}
+	return routes, nil
+}
+
+// IsFullTunnel reports whether the routes cover all IPv4 traffic through
+// a default route
+func IsFullTunnel(routes []string) bool {
+	for _, route := range routes {
+		if strings.TrimSpace(route) == "0.0.0.0/0" {
+			return true
+		}
+	}
+	return false
+}
+
+// parsePrefixes parses IPv4 CIDRs; a bare address is treated as a /32
+func parsePrefixes(cidrs []string) ([]netip.Prefix, error) {
+	prefixes := make([]netip.Prefix, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		cidr = strings.TrimSpace(cidr)
+		if !strings.Contains(cidr, "/") {
+			cidr += "/32"
+		}
+		p, err := netip.ParsePrefix(cidr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid route %q: %w", cidr, err)
+		}
+		if !p.Addr().Is4() {
+			return nil, fmt.Errorf("invalid route %q: only IPv4 routes are supported", cidr)
+		}
+		prefixes = append(prefixes, p.Masked())
+	}
+	return prefixes, nil
+}
+
+// subtractPrefix returns the parts of p not covered by ex
+func subtractPrefix(p, ex netip.Prefix) []netip.Prefix {
+	if !p.Overlaps(ex) {
+		return []netip.Prefix{p}
+	}
+	if ex.Bits() <= p.Bits() {
+		// ex covers p entirely
+		return nil
+	}
+
+	// Split p in halves and keep the half that does not contain ex
+	bits := p.Bits() + 1
+	low := netip.PrefixFrom(p.Addr(), bits)
+	high := netip.PrefixFrom(lastAddr(low).Next(), bits)
+
+	return append(subtractPrefix(low, ex), subtractPrefix(high, ex)...)
+}
+
+// lastAddr returns the highest address in p
+func lastAddr(p netip.Prefix) netip.Addr {
+	a := p.Addr().As4()
+	hostBits := 32 - p.Bits()
+	for i := 3; i >= 0 && hostBits > 0; i-- {
+		n := hostBits
+		if n > 8 {
+			n = 8
+		}
+		a[i] |= byte(1<<n - 1)
+		hostBits -= n
+	}
+	return netip.AddrFrom4(a)
+}
+
+// OpenVPNOverrides renders client-side route, DNS and MTU overrides as
+// directives appended to a server-provided OpenVPN config. A non-empty
+// include list ignores the server's pushed routes and routes only those
+// prefixes; exclude sends the listed prefixes around the tunnel.
+func OpenVPNOverrides(include, exclude, dns []string, mtu int) (string, error) {
+	var b strings.Builder
+	if mtu > 0 {
+		fmt.Fprintf(&b, "tun-mtu %d\n", mtu)
+	}
+
+	if len(include) > 0 {
+		prefixes, err := parsePrefixes(include)
+		if err != nil {
+			return "", err
+		}
+		b.WriteString("route-nopull\n")
+		for _, p := range prefixes {
+			fmt.Fprintf(&b, "route %s %s\n", p.Addr(), prefixMask(p))
+		}
+	}
+
+	prefixes, err := parsePrefixes(exclude)
+	if err != nil {
+		return "", err
+	}
+	for _, p := range prefixes {
+		fmt.Fprintf(&b, "route %s %s net_gateway\n", p.Addr(), prefixMask(p))
+	}
+
+	for _, server := range dns {
+		addr, err := netip.ParseAddr(strings.TrimSpace(server))
+		if err != nil {
+			return "", fmt.Errorf("invalid DNS server %q: %w", server, err)
+		}
+		fmt.Fprintf(&b, "dhcp-option DNS %s\n", addr)
+	}
+
+	if b.Len() == 0 {
+		return "", nil
+	}
+	return "\n# Client overrides\n" + b.String(), nil
+}
+
+// prefixMask returns the dotted netmask of an IPv4 prefix
+func prefixMask(p netip.Prefix) netip.Addr {
+	var mask [4]byte
+	for i := 0; i < p.Bits(); i++ {
+		mask[i/8] |= 0x80 >> (i % 8)
+	}
+	return netip.AddrFrom4(mask)
+}
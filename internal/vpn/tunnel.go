@@ -0,0 +1,96 @@
+package vpn
+
+import (
+	"context"
+	"time"
+
+	"github.com/nikola43/aureo-vpn-client/internal/logging"
+)
+
+var logger = logging.For(logging.SubsystemVPN)
+
+// Protocol names accepted by the API and reported by tunnels
+const (
+	ProtocolWireGuard = "wireguard"
+	ProtocolOpenVPN   = "openvpn"
+)
+
+// DefaultInterface is the interface name used for single-hop connections
+const DefaultInterface = "wg0"
+
+// Stats is a snapshot of a tunnel's counters. Tunnels report exact byte
+// counters; the rates are filled in by StatsHistory from successive samples.
+type Stats struct {
+	Connected     bool      `json:"connected"`
+	BytesSent     int64     `json:"bytes_sent"`
+	BytesReceived int64     `json:"bytes_received"`
+	LastHandshake time.Time `json:"last_handshake"`
+	Endpoint      string    `json:"endpoint,omitempty"`
+
+	// Throughput in bytes per second
+	RxRate float64 `json:"rx_rate"`
+	TxRate float64 `json:"tx_rate"`
+}
+
+// Tunnel is a protocol-agnostic VPN connection. Protocol-specific setup
+// (keys, config) happens before Up is called.
+type Tunnel interface {
+	// Name returns the backend name, e.g. "wg-quick" or "openvpn"
+	Name() string
+	// Protocol returns the protocol name, e.g. "wireguard" or "openvpn"
+	Protocol() string
+	// Up brings the tunnel up using the previously applied config
+	Up(ctx context.Context) error
+	// Down tears the tunnel down and removes its config
+	Down(ctx context.Context) error
+	// IsConnected reports whether the tunnel is currently up
+	IsConnected() bool
+	// Stats returns a snapshot of the tunnel's counters
+	Stats(ctx context.Context) (Stats, error)
+}
+
+// Runner is implemented by tunnels whose process can be running while the
+// tunnel is not established, e.g. while it reconnects. Such a tunnel still
+// has to be taken down.
+type Runner interface {
+	IsRunning() bool
+}
+
+// IsRunning reports whether t has to be taken down: it is running, or
+// connected if it cannot tell
+func IsRunning(t Tunnel) bool {
+	if r, ok := t.(Runner); ok {
+		return r.IsRunning()
+	}
+	return t.IsConnected()
+}
+
+// BoundTunnel is implemented by tunnels backed by a system network interface,
+// so that sockets can be bound to it directly
+type BoundTunnel interface {
+	SystemInterface() (string, error)
+}
+
+// DNSTunnel is implemented by tunnels that know the DNS servers configured
+// for them, so that names can be resolved through the tunnel
+type DNSTunnel interface {
+	DNSServers() []string
+}
+
+// StateDumper is implemented by tunnels that can describe their device state
+// in wg show style for diagnostics. The output may contain keys and must be
+// redacted before it is shared.
+type StateDumper interface {
+	DumpState(ctx context.Context) (string, error)
+}
+
+// WireGuardTunnel is a Tunnel backed by a single WireGuard interface
+type WireGuardTunnel interface {
+	Tunnel
+	// Interface returns the WireGuard interface name
+	Interface() string
+	// Configure applies the interface and peer config used by the next Up
+	Configure(cfg TunnelConfig) error
+}
+
+var _ Tunnel = (*OpenVPNManager)(nil)
@@ -0,0 +1,304 @@
+package vpn
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"net/netip"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nikola43/aureo-vpn-client/internal/secrets"
+	"golang.zx2c4.com/wireguard/conn"
+	"golang.zx2c4.com/wireguard/device"
+	"golang.zx2c4.com/wireguard/tun/netstack"
+	"golang.zx2c4.com/wireguard/wgctrl/wgtypes"
+)
+
+// Dialer is implemented by tunnels that can open connections through
+// themselves without a system-wide interface
+type Dialer interface {
+	DialContext(ctx context.Context, network, address string) (net.Conn, error)
+}
+
+// userspaceTunnel runs wireguard-go on a gVisor netstack inside the app
+// process. It needs no privileges and creates no system interface; traffic
+// only enters the tunnel through DialContext (e.g. from the local proxies).
+type userspaceTunnel struct {
+	iface string
+
+	mu     sync.Mutex
+	config *TunnelConfig
+	dev    *device.Device
+	tnet   *netstack.Net
+}
+
+var _ Dialer = (*userspaceTunnel)(nil)
+
+func init() {
+	RegisterBackend(Backend{
+		Name:     BackendUserspace,
+		Priority: 30,
+		Probe:    func() error { return nil },
+		New: func(configDir, iface string) (WireGuardTunnel, error) {
+			return &userspaceTunnel{iface: iface}, nil
+		},
+	})
+}
+
+// Name returns the backend name
+func (t *userspaceTunnel) Name() string {
+	return BackendUserspace
+}
+
+// Protocol returns the protocol name for WireGuard tunnels
+func (t *userspaceTunnel) Protocol() string {
+	return ProtocolWireGuard
+}
+
+// Interface returns the tunnel name; no system interface exists
+func (t *userspaceTunnel) Interface() string {
+	return t.iface
+}
+
+// Configure stores a copy of the config in memory for the next Up
+func (t *userspaceTunnel) Configure(cfg TunnelConfig) error {
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.config != nil {
+		t.config.WipeKeys()
+	}
+	cfg = cfg.cloneKeys()
+	t.config = &cfg
+	return nil
+}
+
+// Up starts a wireguard-go device on a fresh netstack
+func (t *userspaceTunnel) Up(ctx context.Context) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.config == nil {
+		return fmt.Errorf("tunnel %s is not configured", t.iface)
+	}
+	if t.dev != nil {
+		return nil
+	}
+
+	address, err := netip.ParseAddr(t.config.Address)
+	if err != nil {
+		return fmt.Errorf("invalid address %q: %w", t.config.Address, err)
+	}
+
+	var dnsServers []netip.Addr
+	for _, server := range strings.Split(t.config.DNS, ",") {
+		server = strings.TrimSpace(server)
+		if server == "" {
+			continue
+		}
+		addr, err := netip.ParseAddr(server)
+		if err != nil {
+			return fmt.Errorf("invalid DNS server %q: %w", server, err)
+		}
+		dnsServers = append(dnsServers, addr)
+	}
+
+	mtu := t.config.MTU
+	if mtu == 0 {
+		mtu = linkMTU - wireGuardOverheadIPv6
+	}
+
+	uapi, err := uapiConfig(t.config)
+	if err != nil {
+		return err
+	}
+	defer uapi.Wipe()
+
+	tunDev, tnet, err := netstack.CreateNetTUN([]netip.Addr{address}, dnsServers, mtu)
+	if err != nil {
+		return fmt.Errorf("failed to create netstack: %w", err)
+	}
+
+	dev := device.NewDevice(tunDev, conn.NewDefaultBind(), device.NewLogger(device.LogLevelSilent, ""))
+	if err := dev.IpcSetOperation(bytes.NewReader(uapi)); err != nil {
+		dev.Close()
+		return fmt.Errorf("failed to configure device: %w", err)
+	}
+	if err := dev.Up(); err != nil {
+		dev.Close()
+		return fmt.Errorf("failed to bring up device: %w", err)
+	}
+
+	t.dev = dev
+	t.tnet = tnet
+	return nil
+}
+
+// Down closes the device and its netstack, and wipes the stored keys; the
+// next Up needs a new Configure
+func (t *userspaceTunnel) Down(ctx context.Context) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.dev != nil {
+		t.dev.Close()
+	}
+	if t.config != nil {
+		t.config.WipeKeys()
+	}
+	t.dev = nil
+	t.tnet = nil
+	return nil
+}
+
+// IsConnected reports whether the device is running
+func (t *userspaceTunnel) IsConnected() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.dev != nil
+}
+
+// Stats returns exact counters from the device's UAPI state
+func (t *userspaceTunnel) Stats(ctx context.Context) (Stats, error) {
+	t.mu.Lock()
+	dev := t.dev
+	t.mu.Unlock()
+
+	if dev == nil {
+		return Stats{}, nil
+	}
+
+	state, err := dev.IpcGet()
+	if err != nil {
+		return Stats{}, fmt.Errorf("failed to read device state: %w", err)
+	}
+
+	stats := parseUAPIStats(state)
+	stats.Connected = true
+	return stats, nil
+}
+
+// DumpState returns the device's UAPI state
+func (t *userspaceTunnel) DumpState(ctx context.Context) (string, error) {
+	t.mu.Lock()
+	dev := t.dev
+	t.mu.Unlock()
+
+	if dev == nil {
+		return "", fmt.Errorf("tunnel %s is not connected", t.iface)
+	}
+	return dev.IpcGet()
+}
+
+// DialContext opens a connection through the tunnel's netstack
+func (t *userspaceTunnel) DialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	t.mu.Lock()
+	tnet := t.tnet
+	t.mu.Unlock()
+
+	if tnet == nil {
+		return nil, fmt.Errorf("tunnel %s is not connected", t.iface)
+	}
+	return tnet.DialContext(ctx, network, address)
+}
+
+// uapiConfig renders a TunnelConfig in the wireguard-go UAPI format, which
+// uses hex-encoded keys and resolved endpoints. The caller wipes the result.
+func uapiConfig(cfg *TunnelConfig) (secrets.Buffer, error) {
+	peerKey, err := wgtypes.ParseKey(cfg.PeerPublicKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid peer public key: %w", err)
+	}
+
+	endpoint, err := net.ResolveUDPAddr("udp", cfg.Endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid endpoint %q: %w", cfg.Endpoint, err)
+	}
+
+	var peer strings.Builder
+	fmt.Fprintf(&peer, "endpoint=%s\n", endpoint.String())
+	if cfg.Keepalive > 0 {
+		fmt.Fprintf(&peer, "persistent_keepalive_interval=%d\n", cfg.Keepalive)
+	}
+	for _, allowed := range cfg.AllowedIPs {
+		fmt.Fprintf(&peer, "allowed_ip=%s\n", strings.TrimSpace(allowed))
+	}
+
+	privateKey, err := decodeKey(cfg.PrivateKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid private key: %w", err)
+	}
+	defer clear(privateKey[:])
+
+	var psk wgtypes.Key
+	if len(cfg.PresharedKey) > 0 {
+		if psk, err = decodeKey(cfg.PresharedKey); err != nil {
+			return nil, fmt.Errorf("invalid pre-shared key: %w", err)
+		}
+		defer clear(psk[:])
+	}
+
+	// Size the buffer up front so appending never leaves a stray copy of
+	// the keys in a discarded array
+	keyLine := len("preshared_key=\n") + hex.EncodedLen(wgtypes.KeyLen)
+	uapi := make(secrets.Buffer, 0, 3*keyLine+peer.Len())
+	uapi = appendUAPIKey(uapi, "private_key", &privateKey)
+	uapi = appendUAPIKey(uapi, "public_key", &peerKey)
+	if len(cfg.PresharedKey) > 0 {
+		uapi = appendUAPIKey(uapi, "preshared_key", &psk)
+	}
+	uapi = append(uapi, peer.String()...)
+
+	return uapi, nil
+}
+
+// appendUAPIKey appends a hex-encoded key line
+func appendUAPIKey(dst secrets.Buffer, name string, key *wgtypes.Key) secrets.Buffer {
+	dst = append(dst, name...)
+	dst = append(dst, '=')
+	dst = hex.AppendEncode(dst, key[:])
+	return append(dst, '\n')
+}
+
+// parseUAPIStats parses counters from a wireguard-go UAPI get response
+func parseUAPIStats(state string) Stats {
+	var stats Stats
+	var handshakeSec, handshakeNsec int64
+
+	scanner := bufio.NewScanner(strings.NewReader(state))
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(scanner.Text(), "=")
+		if !ok {
+			continue
+		}
+
+		switch key {
+		case "rx_bytes":
+			n, _ := strconv.ParseInt(value, 10, 64)
+			stats.BytesReceived += n
+		case "tx_bytes":
+			n, _ := strconv.ParseInt(value, 10, 64)
+			stats.BytesSent += n
+		case "last_handshake_time_sec":
+			handshakeSec, _ = strconv.ParseInt(value, 10, 64)
+		case "last_handshake_time_nsec":
+			handshakeNsec, _ = strconv.ParseInt(value, 10, 64)
+		case "endpoint":
+			stats.Endpoint = value
+		}
+	}
+
+	if handshakeSec > 0 {
+		stats.LastHandshake = time.Unix(handshakeSec, handshakeNsec)
+	}
+
+	return stats
+}
@@ -0,0 +1,214 @@
+package vpn
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/nikola43/aureo-vpn-client/internal/secrets"
+)
+
+// keyDelivery is how the tools backend hands the keys to the tunnel
+type keyDelivery int
+
+const (
+	// keysInConfig keeps the keys in the config file until Down, for tools
+	// that read the file again while the tunnel runs
+	keysInConfig keyDelivery = iota
+	// keysUntilUp rewrites the config file without the keys once Up returns
+	keysUntilUp
+	// keysOnStdin never writes the keys; a PostUp hook reads them from stdin
+	keysOnStdin
+)
+
+// toolsTunnel drives the platform's WireGuard tools (wg-quick on Linux and
+// macOS, the tunnel service on Windows) using a config file per interface
+type toolsTunnel struct {
+	configDir string
+	iface     string
+
+	// keyless is the config without keys, written once they are delivered
+	keyless TunnelConfig
+	// keys is the hook input for keysOnStdin
+	keys secrets.Buffer
+}
+
+func init() {
+	RegisterBackend(Backend{
+		Name:     toolsBackendName,
+		Priority: 20,
+		Probe:    probeTools,
+		New: func(configDir, iface string) (WireGuardTunnel, error) {
+			return &toolsTunnel{configDir: configDir, iface: iface}, nil
+		},
+	})
+}
+
+// Name returns the backend name
+func (t *toolsTunnel) Name() string {
+	return toolsBackendName
+}
+
+// Protocol returns the protocol name for WireGuard tunnels
+func (t *toolsTunnel) Protocol() string {
+	return ProtocolWireGuard
+}
+
+// Interface returns the WireGuard interface name
+func (t *toolsTunnel) Interface() string {
+	return t.iface
+}
+
+// SystemInterface returns the OS interface carrying the tunnel
+func (t *toolsTunnel) SystemInterface() (string, error) {
+	return systemInterface(t.iface)
+}
+
+// DNSServers returns the DNS servers of the applied config
+func (t *toolsTunnel) DNSServers() []string {
+	return t.keyless.nameservers()
+}
+
+// configPath returns the path of the config file for this interface
+func (t *toolsTunnel) configPath() string {
+	return filepath.Join(t.configDir, t.iface+".conf")
+}
+
+// Configure writes the wg-quick config file for this interface. Where the
+// tools allow it the keys are left out and passed to Up on stdin instead.
+func (t *toolsTunnel) Configure(cfg TunnelConfig) error {
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+	t.keys.Wipe()
+	t.keys = nil
+
+	t.keyless = cfg
+	t.keyless.PrivateKey, t.keyless.PresharedKey = nil, nil
+
+	if toolsKeyDelivery == keysOnStdin {
+		t.keys = keysInput(cfg)
+		hook := keysHook(cfg)
+		cfg = t.keyless
+		cfg.PostUp = append([]string{hook}, cfg.PostUp...)
+	}
+	return t.writeConfig(cfg)
+}
+
+// writeConfig overwrites any previous config file and writes cfg
+func (t *toolsTunnel) writeConfig(cfg TunnelConfig) error {
+	config := renderConfig(cfg)
+	defer config.Wipe()
+
+	if err := secrets.Shred(t.configPath()); err != nil {
+		return fmt.Errorf("failed to remove old config file: %w", err)
+	}
+	if err := os.WriteFile(t.configPath(), config, 0600); err != nil {
+		return fmt.Errorf("failed to write config file: %w", err)
+	}
+	return nil
+}
+
+// Up brings the interface up
+func (t *toolsTunnel) Up(ctx context.Context) error {
+	if err := toolsUp(ctx, t.configDir, t.iface, t.configPath(), t.keys); err != nil {
+		return fmt.Errorf("failed to start VPN: %w", err)
+	}
+
+	if toolsKeyDelivery == keysUntilUp {
+		// The tools only need the keys while bringing the interface up
+		if err := t.writeConfig(t.keyless); err != nil {
+			logger.Warn("failed to remove keys from config file", "interface", t.iface, "error", err)
+		}
+	}
+	return nil
+}
+
+// Down tears the interface down and shreds its config file, even if the
+// interface cannot be stopped
+func (t *toolsTunnel) Down(ctx context.Context) error {
+	defer func() {
+		t.keys.Wipe()
+		t.keys = nil
+		if err := secrets.Shred(t.configPath()); err != nil {
+			logger.Warn("failed to shred config file", "interface", t.iface, "error", err)
+		}
+	}()
+
+	output, err := toolsDown(ctx, t.configDir, t.iface, t.configPath())
+	if err != nil && !isNotRunning(output) {
+		return fmt.Errorf("failed to stop VPN: %w\nOutput: %s", err, output)
+	}
+	return nil
+}
+
+// DumpState returns the output of wg show
+func (t *toolsTunnel) DumpState(ctx context.Context) (string, error) {
+	return wgShow(ctx, t.configDir, t.iface)
+}
+
+// IsConnected checks if the interface is currently up
+func (t *toolsTunnel) IsConnected() bool {
+	// If the config file is gone we're definitely not connected
+	if _, err := os.Stat(t.configPath()); os.IsNotExist(err) {
+		return false
+	}
+	return interfaceExists(t.iface)
+}
+
+// Stats returns the exact counters from wg show dump
+func (t *toolsTunnel) Stats(ctx context.Context) (Stats, error) {
+	connected := t.IsConnected()
+
+	output, err := wgShow(ctx, t.configDir, t.iface, "dump")
+	if err != nil {
+		return Stats{Connected: connected}, fmt.Errorf("failed to read WireGuard stats: %w", err)
+	}
+	if len(output) == 0 {
+		return Stats{Connected: connected}, fmt.Errorf("failed to read WireGuard stats: no output for %s", t.iface)
+	}
+
+	stats := parseWgDump(output)
+	stats.Connected = connected
+	return stats, nil
+}
+
+// keysHook returns a PostUp command that reads the keys from wg-quick's stdin
+// and hands each to wg through a pipe (/dev/fd/N), so they are never
+// written to disk or passed as arguments
+func keysHook(cfg TunnelConfig) string {
+	hook := `read -r private psk; wg set %i private-key <(echo "$private")`
+	if len(cfg.PresharedKey) > 0 {
+		hook += " peer " + cfg.PeerPublicKey + ` preshared-key <(echo "$psk")`
+	}
+	return hook
+}
+
+// keysInput returns the stdin line read by keysHook
+func keysInput(cfg TunnelConfig) secrets.Buffer {
+	input := make(secrets.Buffer, 0, len(cfg.PrivateKey)+len(cfg.PresharedKey)+2)
+	input = append(input, cfg.PrivateKey...)
+	if len(cfg.PresharedKey) > 0 {
+		input = append(input, ' ')
+		input = append(input, cfg.PresharedKey...)
+	}
+	return append(input, '\n')
+}
+
+// isNotRunning reports whether tool output means the interface was already down
+func isNotRunning(output string) bool {
+	lower := strings.ToLower(output)
+	for _, marker := range []string{
+		"is not a wireguard interface",
+		"does not exist",
+		"not found",
+		"not installed",
+	} {
+		if strings.Contains(lower, marker) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,147 @@
+//go:build darwin
+
+package vpn
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+const toolsBackendName = BackendWgQuick
+
+// toolsKeyDelivery leaves the keys in the config file only while wg-quick
+// brings the interface up. osascript runs the command without stdin, so
+// they cannot be passed to a PostUp hook.
+const toolsKeyDelivery = keysUntilUp
+
+// probeTools checks that wg-quick is installed
+func probeTools() error {
+	if _, err := exec.LookPath("wg-quick"); err != nil {
+		return fmt.Errorf("WireGuard not installed. Install with: brew install wireguard-tools")
+	}
+	return nil
+}
+
+// toolsUp brings up the interface described by configPath
+func toolsUp(ctx context.Context, configDir, iface, configPath string, keys []byte) error {
+	if err := probeTools(); err != nil {
+		return err
+	}
+
+	// Bring down any existing interface and give it a moment to be removed
+	path := shellQuote(configPath)
+	output, err := runPrivileged(ctx, fmt.Sprintf("wg-quick down %s 2>/dev/null || true; sleep 0.5; wg-quick up %s", path, path))
+	if err != nil {
+		return fmt.Errorf("%w\nOutput: %s", err, output)
+	}
+
+	return nil
+}
+
+// toolsDown tears down the interface described by configPath
+func toolsDown(ctx context.Context, configDir, iface, configPath string) (string, error) {
+	return runPrivileged(ctx, "wg-quick down "+shellQuote(configPath))
+}
+
+// runPrivileged runs a shell command through osascript to get admin
+// privileges via GUI prompt. The command is passed inline rather than as a
+// script file, so nothing is left on disk.
+func runPrivileged(ctx context.Context, command string) (string, error) {
+	escaped := strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(command)
+	script := fmt.Sprintf(`do shell script "%s" with administrator privileges`, escaped)
+
+	cmd := exec.CommandContext(ctx, "osascript", "-e", script)
+	output, err := cmd.CombinedOutput()
+	return string(output), err
+}
+
+// interfaceExists checks if the named WireGuard interface is up
+func interfaceExists(iface string) bool {
+	// wg-quick records the utun interface backing each named tunnel
+	if _, err := os.Stat(filepath.Join("/var/run/wireguard", iface+".name")); err == nil {
+		return true
+	}
+
+	// If we can run wg show without sudo, use it for a more accurate check
+	wgOutput, err := exec.Command("wg", "show").Output()
+	if err == nil && len(wgOutput) > 0 {
+		return strings.Contains(string(wgOutput), "interface:")
+	}
+
+	// Fallback: check if any utun interface exists. WireGuard on macOS
+	// typically creates utun interfaces.
+	output, err := exec.Command("ifconfig").Output()
+	if err != nil {
+		return false
+	}
+	return strings.Contains(string(output), "utun")
+}
+
+// systemInterface returns the utun interface wg-quick created for iface
+func systemInterface(iface string) (string, error) {
+	name, err := os.ReadFile(filepath.Join("/var/run/wireguard", iface+".name"))
+	if err != nil {
+		return "", fmt.Errorf("failed to find interface for %s: %w", iface, err)
+	}
+	return strings.TrimSpace(string(name)), nil
+}
+
+// wgShow returns the output of wg show for the named interface, followed
+// by args such as "dump"
+func wgShow(ctx context.Context, configDir, iface string, args ...string) (string, error) {
+	// Use sudo wg show directly (assumes wg is in sudoers/visudo for passwordless access)
+	cmd := exec.CommandContext(ctx, "sudo", append([]string{"wg", "show", iface}, args...)...)
+	output, err := cmd.Output()
+	if err != nil {
+		// The sampler reads stats every second, so this must not be noisy
+		logger.Debug("failed to get WireGuard stats", "interface", iface, "error", err)
+	}
+	return string(output), err
+}
+
+// prepareMultiHop keeps the exit endpoint routed through the entry tunnel.
+// wg-quick on macOS replaces endpoint routes with direct routes via the
+// default gateway, so the exit tunnel re-points its endpoint at the entry
+// utun once it is up. The entry endpoint gets a direct route via the
+// original gateway, or the exit tunnel's default routes would loop the
+// entry tunnel's packets back into it.
+func prepareMultiHop(entryIface string, entry, exit *TunnelConfig) error {
+	entryHost, _, err := net.SplitHostPort(entry.Endpoint)
+	if err != nil {
+		return fmt.Errorf("invalid entry endpoint %q: %w", entry.Endpoint, err)
+	}
+	exitHost, _, err := net.SplitHostPort(exit.Endpoint)
+	if err != nil {
+		return fmt.Errorf("invalid exit endpoint %q: %w", exit.Endpoint, err)
+	}
+
+	family, dst := hostRoute(entryHost)
+	gateway := fmt.Sprintf("$(route -n get %s default | awk '/gateway:/ { print $2 }')", family)
+	entry.PostUp = append(entry.PostUp,
+		fmt.Sprintf("route -q -n delete %s %s || true", family, dst),
+		fmt.Sprintf("route -q -n add %s %s -gateway %s", family, dst, gateway),
+	)
+	entry.PostDown = append(entry.PostDown, fmt.Sprintf("route -q -n delete %s %s || true", family, dst))
+
+	family, dst = hostRoute(exitHost)
+	entryName := filepath.Join("/var/run/wireguard", entryIface+".name")
+	exit.PostUp = append(exit.PostUp,
+		fmt.Sprintf("route -q -n delete %s %s || true", family, dst),
+		fmt.Sprintf("route -q -n add %s %s -interface $(cat %s)", family, dst, entryName),
+	)
+	return nil
+}
+
+// hostRoute returns the route(8) address family flag and host destination
+// for an IP address
+func hostRoute(host string) (family, dst string) {
+	if ip := net.ParseIP(host); ip != nil && ip.To4() == nil {
+		return "-inet6", host + "/128"
+	}
+	return "-inet", host + "/32"
+}
@@ -0,0 +1,90 @@
+//go:build linux
+
+package vpn
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+)
+
+const toolsBackendName = BackendWgQuick
+
+// toolsKeyDelivery passes the keys to wg-quick on stdin, through sudo
+const toolsKeyDelivery = keysOnStdin
+
+// probeTools checks that wg-quick is installed and can run as root without
+// a password prompt, which a GUI app has no terminal to answer
+func probeTools() error {
+	wgQuick, err := exec.LookPath("wg-quick")
+	if err != nil {
+		return fmt.Errorf("WireGuard not installed. Install with: sudo apt install wireguard-tools")
+	}
+
+	if os.Geteuid() == 0 {
+		return nil
+	}
+	if err := exec.Command("sudo", "-n", "-l", wgQuick).Run(); err != nil {
+		return fmt.Errorf("passwordless sudo for wg-quick not configured. Run setup-wg-sudoers.sh")
+	}
+	return nil
+}
+
+// toolsUp brings up the interface described by configPath, feeding keys to
+// the config's PostUp hook on stdin
+func toolsUp(ctx context.Context, configDir, iface, configPath string, keys []byte) error {
+	if err := probeTools(); err != nil {
+		return err
+	}
+
+	// Try to bring down any existing interface first
+	downCmd := exec.CommandContext(ctx, "sudo", "wg-quick", "down", configPath)
+	downCmd.Run() // ignore error
+
+	// Bring up the WireGuard interface
+	cmd := exec.CommandContext(ctx, "sudo", "wg-quick", "up", configPath)
+	cmd.Stdin = bytes.NewReader(keys)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%w\nOutput: %s", err, string(output))
+	}
+
+	return nil
+}
+
+// toolsDown tears down the interface described by configPath
+func toolsDown(ctx context.Context, configDir, iface, configPath string) (string, error) {
+	cmd := exec.CommandContext(ctx, "sudo", "wg-quick", "down", configPath)
+	output, err := cmd.CombinedOutput()
+	return string(output), err
+}
+
+// interfaceExists checks if the named network interface exists
+func interfaceExists(iface string) bool {
+	cmd := exec.Command("ip", "link", "show", iface)
+	return cmd.Run() == nil
+}
+
+// systemInterface returns the interface name, which wg-quick uses as is
+func systemInterface(iface string) (string, error) {
+	return iface, nil
+}
+
+// wgShow returns the output of wg show for the named interface, followed
+// by args such as "dump"
+func wgShow(ctx context.Context, configDir, iface string, args ...string) (string, error) {
+	output, err := exec.CommandContext(ctx, "sudo", append([]string{"wg", "show", iface}, args...)...).Output()
+	return string(output), err
+}
+
+// prepareMultiHop marks the entry tunnel's own traffic so that it bypasses the
+// policy routing table installed for the exit tunnel's default route. wg-quick
+// picks the first free table unless the interface already has a fwmark, so
+// the exit tunnel gets the same mark to pin its table to it.
+func prepareMultiHop(entryIface string, entry, exit *TunnelConfig) error {
+	entry.FwMark = multiHopFwMark
+	exit.FwMark = multiHopFwMark
+	return nil
+}
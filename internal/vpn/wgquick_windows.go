@@ -0,0 +1,203 @@
+//go:build windows
+
+package vpn
+
+import (
+	"context"
+	_ "embed"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+)
+
+const toolsBackendName = BackendTunnelService
+
+// toolsKeyDelivery keeps the keys in the config file, which the tunnel
+// service reads again whenever it restarts
+const toolsKeyDelivery = keysInConfig
+
+//go:embed wgbin/wg.exe
+var embeddedWgExe []byte
+
+//go:embed wgbin/wireguard.exe
+var embeddedWireguardExe []byte
+
+// ensureBinaries extracts the embedded WireGuard binaries to the config directory if not already present
+func ensureBinaries(configDir string) error {
+	binDir := filepath.Join(configDir, "bin")
+	if err := os.MkdirAll(binDir, 0755); err != nil {
+		return fmt.Errorf("failed to create bin directory: %w", err)
+	}
+
+	binaries := map[string][]byte{
+		"wg.exe":        embeddedWgExe,
+		"wireguard.exe": embeddedWireguardExe,
+	}
+
+	for name, data := range binaries {
+		destPath := filepath.Join(binDir, name)
+		// Skip if binary already exists with correct size
+		if info, err := os.Stat(destPath); err == nil && info.Size() == int64(len(data)) {
+			continue
+		}
+		if err := os.WriteFile(destPath, data, 0755); err != nil {
+			return fmt.Errorf("failed to extract %s: %w", name, err)
+		}
+	}
+
+	return nil
+}
+
+// findExe searches for a WireGuard executable: bundled bin dir, app dir, PATH, then Program Files
+func findExe(configDir, name string) (string, error) {
+	// Check bundled bin directory first
+	bundledPath := filepath.Join(configDir, "bin", name)
+	if _, err := os.Stat(bundledPath); err == nil {
+		return bundledPath, nil
+	}
+
+	// Check next to the running executable
+	if exePath, err := os.Executable(); err == nil {
+		p := filepath.Join(filepath.Dir(exePath), name)
+		if _, err := os.Stat(p); err == nil {
+			return p, nil
+		}
+	}
+
+	// Check PATH
+	if p, err := exec.LookPath(name); err == nil {
+		return p, nil
+	}
+
+	// Check common Windows install locations
+	for _, envVar := range []string{"ProgramFiles", "ProgramFiles(x86)"} {
+		dir := os.Getenv(envVar)
+		if dir != "" {
+			p := filepath.Join(dir, "WireGuard", name)
+			if _, err := os.Stat(p); err == nil {
+				return p, nil
+			}
+		}
+	}
+
+	return "", fmt.Errorf("%s not found", name)
+}
+
+// hiddenCmd sets SysProcAttr to hide the console window for a command
+func hiddenCmd(cmd *exec.Cmd) *exec.Cmd {
+	cmd.SysProcAttr = &syscall.SysProcAttr{HideWindow: true}
+	return cmd
+}
+
+// probeTools always succeeds; the WireGuard binaries are embedded in the app
+func probeTools() error {
+	return nil
+}
+
+// toolsUp starts the named tunnel using the Windows tunnel service.
+// The app must be running as Administrator (enforced by the manifest).
+func toolsUp(ctx context.Context, configDir, iface, configPath string, keys []byte) error {
+	if err := ensureBinaries(configDir); err != nil {
+		return err
+	}
+
+	wireguardExe, err := findExe(configDir, "wireguard.exe")
+	if err != nil {
+		return fmt.Errorf("wireguard.exe not found: %w", err)
+	}
+
+	// Remove existing tunnel service if any (ignore errors — tunnel may not exist)
+	uninstall := hiddenCmd(exec.CommandContext(ctx, wireguardExe, "/uninstalltunnelservice", iface))
+	uninstall.Run()
+	time.Sleep(time.Second)
+
+	// Install and start the WireGuard tunnel service
+	install := hiddenCmd(exec.CommandContext(ctx, wireguardExe, "/installtunnelservice", configPath))
+	output, err := install.CombinedOutput()
+	if err != nil {
+		outputStr := string(output)
+		lower := strings.ToLower(outputStr + err.Error())
+		if strings.Contains(lower, "access") || strings.Contains(lower, "denied") || strings.Contains(lower, "privilege") {
+			return fmt.Errorf("administrator privileges required. Please run Aureo VPN as Administrator")
+		}
+		return fmt.Errorf("%w\nOutput: %s", err, outputStr)
+	}
+
+	// Wait for the tunnel service to come up
+	for i := 0; i < 10; i++ {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(500 * time.Millisecond):
+		}
+		if interfaceExists(iface) {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("VPN tunnel service did not start in time. Check WireGuard logs")
+}
+
+// toolsDown stops the named tunnel service
+func toolsDown(ctx context.Context, configDir, iface, configPath string) (string, error) {
+	wireguardExe, err := findExe(configDir, "wireguard.exe")
+	if err != nil {
+		return "", nil // Not found — nothing to disconnect
+	}
+
+	cmd := hiddenCmd(exec.CommandContext(ctx, wireguardExe, "/uninstalltunnelservice", iface))
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return string(output), err
+	}
+
+	// Wait for service to stop
+	for i := 0; i < 10; i++ {
+		time.Sleep(500 * time.Millisecond)
+		if !interfaceExists(iface) {
+			break
+		}
+	}
+
+	return string(output), nil
+}
+
+// interfaceExists checks if the WireGuard tunnel service for iface is running
+func interfaceExists(iface string) bool {
+	cmd := hiddenCmd(exec.Command("sc", "query", "WireGuardTunnel$"+iface))
+	output, err := cmd.Output()
+	if err != nil {
+		return false
+	}
+
+	return strings.Contains(string(output), "RUNNING")
+}
+
+// systemInterface returns the adapter name, which the tunnel service
+// names after the tunnel
+func systemInterface(iface string) (string, error) {
+	return iface, nil
+}
+
+// wgShow returns the output of wg show for the named tunnel, followed by
+// args such as "dump"
+func wgShow(ctx context.Context, configDir, iface string, args ...string) (string, error) {
+	wgExe, err := findExe(configDir, "wg.exe")
+	if err != nil {
+		return "", err
+	}
+
+	output, err := hiddenCmd(exec.CommandContext(ctx, wgExe, append([]string{"show", iface}, args...)...)).Output()
+	return string(output), err
+}
+
+// prepareMultiHop reports that nested tunnels are unavailable on Windows.
+// The tunnel service binds a full-tunnel peer's socket to the physical
+// default interface, which would bypass the entry tunnel entirely.
+func prepareMultiHop(entryIface string, entry, exit *TunnelConfig) error {
+	return fmt.Errorf("multi-hop is not supported on Windows")
+}
@@ -1,122 +1,237 @@
 package vpn
 
 import (
+	"encoding/base64"
 	"fmt"
-	"os"
-	"path/filepath"
+	"net"
+	"net/netip"
+	"regexp"
+	"strconv"
 	"strings"
+	"time"
+
+	"github.com/nikola43/aureo-vpn-client/internal/secrets"
+	"golang.zx2c4.com/wireguard/wgctrl/wgtypes"
 )
 
-// WireGuardManager manages WireGuard VPN connections
-type WireGuardManager struct {
-	configDir string
+// TunnelConfig describes a single WireGuard interface and its peer. Keys are
+// base64 text in buffers the caller wipes once the tunnel is up; backends
+// that keep the config hold their own copy.
+type TunnelConfig struct {
+	PrivateKey    secrets.Buffer
+	Address       string
+	DNS           string
+	MTU           int
+	FwMark        string
+	PeerPublicKey string
+	Endpoint      string
+	AllowedIPs    []string
+	Keepalive     int
+	PostUp        []string
+	PostDown      []string
+	// PresharedKey adds a symmetric key to the handshake; empty disables it
+	PresharedKey secrets.Buffer
+}
+
+// WipeKeys clears the private and pre-shared keys
+func (cfg *TunnelConfig) WipeKeys() {
+	cfg.PrivateKey.Wipe()
+	cfg.PresharedKey.Wipe()
+}
+
+// cloneKeys returns the config with its own copy of the keys, so a backend
+// can keep it after the caller wipes its keys
+func (cfg TunnelConfig) cloneKeys() TunnelConfig {
+	cfg.PrivateKey = cfg.PrivateKey.Clone()
+	cfg.PresharedKey = cfg.PresharedKey.Clone()
+	return cfg
+}
+
+// hostnamePattern matches DNS names; anything else, such as a newline or
+// shell syntax, is rejected before it reaches a config file
+var hostnamePattern = regexp.MustCompile(`^[A-Za-z0-9]([A-Za-z0-9-]*[A-Za-z0-9])?(\.[A-Za-z0-9]([A-Za-z0-9-]*[A-Za-z0-9])?)*\.?$`)
+
+// Validate checks the fields that come from the API server before they are
+// rendered into a wg-quick config, whose hooks run as root
+func (cfg TunnelConfig) Validate() error {
+	if _, err := wgtypes.ParseKey(cfg.PeerPublicKey); err != nil {
+		return fmt.Errorf("invalid server public key: %w", err)
+	}
+	if _, err := netip.ParseAddr(cfg.Address); err != nil {
+		return fmt.Errorf("invalid client address %q", cfg.Address)
+	}
+	if cfg.DNS != "" {
+		for _, server := range strings.Split(cfg.DNS, ",") {
+			server = strings.TrimSpace(server)
+			if _, err := netip.ParseAddr(server); err != nil && !hostnamePattern.MatchString(server) {
+				return fmt.Errorf("invalid DNS server %q", server)
+			}
+		}
+	}
+	host, port, err := net.SplitHostPort(cfg.Endpoint)
+	if err != nil {
+		return fmt.Errorf("invalid endpoint %q: %w", cfg.Endpoint, err)
+	}
+	if _, err := netip.ParseAddr(host); err != nil && !hostnamePattern.MatchString(host) {
+		return fmt.Errorf("invalid endpoint host %q", host)
+	}
+	if n, err := strconv.Atoi(port); err != nil || n < 1 || n > 65535 {
+		return fmt.Errorf("invalid endpoint port %q", port)
+	}
+	for _, prefix := range cfg.AllowedIPs {
+		if _, err := netip.ParsePrefix(prefix); err != nil {
+			return fmt.Errorf("invalid allowed IPs %q", prefix)
+		}
+	}
+	for _, key := range []secrets.Buffer{cfg.PrivateKey, cfg.PresharedKey} {
+		if len(key) == 0 {
+			continue
+		}
+		parsed, err := decodeKey(key)
+		clear(parsed[:])
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// nameservers returns the DNS entries that are server addresses, leaving
+// out search domains
+func (cfg TunnelConfig) nameservers() []string {
+	var servers []string
+	for _, server := range strings.Split(cfg.DNS, ",") {
+		server = strings.TrimSpace(server)
+		if _, err := netip.ParseAddr(server); err == nil {
+			servers = append(servers, server)
+		}
+	}
+	return servers
 }
 
-// NewWireGuardManager creates a new WireGuard manager
-func NewWireGuardManager() (*WireGuardManager, error) {
-	homeDir, err := os.UserHomeDir()
+// GeneratePresharedKey generates a random WireGuard pre-shared key
+func GeneratePresharedKey() (secrets.Buffer, error) {
+	key, err := wgtypes.GenerateKey()
 	if err != nil {
-		return nil, fmt.Errorf("failed to get home directory: %w", err)
+		return nil, fmt.Errorf("failed to generate pre-shared key: %w", err)
 	}
+	defer clear(key[:])
+	return encodeKey(&key), nil
+}
 
-	configDir := filepath.Join(homeDir, ".aureo-vpn")
-	if err := os.MkdirAll(configDir, 0700); err != nil {
-		return nil, fmt.Errorf("failed to create config directory: %w", err)
+// GenerateKeys generates a WireGuard key pair
+func GenerateKeys() (privateKey secrets.Buffer, publicKey string, err error) {
+	key, err := wgtypes.GeneratePrivateKey()
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to generate private key: %w", err)
 	}
+	defer clear(key[:])
+	return encodeKey(&key), key.PublicKey().String(), nil
+}
 
-	return &WireGuardManager{
-		configDir: configDir,
-	}, nil
+// encodeKey returns the base64 text of a key
+func encodeKey(key *wgtypes.Key) secrets.Buffer {
+	text := make(secrets.Buffer, base64.StdEncoding.EncodedLen(wgtypes.KeyLen))
+	base64.StdEncoding.Encode(text, key[:])
+	return text
 }
 
-// WriteConfig writes a WireGuard configuration file
-func (m *WireGuardManager) WriteConfig(privateKey, clientIP, serverPublicKey, serverEndpoint, dns string) error {
-	configPath := filepath.Join(m.configDir, "wg0.conf")
+// decodeKey parses the base64 text of a key. The caller clears the key
+// once it has been handed to the device.
+func decodeKey(text secrets.Buffer) (wgtypes.Key, error) {
+	var key wgtypes.Key
+	raw := make([]byte, base64.StdEncoding.DecodedLen(len(text)))
+	defer clear(raw)
 
-	// Build config without leading spaces/tabs
-	config := "[Interface]\n"
-	config += "PrivateKey = " + privateKey + "\n"
-	config += "Address = " + clientIP + "/32\n"
-	config += "DNS = " + dns + "\n"
-	config += "\n"
-	config += "[Peer]\n"
-	config += "PublicKey = " + serverPublicKey + "\n"
-	config += "Endpoint = " + serverEndpoint + "\n"
-	config += "AllowedIPs = 0.0.0.0/0\n"
-	config += "PersistentKeepalive = 25\n"
+	n, err := base64.StdEncoding.Decode(raw, text)
+	if err != nil {
+		return key, fmt.Errorf("key is not valid base64")
+	}
+	if n != wgtypes.KeyLen {
+		return key, fmt.Errorf("key must be %d bytes", wgtypes.KeyLen)
+	}
+	copy(key[:], raw)
+	return key, nil
+}
 
-	if err := os.WriteFile(configPath, []byte(config), 0600); err != nil {
-		return fmt.Errorf("failed to write config file: %w", err)
+// renderConfig renders a TunnelConfig in wg-quick format. The keys are only
+// copied into the returned buffer, which the caller wipes once written; a
+// config without keys leaves them for a PostUp hook to set.
+func renderConfig(cfg TunnelConfig) secrets.Buffer {
+	// Build config without leading spaces/tabs
+	var iface strings.Builder
+	iface.WriteString("Address = " + cfg.Address + "/32\n")
+	if cfg.DNS != "" {
+		iface.WriteString("DNS = " + cfg.DNS + "\n")
+	}
+	if cfg.MTU > 0 {
+		fmt.Fprintf(&iface, "MTU = %d\n", cfg.MTU)
+	}
+	if cfg.FwMark != "" {
+		iface.WriteString("FwMark = " + cfg.FwMark + "\n")
+	}
+	for _, cmd := range cfg.PostUp {
+		iface.WriteString("PostUp = " + cmd + "\n")
+	}
+	for _, cmd := range cfg.PostDown {
+		iface.WriteString("PostDown = " + cmd + "\n")
 	}
 
-	return nil
-}
+	var peer strings.Builder
+	peer.WriteString("PublicKey = " + cfg.PeerPublicKey + "\n")
+	peer.WriteString("Endpoint = " + cfg.Endpoint + "\n")
+	peer.WriteString("AllowedIPs = " + strings.Join(cfg.AllowedIPs, ", ") + "\n")
+	if cfg.Keepalive > 0 {
+		fmt.Fprintf(&peer, "PersistentKeepalive = %d\n", cfg.Keepalive)
+	}
 
-// parseTransferSize parses WireGuard transfer sizes like "1.23 KiB", "2.34 MiB", "567 B"
-func parseTransferSize(sizeStr string) int64 {
-	// Remove "received" or "sent" suffix
-	sizeStr = strings.TrimSpace(sizeStr)
-	sizeStr = strings.Replace(sizeStr, "received", "", 1)
-	sizeStr = strings.Replace(sizeStr, "sent", "", 1)
-	sizeStr = strings.TrimSpace(sizeStr)
-
-	// Split number and unit
-	parts := strings.Fields(sizeStr)
-	if len(parts) < 2 {
-		return 0
-	}
-
-	value := 0.0
-	fmt.Sscanf(parts[0], "%f", &value)
-	unit := parts[1]
-
-	// Convert to bytes
-	switch unit {
-	case "B":
-		return int64(value)
-	case "KiB":
-		return int64(value * 1024)
-	case "MiB":
-		return int64(value * 1024 * 1024)
-	case "GiB":
-		return int64(value * 1024 * 1024 * 1024)
-	case "TiB":
-		return int64(value * 1024 * 1024 * 1024 * 1024)
-	default:
-		return 0
+	// Size the buffer up front so appending never leaves a stray copy of
+	// the keys in a discarded array; 64 bytes covers headers and key names
+	config := make(secrets.Buffer, 0, iface.Len()+peer.Len()+len(cfg.PrivateKey)+len(cfg.PresharedKey)+64)
+	config = append(config, "[Interface]\n"...)
+	if len(cfg.PrivateKey) > 0 {
+		config = append(config, "PrivateKey = "...)
+		config = append(config, cfg.PrivateKey...)
+		config = append(config, '\n')
+	}
+	config = append(config, iface.String()...)
+	config = append(config, "\n[Peer]\n"...)
+	if len(cfg.PresharedKey) > 0 {
+		config = append(config, "PresharedKey = "...)
+		config = append(config, cfg.PresharedKey...)
+		config = append(config, '\n')
 	}
+	config = append(config, peer.String()...)
+
+	return config
 }
 
-// parseWgShowStats parses wg show output for transfer and handshake stats
-func parseWgShowStats(output string) (bytesSent, bytesReceived int64, latestHandshake string) {
-	lines := strings.Split(output, "\n")
-
-	for _, line := range lines {
-		line = strings.TrimSpace(line)
-
-		if strings.HasPrefix(line, "transfer:") {
-			parts := strings.Split(line, "transfer:")
-			if len(parts) > 1 {
-				transferData := strings.TrimSpace(parts[1])
-				transferParts := strings.Split(transferData, ",")
-				for _, part := range transferParts {
-					part = strings.TrimSpace(part)
-					if strings.Contains(part, "received") {
-						bytesReceived = parseTransferSize(part)
-					} else if strings.Contains(part, "sent") {
-						bytesSent = parseTransferSize(part)
-					}
-				}
-			}
+// parseWgDump parses wg show <iface> dump output: a tab-separated line for
+// the interface, then one per peer with its public key, pre-shared key,
+// endpoint, allowed IPs, latest handshake as a Unix time, bytes received,
+// bytes sent and keepalive. The interface line holds the private key and is
+// skipped.
+func parseWgDump(output string) Stats {
+	var stats Stats
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	for _, line := range lines[1:] {
+		fields := strings.Split(line, "\t")
+		if len(fields) < 7 {
+			continue
 		}
 
-		if strings.HasPrefix(line, "latest handshake:") {
-			parts := strings.Split(line, "latest handshake:")
-			if len(parts) > 1 {
-				latestHandshake = strings.TrimSpace(parts[1])
+		if fields[2] != "(none)" {
+			stats.Endpoint = fields[2]
+		}
+		if handshake, err := strconv.ParseInt(fields[4], 10, 64); err == nil && handshake > 0 {
+			if t := time.Unix(handshake, 0); t.After(stats.LastHandshake) {
+				stats.LastHandshake = t
 			}
 		}
+		rx, _ := strconv.ParseInt(fields[5], 10, 64)
+		tx, _ := strconv.ParseInt(fields[6], 10, 64)
+		stats.BytesReceived += rx
+		stats.BytesSent += tx
 	}
-
-	return
+	return stats
 }
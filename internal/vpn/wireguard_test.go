@@ -0,0 +1,27 @@
+package vpn
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseWgDump(t *testing.T) {
+	output := "cHJpdmF0ZQ==\tcHVibGlj\t51820\toff\n" +
+		"cGVlcg==\t(none)\t203.0.113.1:51820\t0.0.0.0/0\t1700000000\t1234567\t7654321\t25\n"
+
+	stats := parseWgDump(output)
+	if stats.BytesReceived != 1234567 || stats.BytesSent != 7654321 {
+		t.Errorf("counters = %d/%d, want 1234567/7654321", stats.BytesReceived, stats.BytesSent)
+	}
+	if !stats.LastHandshake.Equal(time.Unix(1700000000, 0)) {
+		t.Errorf("LastHandshake = %v", stats.LastHandshake)
+	}
+	if stats.Endpoint != "203.0.113.1:51820" {
+		t.Errorf("Endpoint = %q", stats.Endpoint)
+	}
+
+	never := parseWgDump("cHJpdmF0ZQ==\tcHVibGlj\t51820\toff\ncGVlcg==\t(none)\t(none)\t0.0.0.0/0\t0\t0\t0\toff\n")
+	if !never.LastHandshake.IsZero() || never.Endpoint != "" {
+		t.Errorf("peer without handshake = %+v", never)
+	}
+}
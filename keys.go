@@ -0,0 +1,189 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/nikola43/aureo-vpn-client/internal/keys"
+	"github.com/nikola43/aureo-vpn-client/internal/models"
+	"github.com/nikola43/aureo-vpn-client/internal/secrets"
+	"github.com/nikola43/aureo-vpn-client/internal/vpn"
+)
+
+// secretsService labels the app's entries in the system credential store
+const secretsService = "aureo-vpn"
+
+// openKeys opens the credential store and the WireGuard key index
+func (a *App) openKeys() error {
+	a.secrets = secrets.New(secretsService, a.configDir)
+
+	manager, err := keys.Open(filepath.Join(a.configDir, "keys.json"), a.secrets)
+	if err != nil {
+		return err
+	}
+	a.keys = manager
+	return nil
+}
+
+// registerPeer registers the account's key for a node and returns its
+// private key with the node's config, which carries the pre-shared key the
+// server settled on. The stored key is reused until it is due for rotation;
+// a rotated key is revoked once its replacement is registered. Without a
+// usable key store a throwaway key is used. The caller wipes the returned
+// private key and the config's pre-shared key once the tunnel is up.
+func (a *App) registerPeer(nodeID string) (secrets.Buffer, *models.WireGuardConfigResponse, error) {
+	var old keys.Key
+	var stored bool
+	if a.keys != nil {
+		key, material, ok, err := a.keys.Get(a.keyAccount(), nodeID)
+		if err != nil {
+			logger.Warn("failed to load WireGuard key", "node_id", nodeID, "error", err)
+		}
+		if ok && !key.Due(a.keyRotationInterval()) {
+			return a.reregisterPeer(nodeID, key, material)
+		}
+		material.Wipe()
+		// A key whose secret is unreadable is still registered, so it is
+		// revoked like a rotated one
+		old, stored = a.keys.Lookup(a.keyAccount(), nodeID)
+	}
+
+	privateKey, publicKey, err := vpn.GenerateKeys()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to generate keys: %w", err)
+	}
+	psk, err := vpn.GeneratePresharedKey()
+	if err != nil {
+		privateKey.Wipe()
+		return nil, nil, err
+	}
+	defer psk.Wipe()
+
+	configResp, err := a.apiClient.RegisterWireGuardPeer(nodeID, publicKey, psk)
+	if err != nil {
+		privateKey.Wipe()
+		return nil, nil, fmt.Errorf("failed to register with VPN server: %w", err)
+	}
+	if len(configResp.PresharedKey) == 0 {
+		logger.Warn("VPN server did not accept a pre-shared key", "node_id", nodeID)
+	}
+
+	if a.keys != nil {
+		if err := a.storePeerKey(nodeID, publicKey, keys.Material{PrivateKey: privateKey, PresharedKey: configResp.PresharedKey}); err != nil {
+			// A key that is not stored would stay registered for good, so it
+			// is revoked. The old key stays registered; it is still the
+			// stored one.
+			privateKey.Wipe()
+			if revokeErr := a.apiClient.RevokeWireGuardPeer(nodeID, publicKey); revokeErr != nil {
+				logger.Warn("failed to revoke unstored WireGuard key", "node_id", nodeID, "error", revokeErr)
+			}
+			return nil, nil, err
+		}
+	}
+	if stored && old.PublicKey != publicKey {
+		logger.Info("rotated WireGuard key", "node_id", nodeID, "age", time.Since(old.CreatedAt).Round(time.Hour))
+		if err := a.apiClient.RevokeWireGuardPeer(nodeID, old.PublicKey); err != nil {
+			logger.Warn("failed to revoke old WireGuard key", "node_id", nodeID, "error", err)
+		}
+	}
+	return privateKey, configResp, nil
+}
+
+// storePeerKey stores a newly registered key pair, trying once more if the
+// first attempt fails
+func (a *App) storePeerKey(nodeID, publicKey string, material keys.Material) error {
+	_, err := a.keys.Put(a.keyAccount(), nodeID, publicKey, material)
+	if err == nil {
+		return nil
+	}
+	logger.Warn("failed to store WireGuard key, retrying", "node_id", nodeID, "error", err)
+
+	if _, err := a.keys.Put(a.keyAccount(), nodeID, publicKey, material); err != nil {
+		return fmt.Errorf("failed to store WireGuard key: %w", err)
+	}
+	return nil
+}
+
+// reregisterPeer registers a stored key again, proposing its pre-shared key
+// (or a new one if it has none) and storing whichever the server settled on
+func (a *App) reregisterPeer(nodeID string, key keys.Key, material keys.Material) (secrets.Buffer, *models.WireGuardConfigResponse, error) {
+	// The config carries the server's copy of the pre-shared key, so the
+	// proposed one is no longer needed once registered
+	proposed := material.PresharedKey
+	if len(proposed) == 0 {
+		var err error
+		if proposed, err = vpn.GeneratePresharedKey(); err != nil {
+			material.Wipe()
+			return nil, nil, err
+		}
+	}
+	defer proposed.Wipe()
+
+	configResp, err := a.apiClient.RegisterWireGuardPeer(nodeID, key.PublicKey, proposed)
+	if err != nil {
+		material.Wipe()
+		return nil, nil, fmt.Errorf("failed to register with VPN server: %w", err)
+	}
+
+	if !configResp.PresharedKey.Equal(material.PresharedKey) {
+		material.PresharedKey = configResp.PresharedKey
+		if err := a.keys.SetMaterial(a.keyAccount(), nodeID, material); err != nil {
+			logger.Warn("failed to store pre-shared key", "node_id", nodeID, "error", err)
+		}
+	}
+	return material.PrivateKey, configResp, nil
+}
+
+// keyAccount names the signed-in account in the key store. Keys are
+// registered with one API server, so the same user on another server has
+// separate keys.
+func (a *App) keyAccount() string {
+	user := a.currentUser()
+	if user == nil {
+		return ""
+	}
+	return accountID(a.apiURL, user.ID)
+}
+
+// keyRotationInterval returns the configured key lifetime
+func (a *App) keyRotationInterval() time.Duration {
+	return time.Duration(a.settings.Preferences.KeyRotationDays) * 24 * time.Hour
+}
+
+// GetWireGuardKeys returns the public keys stored for the signed-in account
+func (a *App) GetWireGuardKeys() ([]map[string]interface{}, error) {
+	if a.currentUser() == nil {
+		return nil, fmt.Errorf("no user logged in")
+	}
+	if a.keys == nil {
+		return nil, fmt.Errorf("key store not available")
+	}
+
+	interval := a.keyRotationInterval()
+	list := []map[string]interface{}{}
+	for _, key := range a.keys.List(a.keyAccount()) {
+		entry := map[string]interface{}{
+			"node_id":      key.NodeID,
+			"public_key":   key.PublicKey,
+			"created_at":   key.CreatedAt,
+			"rotation_due": key.Due(interval),
+		}
+		if interval > 0 {
+			entry["rotates_at"] = key.CreatedAt.Add(interval)
+		}
+		list = append(list, entry)
+	}
+	return list, nil
+}
+
+// RotateWireGuardKeys replaces every stored key on its next connect
+func (a *App) RotateWireGuardKeys() error {
+	if a.currentUser() == nil {
+		return fmt.Errorf("no user logged in")
+	}
+	if a.keys == nil {
+		return fmt.Errorf("key store not available")
+	}
+	return a.keys.RotateAll(a.keyAccount())
+}
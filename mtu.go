@@ -0,0 +1,125 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"time"
+
+	"github.com/nikola43/aureo-vpn-client/internal/netcheck"
+	"github.com/nikola43/aureo-vpn-client/internal/netmon"
+	"github.com/nikola43/aureo-vpn-client/internal/nodes"
+	"github.com/nikola43/aureo-vpn-client/internal/vpn"
+)
+
+const (
+	// mtuMaxAge is how long a discovered MTU is reused before the path is
+	// probed again
+	mtuMaxAge = 7 * 24 * time.Hour
+	// mtuProbeTimeout bounds path MTU discovery during connect
+	mtuProbeTimeout = 10 * time.Second
+	// defaultWireGuardPort is probed when a node does not list its port
+	defaultWireGuardPort = 51820
+)
+
+// discoveredMTU returns the tunnel MTU for a node's endpoint on the current
+// network, probing the path when no recent measurement is stored. It returns
+// 0 when the MTU could not be determined, leaving the backend default.
+func (a *App) discoveredMTU(nodeID, endpoint string) int {
+	networkID := a.currentNetworkID()
+	if a.nodeStore != nil && networkID != "" {
+		if entry, ok := a.nodeStore.MTU(networkID, nodeID, mtuMaxAge); ok {
+			return entry.MTU
+		}
+	}
+
+	entry, err := a.probeMTU(nodeID, networkID, endpoint)
+	if err != nil {
+		logger.Warn("path MTU discovery failed", "node_id", nodeID, "error", err)
+		return 0
+	}
+	return entry.MTU
+}
+
+// probeMTU measures the path MTU to endpoint and stores the derived tunnel
+// MTU. Measurements on an unidentified network are not stored, since they
+// would be reused on any other such network.
+func (a *App) probeMTU(nodeID, networkID, endpoint string) (nodes.MTU, error) {
+	host, portStr, err := net.SplitHostPort(endpoint)
+	if err != nil {
+		return nodes.MTU{}, fmt.Errorf("invalid endpoint %q: %w", endpoint, err)
+	}
+	port, err := strconv.Atoi(portStr)
+	if err != nil {
+		return nodes.MTU{}, fmt.Errorf("invalid endpoint %q: %w", endpoint, err)
+	}
+
+	ctx, cancel := context.WithTimeout(a.ctx, mtuProbeTimeout)
+	defer cancel()
+
+	path, err := netcheck.ProbePathMTU(ctx, host, port)
+	if err != nil {
+		return nodes.MTU{}, err
+	}
+
+	entry := nodes.MTU{
+		NodeID:     nodeID,
+		NetworkID:  networkID,
+		PathMTU:    path.MTU,
+		MTU:        vpn.TunnelMTU(path.MTU, path.IPv6),
+		Method:     path.Method,
+		MeasuredAt: time.Now(),
+	}
+	logger.Info("path MTU discovered", "node_id", nodeID, "path_mtu", entry.PathMTU, "mtu", entry.MTU, "method", entry.Method)
+
+	if a.nodeStore != nil && networkID != "" {
+		if err := a.nodeStore.SetMTU(entry); err != nil {
+			logger.Warn("failed to store MTU", "error", err)
+		}
+	}
+	return entry, nil
+}
+
+// currentNetworkID returns the ID of the network the host is on, or an
+// empty string if it cannot be determined
+func (a *App) currentNetworkID() string {
+	network, err := netmon.Current(a.ctx)
+	if err != nil {
+		return ""
+	}
+	return network.ID
+}
+
+// DiscoverMTU probes the path to a node from the current network and stores
+// the resulting tunnel MTU for later connections
+func (a *App) DiscoverMTU(nodeID string) (nodes.MTU, error) {
+	if a.activeTunnel() != nil {
+		return nodes.MTU{}, fmt.Errorf("disconnect first to measure the path to the node")
+	}
+
+	node, err := a.apiClient.GetNode(nodeID)
+	if err != nil {
+		return nodes.MTU{}, fmt.Errorf("failed to get node: %w", err)
+	}
+
+	host := node.PublicIP
+	if host == "" {
+		host = node.Hostname
+	}
+	port := node.WireGuardPort
+	if port == 0 {
+		port = defaultWireGuardPort
+	}
+
+	return a.probeMTU(nodeID, a.currentNetworkID(), net.JoinHostPort(host, strconv.Itoa(port)))
+}
+
+// ClearDiscoveredMTUs forgets every stored MTU, so the next connections probe again
+func (a *App) ClearDiscoveredMTUs() error {
+	store, err := a.requireNodeStore()
+	if err != nil {
+		return err
+	}
+	return store.ClearMTUs()
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/nikola43/aureo-vpn-client/internal/models"
+	"github.com/nikola43/aureo-vpn-client/internal/nodes"
+)
+
+// defaultRecentsLimit is the number of recent nodes returned when no limit is given
+const defaultRecentsLimit = 10
+
+// openNodeStore opens the favorites and connection history file
+func (a *App) openNodeStore() error {
+	store, err := nodes.Open(filepath.Join(a.configDir, "nodes.json"))
+	if err != nil {
+		return err
+	}
+	a.nodeStore = store
+	return nil
+}
+
+// recordConnect adds a successful connection to the history
+func (a *App) recordConnect(nodeID, nodeName, protocol string) {
+	if a.nodeStore == nil {
+		return
+	}
+	if err := a.nodeStore.Connected(nodeID, nodeName, protocol, time.Now()); err != nil {
+		logger.Warn("failed to record connection", "error", err)
+	}
+}
+
+// recordFailure adds a failed connection attempt to the history
+func (a *App) recordFailure(nodeID, protocol string, cause error) {
+	if a.nodeStore == nil {
+		return
+	}
+	if err := a.nodeStore.Failed(nodeID, "", protocol, time.Now(), cause); err != nil {
+		logger.Warn("failed to record connection", "error", err)
+	}
+}
+
+// recordDisconnect ends the open history entries
+func (a *App) recordDisconnect() {
+	if a.nodeStore == nil {
+		return
+	}
+	if err := a.nodeStore.Disconnected(time.Now()); err != nil {
+		logger.Warn("failed to record disconnect", "error", err)
+	}
+}
+
+// requireNodeStore returns the node store or an error if it failed to open
+func (a *App) requireNodeStore() (*nodes.Store, error) {
+	if a.nodeStore == nil {
+		return nil, fmt.Errorf("node store not available")
+	}
+	return a.nodeStore, nil
+}
+
+// GetFavorites returns the favorite nodes that are still listed by the API
+func (a *App) GetFavorites() ([]models.VPNNode, error) {
+	store, err := a.requireNodeStore()
+	if err != nil {
+		return nil, err
+	}
+
+	list, err := a.apiClient.GetNodes("", "")
+	if err != nil {
+		return nil, err
+	}
+
+	byID := make(map[string]models.VPNNode, len(list))
+	for _, node := range list {
+		byID[node.ID] = node
+	}
+
+	favorites := []models.VPNNode{}
+	for _, id := range store.Favorites() {
+		if node, ok := byID[id]; ok {
+			favorites = append(favorites, node)
+		}
+	}
+	return favorites, nil
+}
+
+// GetFavoriteIDs returns the IDs of the favorite nodes
+func (a *App) GetFavoriteIDs() ([]string, error) {
+	store, err := a.requireNodeStore()
+	if err != nil {
+		return nil, err
+	}
+	return store.Favorites(), nil
+}
+
+// AddFavorite marks a node as favorite
+func (a *App) AddFavorite(nodeID string) error {
+	store, err := a.requireNodeStore()
+	if err != nil {
+		return err
+	}
+	return store.AddFavorite(nodeID)
+}
+
+// RemoveFavorite unmarks a favorite node
+func (a *App) RemoveFavorite(nodeID string) error {
+	store, err := a.requireNodeStore()
+	if err != nil {
+		return err
+	}
+	return store.RemoveFavorite(nodeID)
+}
+
+// GetRecents returns the recently used nodes with their success and
+// failure counts and connection durations
+func (a *App) GetRecents(limit int) ([]nodes.Recent, error) {
+	store, err := a.requireNodeStore()
+	if err != nil {
+		return nil, err
+	}
+	if limit <= 0 {
+		limit = defaultRecentsLimit
+	}
+	return store.Recents(limit), nil
+}
+
+// GetConnectionHistory returns the most recent connection attempts
+func (a *App) GetConnectionHistory(limit int) ([]nodes.Attempt, error) {
+	store, err := a.requireNodeStore()
+	if err != nil {
+		return nil, err
+	}
+	return store.Attempts(limit), nil
+}
+
+// GetNodeGroups returns computed groups: fastest in each country, least
+// loaded, community-operated, first-party and streaming nodes
+func (a *App) GetNodeGroups() ([]nodes.Group, error) {
+	store, err := a.requireNodeStore()
+	if err != nil {
+		return nil, err
+	}
+
+	list, err := a.apiClient.GetNodes("", "")
+	if err != nil {
+		return nil, err
+	}
+	return nodes.Groups(list, store.Tags()), nil
+}
+
+// GetNodeTags returns the user's tags for every tagged node
+func (a *App) GetNodeTags() (map[string][]string, error) {
+	store, err := a.requireNodeStore()
+	if err != nil {
+		return nil, err
+	}
+	return store.Tags(), nil
+}
+
+// SetNodeTags replaces a node's tags, e.g. "streaming"
+func (a *App) SetNodeTags(nodeID string, tags []string) error {
+	store, err := a.requireNodeStore()
+	if err != nil {
+		return err
+	}
+	return store.SetTags(nodeID, tags)
+}
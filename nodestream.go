@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/nikola43/aureo-vpn-client/internal/api"
+	"github.com/nikola43/aureo-vpn-client/internal/models"
+	"github.com/nikola43/aureo-vpn-client/internal/nodes"
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// nodePollInterval is how often the node list is refetched when the server
+// has no status feed
+const nodePollInterval = 30 * time.Second
+
+// startNodeStream keeps the live node list current from the server's status
+// feed, falling back to polling, and emits changes as "nodes:update" events
+func (a *App) startNodeStream() {
+	a.stopNodeStatus()
+
+	ctx, cancel := context.WithCancel(a.ctx)
+	a.stopNodeStream = cancel
+	client := a.apiClient
+
+	go func() {
+		err := client.StreamNodeStatus(ctx, func(msg api.NodeStatusMessage) {
+			deltas, unknown := a.liveNodes.Apply(msg)
+			a.emitNodeDeltas(deltas)
+			if len(unknown) > 0 {
+				a.refreshLiveNodes(client)
+			}
+		}, func() {
+			a.refreshLiveNodes(client)
+		})
+		if !errors.Is(err, api.ErrStreamUnsupported) {
+			return
+		}
+
+		logger.Info("node status feed unavailable, polling", "interval", nodePollInterval)
+		a.refreshLiveNodes(client)
+		ticker := time.NewTicker(nodePollInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				a.refreshLiveNodes(client)
+			}
+		}
+	}()
+}
+
+// stopNodeStatus stops following node status
+func (a *App) stopNodeStatus() {
+	if a.stopNodeStream != nil {
+		a.stopNodeStream()
+		a.stopNodeStream = nil
+	}
+}
+
+// refreshLiveNodes refetches the full node list and emits what changed
+func (a *App) refreshLiveNodes(client *api.Client) {
+	list, err := client.GetNodes("", "")
+	if err != nil {
+		logger.Warn("failed to refresh nodes", "error", err)
+		return
+	}
+	a.emitNodeDeltas(a.liveNodes.Replace(list))
+}
+
+// emitNodeDeltas sends node changes to the UI
+func (a *App) emitNodeDeltas(deltas []nodes.Delta) {
+	if len(deltas) == 0 {
+		return
+	}
+	runtime.EventsEmit(a.ctx, "nodes:update", deltas)
+}
+
+// GetLiveNodes returns the node list kept current by the status feed,
+// least loaded first. It is empty until the first list has been fetched.
+func (a *App) GetLiveNodes() []models.VPNNode {
+	return a.liveNodes.Nodes()
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/netip"
+
+	"github.com/nikola43/aureo-vpn-client/internal/models"
+	"github.com/nikola43/aureo-vpn-client/internal/obfs"
+	"github.com/nikola43/aureo-vpn-client/internal/vpn"
+)
+
+// relaysFor returns the relays offering a transport
+func relaysFor(relays []models.ObfuscationRelay, transport string) []models.ObfuscationRelay {
+	var matching []models.ObfuscationRelay
+	for _, relay := range relays {
+		if relay.Transport == transport {
+			matching = append(matching, relay)
+		}
+	}
+	return matching
+}
+
+// bringUpRelay tunnels WireGuard through each relay in turn until the
+// server answers the handshake. fallback records that UDP was tried first.
+func (a *App) bringUpRelay(tunnel vpn.WireGuardTunnel, cfg vpn.TunnelConfig, opts connectOptions, relays []models.ObfuscationRelay, fallback bool) error {
+	if len(relays) == 0 {
+		return fmt.Errorf("node does not offer an obfuscated transport")
+	}
+
+	var lastErr error
+	for _, relay := range relays {
+		proxy, err := obfs.Start(a.ctx, obfs.Config{
+			Transport:  relay.Transport,
+			Address:    relay.Address,
+			ServerName: relay.ServerName,
+		})
+		if err != nil {
+			logger.Warn("obfuscation relay unavailable", "relay", relay.Address, "error", err)
+			lastErr = err
+			continue
+		}
+
+		// The relay connection itself must not be routed into the tunnel
+		if remote := proxy.RemoteAddr(); remote.Is4() {
+			relayOpts := opts
+			relayOpts.Exclude = append(append([]string{}, opts.Exclude...), remote.String())
+			cfg.AllowedIPs, err = relayOpts.allowedIPs(net.JoinHostPort(remote.String(), "0"))
+			if err != nil {
+				proxy.Close()
+				return err
+			}
+		}
+
+		cfg.Endpoint = proxy.LocalAddr()
+		if err := a.tryWireGuard(tunnel, cfg); err != nil {
+			proxy.Close()
+			lastErr = err
+			continue
+		}
+
+		a.relay = proxy
+		a.setTransport(transportInfo{Protocol: relay.Transport, Endpoint: relay.Address, Fallback: fallback})
+		logger.Info("connected through obfuscation relay", "transport", relay.Transport, "relay", relay.Address)
+		return nil
+	}
+
+	return fmt.Errorf("failed to connect to VPN over an obfuscated transport: %w", lastErr)
+}
+
+// isRelayed reports whether a tunnel was connected through an obfuscation
+// relay, whose local endpoint is a loopback address
+func (a *App) isRelayed(tunnel vpn.Tunnel) bool {
+	ctx, cancel := context.WithTimeout(a.ctx, statsInterval)
+	defer cancel()
+
+	stats, err := tunnel.Stats(ctx)
+	if err != nil {
+		return false
+	}
+	host, _, err := net.SplitHostPort(stats.Endpoint)
+	if err != nil {
+		return false
+	}
+	ip, err := netip.ParseAddr(host)
+	return err == nil && ip.IsLoopback()
+}
+
+// stopRelay closes the obfuscation relay connection, if any
+func (a *App) stopRelay() {
+	if a.relay != nil {
+		a.relay.Close()
+		a.relay = nil
+	}
+}
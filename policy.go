@@ -0,0 +1,138 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/nikola43/aureo-vpn-client/internal/config"
+	"github.com/nikola43/aureo-vpn-client/internal/netmon"
+	"github.com/nikola43/aureo-vpn-client/internal/policy"
+	"github.com/nikola43/aureo-vpn-client/internal/vpn"
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// startPolicyEngine starts applying the auto-connect rules to network changes
+func (a *App) startPolicyEngine() {
+	a.policy = policy.New(a.settings.AutoConnect, policy.Actions{
+		Connect:     a.autoConnect,
+		Disconnect:  a.DisconnectVPN,
+		IsConnected: a.IsConnected,
+		Hold:        a.autoConnectHold,
+	})
+	a.policy.OnDecision = func(decision policy.Decision, network netmon.Network, err error) {
+		event := map[string]interface{}{
+			"action":  decision.Action,
+			"reason":  decision.Reason,
+			"network": network,
+		}
+		if err != nil {
+			event["error"] = err.Error()
+		}
+		runtime.EventsEmit(a.ctx, "policy:action", event)
+	}
+
+	go a.policy.Run(a.ctx)
+}
+
+// autoConnect connects with the profile chosen by the rules, or to the best
+// node with the preferred protocol
+func (a *App) autoConnect(rules config.AutoConnect) error {
+	if rules.Profile != "" {
+		_, err := a.ConnectProfile(rules.Profile)
+		return err
+	}
+
+	protocol := a.settings.Preferences.Protocol
+	if protocol == "" {
+		protocol = vpn.ProtocolWireGuard
+	}
+
+	node, err := a.bestNode("", protocol)
+	if err != nil {
+		return fmt.Errorf("failed to find best node: %w", err)
+	}
+
+	_, err = a.ConnectToVPN(node.ID, protocol)
+	return err
+}
+
+// autoConnectHold keeps the policy engine waiting until the user is signed
+// in and no captive portal is blocking the network
+func (a *App) autoConnectHold() string {
+	if a.currentUser() == nil {
+		return "not signed in"
+	}
+	if !a.IsConnected() && a.checkCaptivePortal() != nil {
+		return "captive portal"
+	}
+	return ""
+}
+
+// signedIn starts following node status and lets the policy engine act
+// once a user session is available
+func (a *App) signedIn() {
+	a.startNodeStream()
+	if a.policy != nil {
+		go a.policy.Reevaluate()
+	}
+}
+
+// GetAutoConnectRules returns the auto-connect rules
+func (a *App) GetAutoConnectRules() config.AutoConnect {
+	return a.settings.AutoConnect
+}
+
+// SetAutoConnectRules saves the auto-connect rules and applies them to the
+// current network on the next change
+func (a *App) SetAutoConnectRules(rules config.AutoConnect) error {
+	if rules.TrustedNetworks == nil {
+		rules.TrustedNetworks = []string{}
+	}
+	if rules.Profile != "" {
+		if _, ok := a.settings.Profile(rules.Profile); !ok {
+			return fmt.Errorf("profile not found: %s", rules.Profile)
+		}
+	}
+
+	a.settings.AutoConnect = rules
+	if err := a.settings.Save(a.configDir); err != nil {
+		return err
+	}
+
+	if a.policy != nil {
+		a.policy.SetRules(rules)
+	}
+	return nil
+}
+
+// GetCurrentNetwork returns the network the host is on and whether it is trusted
+func (a *App) GetCurrentNetwork() (map[string]interface{}, error) {
+	network, err := netmon.Current(a.ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		"id":        network.ID,
+		"type":      network.Type,
+		"interface": network.Interface,
+		"trusted":   a.settings.AutoConnect.IsTrusted(network.ID),
+	}, nil
+}
+
+// TrustCurrentNetwork adds the current network to the trusted list
+func (a *App) TrustCurrentNetwork() error {
+	network, err := netmon.Current(a.ctx)
+	if err != nil {
+		return err
+	}
+	if network.ID == "" {
+		return fmt.Errorf("not connected to a network")
+	}
+
+	rules := a.settings.AutoConnect
+	if rules.IsTrusted(network.ID) {
+		return nil
+	}
+	rules.TrustedNetworks = append(append([]string{}, rules.TrustedNetworks...), network.ID)
+	return a.SetAutoConnectRules(rules)
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/netip"
+
+	"github.com/nikola43/aureo-vpn-client/internal/config"
+	"github.com/nikola43/aureo-vpn-client/internal/vpn"
+)
+
+// connectOptions overrides what the node provides for a single connection
+type connectOptions struct {
+	// Include limits the tunnel to these prefixes; empty means all traffic
+	Include []string
+	// Exclude sends these prefixes around the tunnel
+	Exclude []string
+	DNS     []string
+	MTU     int
+	// Transport forces "udp", "tls" or "websocket"; empty tries UDP and falls
+	// back to the node's obfuscation relays
+	Transport string
+}
+
+// profileOptions converts a profile's split tunnel, DNS and MTU settings
+func profileOptions(p config.Profile) connectOptions {
+	opts := connectOptions{DNS: p.DNS, MTU: p.MTU, Transport: p.Transport}
+	switch p.SplitTunnel.Mode {
+	case config.SplitTunnelInclude:
+		opts.Include = p.SplitTunnel.Routes
+	case config.SplitTunnelExclude:
+		opts.Exclude = p.SplitTunnel.Routes
+	}
+	return opts
+}
+
+// allowedIPs returns the WireGuard AllowedIPs for the options. A split
+// tunnel is routed with plain routes, so the endpoint itself is excluded
+// to keep the encrypted traffic out of the tunnel.
+func (o connectOptions) allowedIPs(endpoint string) ([]string, error) {
+	if len(o.Include) == 0 && len(o.Exclude) == 0 {
+		return vpn.FullTunnel, nil
+	}
+
+	host, _, err := net.SplitHostPort(endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid endpoint %q: %w", endpoint, err)
+	}
+	if _, err := netip.ParseAddr(host); err != nil {
+		addrs, err := net.DefaultResolver.LookupHost(context.Background(), host)
+		if err != nil || len(addrs) == 0 {
+			return nil, fmt.Errorf("failed to resolve endpoint %s: %w", host, err)
+		}
+		host = addrs[0]
+	}
+
+	exclude := o.Exclude
+	if addr, err := netip.ParseAddr(host); err == nil && addr.Is4() {
+		exclude = append(append([]string{}, o.Exclude...), addr.String())
+	}
+
+	routes, err := vpn.SplitRoutes(o.Include, exclude)
+	if err != nil {
+		return nil, fmt.Errorf("invalid split tunnel routes: %w", err)
+	}
+	if len(routes) == 0 {
+		return nil, fmt.Errorf("split tunnel rules leave no traffic for the tunnel")
+	}
+	return routes, nil
+}
+
+// ListProfiles returns the saved connection profiles
+func (a *App) ListProfiles() []config.Profile {
+	return a.settings.Profiles
+}
+
+// SaveProfile adds a connection profile or replaces the one with the same name
+func (a *App) SaveProfile(profile config.Profile) error {
+	if profile.Protocol == "" {
+		profile.Protocol = a.settings.Preferences.Protocol
+	}
+	if profile.SplitTunnel.Mode == "" {
+		profile.SplitTunnel.Mode = config.SplitTunnelOff
+	}
+
+	if err := a.settings.PutProfile(profile); err != nil {
+		return err
+	}
+	return a.settings.Save(a.configDir)
+}
+
+// DeleteProfile removes a connection profile
+func (a *App) DeleteProfile(name string) error {
+	if err := a.settings.DeleteProfile(name); err != nil {
+		return err
+	}
+	if a.policy != nil {
+		a.policy.SetRules(a.settings.AutoConnect)
+	}
+	return a.settings.Save(a.configDir)
+}
+
+// ConnectProfile connects using a saved profile
+func (a *App) ConnectProfile(name string) (map[string]interface{}, error) {
+	a.connMu.Lock()
+	defer a.connMu.Unlock()
+
+	profile, ok := a.settings.Profile(name)
+	if !ok {
+		return nil, fmt.Errorf("profile not found: %s", name)
+	}
+
+	nodeID, err := a.profileNode(profile)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := a.connect(nodeID, profile.Protocol, profileOptions(profile))
+	if err != nil {
+		return nil, err
+	}
+
+	result["profile"] = profile.Name
+	result["kill_switch"] = profile.KillSwitchEnabled(a.settings.Preferences)
+	return result, nil
+}
+
+// profileNode resolves the node a profile connects to: its fixed node, or
+// the best node in its country or overall
+func (a *App) profileNode(profile config.Profile) (string, error) {
+	if profile.NodeID != "" {
+		return profile.NodeID, nil
+	}
+
+	node, err := a.bestNode(profile.Country, profile.Protocol)
+	if err != nil {
+		return "", fmt.Errorf("failed to find best node: %w", err)
+	}
+	return node.ID, nil
+}
+
+// GetPreferences returns the general settings
+func (a *App) GetPreferences() config.Preferences {
+	return a.settings.Preferences
+}
+
+// SavePreferences saves the general settings
+func (a *App) SavePreferences(prefs config.Preferences) error {
+	if prefs.Protocol == "" {
+		prefs.Protocol = vpn.ProtocolWireGuard
+	}
+	if err := prefs.Validate(); err != nil {
+		return err
+	}
+
+	a.settings.Preferences = prefs
+	return a.settings.Save(a.configDir)
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"github.com/nikola43/aureo-vpn-client/internal/vpn"
+)
+
+const (
+	// statsInterval is how often the active tunnel's counters are sampled
+	statsInterval = time.Second
+	// statsHistoryWindow is how much throughput history is kept
+	statsHistoryWindow = 15 * time.Minute
+)
+
+// startSampling records the tunnel's counters every statsInterval until
+// stopStatsSampling is called, so history keeps growing while the window
+// is hidden
+func (a *App) startSampling(tunnel vpn.Tunnel) {
+	a.stopStatsSampling()
+	a.history.Reset()
+	a.startUsageSession(tunnel.Protocol())
+
+	ctx, cancel := context.WithCancel(a.ctx)
+	a.stopSampling = cancel
+
+	go func() {
+		ticker := time.NewTicker(statsInterval)
+		defer ticker.Stop()
+
+		for {
+			if !a.sampleStats(ctx, tunnel) {
+				if ctx.Err() == nil {
+					a.disconnectTunnel(tunnel)
+				}
+				return
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// sampleStats reads the tunnel's counters once and adds them to the history
+// and usage ledger. It returns false when the connection must be dropped.
+func (a *App) sampleStats(ctx context.Context, tunnel vpn.Tunnel) bool {
+	ctx, cancel := context.WithTimeout(ctx, statsInterval)
+	defer cancel()
+
+	stats, err := tunnel.Stats(ctx)
+	if err != nil {
+		logger.Debug("failed to sample tunnel stats", "error", err)
+		return true
+	}
+	now := time.Now()
+	a.history.Add(stats, now)
+	return a.recordUsage(stats.BytesSent, stats.BytesReceived, now)
+}
+
+// stopStatsSampling stops the background sampler and ends the usage
+// session, keeping the history
+func (a *App) stopStatsSampling() {
+	if a.stopSampling != nil {
+		a.stopSampling()
+		a.stopSampling = nil
+		a.endUsageSession()
+	}
+}
+
+// GetStatsHistory returns throughput samples from the last minutes, oldest
+// first. A non-positive value returns the whole history.
+func (a *App) GetStatsHistory(minutes int) []vpn.Sample {
+	since := time.Time{}
+	if minutes > 0 {
+		since = time.Now().Add(-time.Duration(minutes) * time.Minute)
+	}
+
+	samples := a.history.Since(since)
+	if samples == nil {
+		return []vpn.Sample{}
+	}
+	return samples
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/nikola43/aureo-vpn-client/internal/usage"
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+const bytesPerGB = 1 << 30
+
+// openUsageLedger opens the local traffic ledger in the config directory
+func (a *App) openUsageLedger() error {
+	ledger, err := usage.Open(filepath.Join(a.configDir, "usage.db"))
+	if err != nil {
+		return err
+	}
+	a.usage = ledger
+	return nil
+}
+
+// startUsageSession begins recording traffic for the current connection
+func (a *App) startUsageSession(protocol string) {
+	if a.usage == nil {
+		return
+	}
+	a.capWarned = time.Time{}
+	if err := a.usage.StartSession(a.nodeID, a.nodeName, protocol, time.Now()); err != nil {
+		logger.Warn("failed to start usage session", "error", err)
+	}
+}
+
+// endUsageSession stops recording traffic
+func (a *App) endUsageSession() {
+	if a.usage == nil {
+		return
+	}
+	if err := a.usage.EndSession(time.Now()); err != nil {
+		logger.Warn("failed to end usage session", "error", err)
+	}
+}
+
+// recordUsage adds a stats sample to the ledger and enforces data caps. It
+// returns false once the hard cap is reached.
+func (a *App) recordUsage(sent, received int64, at time.Time) bool {
+	if a.usage == nil {
+		return true
+	}
+	if err := a.usage.Record(sent, received, at); err != nil {
+		logger.Warn("failed to record usage", "error", err)
+		return true
+	}
+
+	status, err := a.usage.CheckCaps(at)
+	if err != nil {
+		logger.Warn("failed to check data caps", "error", err)
+		return true
+	}
+
+	switch status.Level {
+	case usage.CapHard:
+		logger.Warn("hard data cap reached, disconnecting", "used", status.Used, "cap", status.HardBytes)
+		runtime.EventsEmit(a.ctx, "usage:cap", status)
+		return false
+	case usage.CapSoft:
+		// Warn once per period
+		if !a.capWarned.Equal(status.PeriodStart) {
+			a.capWarned = status.PeriodStart
+			logger.Warn("soft data cap reached", "used", status.Used, "cap", status.SoftBytes)
+			runtime.EventsEmit(a.ctx, "usage:cap", status)
+		}
+	}
+	return true
+}
+
+// checkHardCap refuses to connect once the hard cap has been reached
+func (a *App) checkHardCap() error {
+	if a.usage == nil {
+		return nil
+	}
+	status, err := a.usage.CheckCaps(time.Now())
+	if err != nil {
+		return nil
+	}
+	if status.Level == usage.CapHard {
+		return fmt.Errorf("data cap of %.2f GB for this %s reached", float64(status.HardBytes)/bytesPerGB, status.Period)
+	}
+	return nil
+}
+
+// GetUsage returns the traffic of the last count periods (day, week or
+// month), oldest first, with a per-node breakdown
+func (a *App) GetUsage(period string, count int) ([]usage.Rollup, error) {
+	if a.usage == nil {
+		return nil, fmt.Errorf("usage ledger not available")
+	}
+	return a.usage.Rollups(period, count, time.Now())
+}
+
+// GetUsageSessions returns the most recent sessions recorded in the ledger
+func (a *App) GetUsageSessions(limit int) ([]usage.Session, error) {
+	if a.usage == nil {
+		return nil, fmt.Errorf("usage ledger not available")
+	}
+	sessions, err := a.usage.Sessions(time.Time{}, time.Now().Add(time.Minute), limit)
+	if err != nil {
+		return nil, err
+	}
+	if sessions == nil {
+		return []usage.Session{}, nil
+	}
+	return sessions, nil
+}
+
+// SetDataCaps sets soft and hard caps in GB per period (day, week or
+// month). Zero disables a cap.
+func (a *App) SetDataCaps(period string, softGB, hardGB float64) error {
+	if a.usage == nil {
+		return fmt.Errorf("usage ledger not available")
+	}
+	return a.usage.SetCaps(usage.Caps{
+		Period:    period,
+		SoftBytes: int64(softGB * bytesPerGB),
+		HardBytes: int64(hardGB * bytesPerGB),
+	})
+}
+
+// GetDataCaps returns the caps and the usage of the current period
+func (a *App) GetDataCaps() (usage.CapStatus, error) {
+	if a.usage == nil {
+		return usage.CapStatus{}, fmt.Errorf("usage ledger not available")
+	}
+	return a.usage.CheckCaps(time.Now())
+}
+
+// ExportUsageCSV writes all recorded sessions to a CSV file in the config
+// directory and returns its path
+func (a *App) ExportUsageCSV() (string, error) {
+	if a.usage == nil {
+		return "", fmt.Errorf("usage ledger not available")
+	}
+
+	path := filepath.Join(a.configDir, fmt.Sprintf("aureo-vpn-usage-%s.csv", time.Now().Format("20060102-150405")))
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return "", fmt.Errorf("failed to create usage file: %w", err)
+	}
+	defer f.Close()
+
+	if err := a.usage.ExportCSV(f, time.Time{}, time.Now().Add(time.Minute)); err != nil {
+		return "", err
+	}
+	return path, nil
+}